@@ -0,0 +1,409 @@
+package escapes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"strings"
+	"time"
+)
+
+// defaultFrameGap is how long frames without a delay are shown, as browsers
+// do.
+const defaultFrameGap = 100 * time.Millisecond
+
+// AnimationOptions controls how PlayAnimation plays an animation.
+type AnimationOptions struct {
+	// Context stops the playback when it is done. A nil Context never does.
+	Context context.Context
+
+	// Protocol is the graphics protocol the animation is played with.
+	Protocol GraphicsProtocol
+
+	// Loops is the number of times the animation is played, or forever if it
+	// is negative. If it is 0, the number stored in the file is used.
+	Loops int
+
+	// RasterOptions controls how frames are drawn with GraphicsHalfBlocks.
+	// With GraphicsKitty, Width is the number of columns the animation is
+	// scaled to, and the other options are ignored.
+	RasterOptions
+}
+
+// ErrInvalidAnimation is returned by PlayAnimation for data that is not a
+// valid GIF or PNG image, or an image too large to be played.
+var ErrInvalidAnimation = errors.New("escapes: invalid animation")
+
+// PlayAnimation decodes an animated GIF or APNG image from r and plays it at
+// the cursor position, showing each frame for its delay. A still image is
+// shown as a single frame. It returns once the animation has been played,
+// or with the error of opts.Context once it is done, leaving the last frame
+// shown.
+//
+// With GraphicsKitty, all frames are sent at once and played by the
+// terminal, which must support animations, as kitty and WezTerm do. With
+// GraphicsHalfBlocks, each frame is drawn over the previous one, and the
+// cursor is left on the line following the animation. If interactive
+// sequences are disabled, only the first frame is drawn, with HalfBlocks.
+func PlayAnimation(w io.Writer, r io.Reader, opts AnimationOptions) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	anim, err := decodeAnimation(r)
+	if err != nil {
+		return err
+	}
+	loops := opts.Loops
+	if loops == 0 {
+		loops = anim.loops
+	}
+
+	if !Interactive() {
+		_, err := io.WriteString(w, HalfBlocks(anim.frames[0], opts.RasterOptions)+"\n")
+		return err
+	}
+	if opts.Protocol == GraphicsKitty {
+		return playKitty(ctx, w, anim, loops, opts.Width)
+	}
+
+	var lines int
+	for loop := 0; loops <= 0 || loop < loops; loop++ {
+		for i, frame := range anim.frames {
+			lines, err = RedrawInPlace(w, lines, HalfBlocks(frame, opts.RasterOptions))
+			if err != nil {
+				return err
+			}
+			timer := time.NewTimer(anim.delays[i])
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return nil
+}
+
+// playKitty sends the frames of anim as a kitty animation, scaled to cols
+// columns if not 0, and waits for it to be played loops times.
+func playKitty(ctx context.Context, w io.Writer, anim *animation, loops, cols int) error {
+	id := NewKittyID()
+	var (
+		b        strings.Builder
+		buf      bytes.Buffer
+		duration time.Duration
+	)
+	for i, frame := range anim.frames {
+		buf.Reset()
+		if err := png.Encode(&buf, frame); err != nil {
+			return err
+		}
+		if i == 0 {
+			b.WriteString(KittyTransmit(id, buf.Bytes()))
+			b.WriteString(KittyFrameGap(id, 1, anim.delays[0]))
+		} else {
+			b.WriteString(KittyAddFrame(id, buf.Bytes(), anim.delays[i]))
+		}
+		duration += anim.delays[i]
+	}
+	b.WriteString(KittyPlace(id, KittyPlacement{Cols: cols}))
+	if len(anim.frames) > 1 {
+		b.WriteString(KittyAnimate(id, KittyAnimationRun, loops))
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	if len(anim.frames) == 1 {
+		return nil
+	}
+
+	var done <-chan time.Time
+	if loops > 0 {
+		timer := time.NewTimer(duration * time.Duration(loops))
+		defer timer.Stop()
+		done = timer.C
+	}
+	select {
+	case <-ctx.Done():
+		_, err := io.WriteString(w, KittyAnimate(id, KittyAnimationStop, 0))
+		if err != nil {
+			return err
+		}
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+
+// animation is a decoded animation, whose frames are whole images.
+type animation struct {
+	frames []image.Image
+	delays []time.Duration
+	loops  int // Number of times played, or 0 to loop forever
+}
+
+// addFrame appends a copy of canvas shown for delay, or defaultFrameGap if 0.
+func (a *animation) addFrame(canvas *image.NRGBA, delay time.Duration) {
+	frame := image.NewNRGBA(canvas.Rect)
+	copy(frame.Pix, canvas.Pix)
+	if delay <= 0 {
+		delay = defaultFrameGap
+	}
+	a.frames = append(a.frames, frame)
+	a.delays = append(a.delays, delay)
+}
+
+// decodeAnimation decodes an animated GIF or PNG image, or a still one.
+func decodeAnimation(r io.Reader) (*animation, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(8)
+	switch {
+	case bytes.HasPrefix(magic, []byte("GIF8")):
+		// The logical screen bounds the frames, so it is checked before
+		// they are decoded
+		header, _ := br.Peek(10)
+		if len(header) < 10 || !validAnimationSize(int(binary.LittleEndian.Uint16(header[6:])), int(binary.LittleEndian.Uint16(header[8:]))) {
+			return nil, ErrInvalidAnimation
+		}
+		g, err := gif.DecodeAll(br)
+		if err != nil {
+			return nil, err
+		}
+		return gifAnimation(g), nil
+	case bytes.Equal(magic, []byte(pngSignature)):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		return decodeAPNG(data)
+	}
+	return nil, ErrInvalidAnimation
+}
+
+// maxAnimationPixels is the largest area of the images decoded by
+// decodeAnimation, so that a corrupt or malicious header cannot make it
+// allocate gigabytes for the canvas.
+const maxAnimationPixels = 4096 * 4096
+
+// validAnimationSize reports whether an image of the given size may be
+// decoded.
+func validAnimationSize(width, height int) bool {
+	return width > 0 && height > 0 && width <= maxAnimationPixels/height
+}
+
+// gifAnimation composes the frames of g, which may only cover part of the
+// image and depend on the previous ones.
+func gifAnimation(g *gif.GIF) *animation {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+	// LoopCount is 0 to loop forever, -1 to play once, and otherwise the
+	// number of repetitions after the first time
+	a := &animation{loops: g.LoopCount + 1}
+	switch {
+	case g.LoopCount == 0:
+		a.loops = 0
+	case g.LoopCount < 0:
+		a.loops = 1
+	}
+
+	canvas := image.NewNRGBA(bounds)
+	prev := image.NewNRGBA(bounds)
+	for i, img := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			copy(prev.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, img.Bounds(), img, img.Bounds().Min, draw.Over)
+
+		var delay time.Duration
+		if i < len(g.Delay) {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		a.addFrame(canvas, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, prev.Pix)
+		}
+	}
+	return a
+}
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// apngFrame is the control data of a frame of an APNG image (fcTL chunk).
+type apngFrame struct {
+	width, height, x, y int
+	delay               time.Duration
+	dispose, blend      byte
+	data                []byte // Compressed image data, from IDAT or fdAT chunks
+}
+
+// Disposal and blending operations of APNG frames
+const (
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+	apngBlendOver         = 1
+)
+
+// decodeAPNG decodes an animated PNG image. Each frame is decoded as a PNG
+// image of its own, made of the header and palette of the image and the data
+// of the frame. PNG images without animation control data are decoded as a
+// single frame.
+func decodeAPNG(data []byte) (*animation, error) {
+	var (
+		header   []byte // IHDR chunk data
+		shared   []byte // PLTE and tRNS chunks, copied into each frame
+		frames   []*apngFrame
+		frame    *apngFrame
+		animated bool
+		loops    int
+	)
+	for rest := data[len(pngSignature):]; len(rest) >= 12; {
+		n := int(binary.BigEndian.Uint32(rest))
+		if n > len(rest)-12 {
+			return nil, ErrInvalidAnimation
+		}
+		typ, body := string(rest[4:8]), rest[8:8+n]
+		rest = rest[12+n:]
+
+		switch typ {
+		case "IHDR":
+			header = body
+		case "PLTE", "tRNS":
+			shared = append(shared, pngChunk(typ, body)...)
+		case "acTL":
+			if n < 8 {
+				return nil, ErrInvalidAnimation
+			}
+			animated = true
+			loops = int(binary.BigEndian.Uint32(body[4:]))
+		case "fcTL":
+			if n < 26 {
+				return nil, ErrInvalidAnimation
+			}
+			num, den := binary.BigEndian.Uint16(body[20:]), binary.BigEndian.Uint16(body[22:])
+			if den == 0 {
+				den = 100
+			}
+			frame = &apngFrame{
+				width:   int(binary.BigEndian.Uint32(body[4:])),
+				height:  int(binary.BigEndian.Uint32(body[8:])),
+				x:       int(binary.BigEndian.Uint32(body[12:])),
+				y:       int(binary.BigEndian.Uint32(body[16:])),
+				delay:   time.Duration(num) * time.Second / time.Duration(den),
+				dispose: body[24],
+				blend:   body[25],
+			}
+			frames = append(frames, frame)
+		case "IDAT":
+			// The default image is the first frame if a frame control
+			// chunk precedes it
+			if frame != nil {
+				frame.data = append(frame.data, body...)
+			}
+		case "fdAT":
+			if frame != nil && n >= 4 {
+				frame.data = append(frame.data, body[4:]...)
+			}
+		}
+	}
+	if len(header) < 13 {
+		return nil, ErrInvalidAnimation
+	}
+	width, height := int(binary.BigEndian.Uint32(header)), int(binary.BigEndian.Uint32(header[4:]))
+	if !validAnimationSize(width, height) {
+		return nil, ErrInvalidAnimation
+	}
+
+	if !animated || len(frames) == 0 {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		canvas := image.NewNRGBA(img.Bounds())
+		draw.Draw(canvas, canvas.Rect, img, img.Bounds().Min, draw.Src)
+		a := &animation{loops: 1}
+		a.addFrame(canvas, 0)
+		return a, nil
+	}
+
+	bounds := image.Rect(0, 0, width, height)
+	canvas := image.NewNRGBA(bounds)
+	prev := image.NewNRGBA(bounds)
+	a := &animation{loops: loops}
+	for i, f := range frames {
+		if len(f.data) == 0 {
+			continue
+		}
+		rect := image.Rect(f.x, f.y, f.x+f.width, f.y+f.height)
+		if f.width <= 0 || f.height <= 0 || f.x < 0 || f.y < 0 || !rect.In(bounds) {
+			return nil, ErrInvalidAnimation
+		}
+		// The header of each frame has the dimensions of the frame
+		ihdr := append([]byte(nil), header...)
+		binary.BigEndian.PutUint32(ihdr, uint32(f.width))
+		binary.BigEndian.PutUint32(ihdr[4:], uint32(f.height))
+		var b bytes.Buffer
+		b.WriteString(pngSignature)
+		b.Write(pngChunk("IHDR", ihdr))
+		b.Write(shared)
+		b.Write(pngChunk("IDAT", f.data))
+		b.Write(pngChunk("IEND", nil))
+		img, err := png.Decode(&b)
+		if err != nil {
+			return nil, err
+		}
+
+		dispose := f.dispose
+		if dispose == apngDisposePrevious && i == 0 {
+			dispose = apngDisposeBackground
+		}
+		if dispose == apngDisposePrevious {
+			copy(prev.Pix, canvas.Pix)
+		}
+		op := draw.Src
+		if f.blend == apngBlendOver {
+			op = draw.Over
+		}
+		draw.Draw(canvas, rect, img, image.Point{}, op)
+		a.addFrame(canvas, f.delay)
+
+		switch dispose {
+		case apngDisposeBackground:
+			draw.Draw(canvas, rect, image.Transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			copy(canvas.Pix, prev.Pix)
+		}
+	}
+	if len(a.frames) == 0 {
+		return nil, ErrInvalidAnimation
+	}
+	return a, nil
+}
+
+// pngChunk returns a PNG chunk of the given type and data.
+func pngChunk(typ string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], typ)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
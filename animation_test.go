@@ -0,0 +1,127 @@
+package escapes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+// apngData returns an APNG image of the given size with a frame for each
+// rectangle, as x, y, width and height, filled with translucent gray, which
+// png.Encode encodes in RGBA like the header.
+func apngData(t *testing.T, width, height uint32, rects ...[4]uint32) []byte {
+	t.Helper()
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr, width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8], ihdr[9] = 8, 6 // 8-bit RGBA
+
+	var b bytes.Buffer
+	b.WriteString(pngSignature)
+	b.Write(pngChunk("IHDR", ihdr))
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl, uint32(len(rects)))
+	b.Write(pngChunk("acTL", actl))
+	for i, r := range rects {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl, uint32(2*i))
+		binary.BigEndian.PutUint32(fctl[4:], r[2])
+		binary.BigEndian.PutUint32(fctl[8:], r[3])
+		binary.BigEndian.PutUint32(fctl[12:], r[0])
+		binary.BigEndian.PutUint32(fctl[16:], r[1])
+		b.Write(pngChunk("fcTL", fctl))
+
+		img := image.NewNRGBA(image.Rect(0, 0, int(min(r[2], 16)), int(min(r[3], 16))))
+		for i := range img.Pix {
+			img.Pix[i] = 0x80
+		}
+		var frame bytes.Buffer
+		if err := png.Encode(&frame, img); err != nil {
+			t.Fatal(err)
+		}
+		data := frame.Bytes()[len(pngSignature):]
+		for len(data) >= 12 {
+			n := binary.BigEndian.Uint32(data)
+			if string(data[4:8]) == "IDAT" {
+				fdat := binary.BigEndian.AppendUint32(nil, uint32(2*i+1))
+				b.Write(pngChunk("fdAT", append(fdat, data[8:8+n]...)))
+			}
+			data = data[12+n:]
+		}
+	}
+	b.Write(pngChunk("IEND", nil))
+	return b.Bytes()
+}
+
+func TestDecodeAPNG(t *testing.T) {
+	a, err := decodeAnimation(bytes.NewReader(apngData(t, 4, 4, [4]uint32{0, 0, 4, 4}, [4]uint32{1, 1, 2, 2})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.frames) != 2 || a.frames[0].Bounds() != image.Rect(0, 0, 4, 4) {
+		t.Errorf("decoded %d frames of %v, want 2 of 4x4", len(a.frames), a.frames[0].Bounds())
+	}
+}
+
+func TestDecodeAnimationInvalidSize(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"APNG overflowing", apngData(t, 0x7FFFFFFF, 0x7FFFFFFF, [4]uint32{0, 0, 1, 1})},
+		{"APNG too large", apngData(t, 65535, 65535, [4]uint32{0, 0, 1, 1})},
+		{"APNG empty", apngData(t, 0, 4, [4]uint32{0, 0, 1, 1})},
+		{"APNG frame outside", apngData(t, 4, 4, [4]uint32{2, 2, 4, 4})},
+		{"APNG frame overflowing", apngData(t, 4, 4, [4]uint32{0xFFFFFFFF, 0, 1, 1})},
+		{"PNG too large", apngData(t, 65535, 65535)},
+		{"GIF too large", gifData(t, 65535, 65535, 0)},
+	}
+	for _, tt := range tests {
+		if _, err := decodeAnimation(bytes.NewReader(tt.data)); err != ErrInvalidAnimation {
+			t.Errorf("%s: decodeAnimation() error = %v, want ErrInvalidAnimation", tt.name, err)
+		}
+	}
+}
+
+func TestGIFAnimationLoops(t *testing.T) {
+	tests := []struct {
+		loopCount, want int
+	}{
+		{0, 0},
+		{-1, 1},
+		{1, 2},
+		{5, 6},
+	}
+	for _, tt := range tests {
+		a, err := decodeAnimation(bytes.NewReader(gifData(t, 1, 1, tt.loopCount)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.loops != tt.want {
+			t.Errorf("LoopCount %d: loops = %d, want %d", tt.loopCount, a.loops, tt.want)
+		}
+	}
+}
+
+// gifData returns a GIF image with a logical screen of the given size and two
+// 1x1 frames, as the loop count is only encoded for several frames.
+func gifData(t *testing.T, width, height, loopCount int) []byte {
+	t.Helper()
+	palette := color.Palette{color.Black, color.White}
+	frame := image.NewPaletted(image.Rect(0, 0, 1, 1), palette)
+	g := &gif.GIF{
+		Image:     []*image.Paletted{frame, frame},
+		Delay:     []int{0, 0},
+		LoopCount: loopCount,
+		Config:    image.Config{ColorModel: palette, Width: width, Height: height},
+	}
+	var b bytes.Buffer
+	if err := gif.EncodeAll(&b, g); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,143 @@
+package escapes
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Sizes of lines (DECDHL, DECSWL and DECDWL), which apply to the whole line
+// the cursor is on. Double-size lines hold half as many characters. A
+// double-height line is made of its top half followed by its bottom half,
+// both with the same text.
+const (
+	LineDoubleHeightTop    = "\u001B#3"
+	LineDoubleHeightBottom = "\u001B#4"
+	LineSingleWidth        = "\u001B#5"
+	LineDoubleWidth        = "\u001B#6"
+)
+
+// SupportsDoubleSize reports whether the terminal is known to support
+// double-size lines, guessed from the environment: xterm, VTE-based terminals
+// such as GNOME Terminal, Konsole, Windows Terminal, mintty and Apple's
+// Terminal do, while tmux does not.
+func SupportsDoubleSize() bool {
+	if InTmux() {
+		return false
+	}
+	for _, env := range []string{"XTERM_VERSION", "VTE_VERSION", "KONSOLE_VERSION", "WT_SESSION"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "mintty", "Apple_Terminal":
+		return true
+	}
+	return false
+}
+
+// Banner returns text as a prominent header in style, made of double-height
+// lines if interactive sequences are enabled and the terminal supports them
+// (see SupportsDoubleSize), or else drawn with block characters in a small
+// font, three lines high, which only has ASCII letters, digits and common
+// punctuation. Lines end with newlines.
+func Banner(text string, style Style) string {
+	if Interactive() && SupportsDoubleSize() {
+		text = style.Render(text)
+		return LineDoubleHeightTop + text + "\n" + LineDoubleHeightBottom + text + "\n"
+	}
+
+	var rows [3]strings.Builder
+	for i, r := range Strip(text) {
+		glyph, ok := bannerFont[unicode.ToUpper(r)]
+		if !ok {
+			glyph = bannerFont['?']
+		}
+		pixels := strings.Split(glyph, "/")
+		for y := range rows {
+			if i > 0 {
+				rows[y].WriteByte(' ')
+			}
+			// Each row of cells shows two rows of pixels
+			for x := 0; x < 3; x++ {
+				upper := pixels[2*y][x] == '#'
+				lower := 2*y+1 < len(pixels) && pixels[2*y+1][x] == '#'
+				switch {
+				case upper && lower:
+					rows[y].WriteString("█")
+				case upper:
+					rows[y].WriteString("▀")
+				case lower:
+					rows[y].WriteString("▄")
+				default:
+					rows[y].WriteByte(' ')
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := range rows {
+		b.WriteString(style.Render(strings.TrimRight(rows[i].String(), " ")) + "\n")
+	}
+	return b.String()
+}
+
+// bannerFont is the font of Banner, with glyphs of 3 by 5 pixels whose rows
+// are separated by slashes.
+var bannerFont = map[rune]string{
+	'A':  ".#./#.#/###/#.#/#.#",
+	'B':  "##./#.#/##./#.#/##.",
+	'C':  ".##/#../#../#../.##",
+	'D':  "##./#.#/#.#/#.#/##.",
+	'E':  "###/#../##./#../###",
+	'F':  "###/#../##./#../#..",
+	'G':  ".##/#../#.#/#.#/.##",
+	'H':  "#.#/#.#/###/#.#/#.#",
+	'I':  "###/.#./.#./.#./###",
+	'J':  "..#/..#/..#/#.#/.#.",
+	'K':  "#.#/#.#/##./#.#/#.#",
+	'L':  "#../#../#../#../###",
+	'M':  "#.#/###/###/#.#/#.#",
+	'N':  "##./#.#/#.#/#.#/#.#",
+	'O':  ".#./#.#/#.#/#.#/.#.",
+	'P':  "##./#.#/##./#../#..",
+	'Q':  ".#./#.#/#.#/##./.##",
+	'R':  "##./#.#/##./#.#/#.#",
+	'S':  ".##/#../.#./..#/##.",
+	'T':  "###/.#./.#./.#./.#.",
+	'U':  "#.#/#.#/#.#/#.#/###",
+	'V':  "#.#/#.#/#.#/#.#/.#.",
+	'W':  "#.#/#.#/###/###/#.#",
+	'X':  "#.#/#.#/.#./#.#/#.#",
+	'Y':  "#.#/#.#/.#./.#./.#.",
+	'Z':  "###/..#/.#./#../###",
+	'0':  "###/#.#/#.#/#.#/###",
+	'1':  ".#./##./.#./.#./###",
+	'2':  "##./..#/.#./#../###",
+	'3':  "##./..#/.#./..#/##.",
+	'4':  "#.#/#.#/###/..#/..#",
+	'5':  "###/#../##./..#/##.",
+	'6':  ".##/#../###/#.#/###",
+	'7':  "###/..#/.#./.#./.#.",
+	'8':  "###/#.#/###/#.#/###",
+	'9':  "###/#.#/###/..#/##.",
+	' ':  ".../.../.../.../...",
+	'!':  ".#./.#./.#./.../.#.",
+	'?':  "##./..#/.#./.../.#.",
+	'.':  ".../.../.../.../.#.",
+	',':  ".../.../.../.#./#..",
+	':':  ".../.#./.../.#./...",
+	'-':  ".../.../###/.../...",
+	'+':  ".../.#./###/.#./...",
+	'=':  ".../###/.../###/...",
+	'_':  ".../.../.../.../###",
+	'/':  "..#/..#/.#./#../#..",
+	'(':  ".#./#../#../#../.#.",
+	')':  ".#./..#/..#/..#/.#.",
+	'#':  "#.#/###/#.#/###/#.#",
+	'*':  ".../#.#/.#./#.#/...",
+	'\'': ".#./.#./.../.../...",
+	'"':  "#.#/#.#/.../.../...",
+}
@@ -0,0 +1,63 @@
+package escapes
+
+import (
+	"io"
+	"strconv"
+	"time"
+)
+
+// Sequences related to the terminal bell
+const (
+	// Bell rings the terminal bell, which may be audible or visual depending
+	// on the terminal's settings.
+	Bell = Bel
+
+	// ReverseVideoEnable and ReverseVideoDisable swap the foreground and
+	// background colors of the whole screen (DECSCNM).
+	ReverseVideoEnable  = Esc + "?5h"
+	ReverseVideoDisable = Esc + "?5l"
+
+	// MarginBellEnable and MarginBellDisable control the bell that rings when
+	// the cursor nears the right margin while typing. Supported by xterm.
+	MarginBellEnable  = Esc + "?44h"
+	MarginBellDisable = Esc + "?44l"
+)
+
+// VisualBellDuration is how long VisualBell keeps the screen reversed.
+var VisualBellDuration = 100 * time.Millisecond
+
+// VisualBell flashes the screen by briefly reversing its colors, as an
+// alternative to the bell for users who disable audible bells. It blocks for
+// VisualBellDuration. The screen is left in normal video afterwards.
+func VisualBell(w io.Writer) error {
+	if _, err := io.WriteString(w, ReverseVideoEnable); err != nil {
+		return err
+	}
+	time.Sleep(VisualBellDuration)
+	_, err := io.WriteString(w, ReverseVideoDisable)
+	return err
+}
+
+// BellVolume returns an escape sequence to set the volume of the bell
+// (DECSWBV), from 0 (off) to 7 (loudest); most terminals only distinguish off,
+// low (1-3) and high (4-7). Supported by xterm.
+func BellVolume(volume int) string {
+	return Esc + strconv.Itoa(bellVolume(volume)) + " t"
+}
+
+// MarginBellVolume returns an escape sequence to set the volume of the margin
+// bell (DECSMBV), using the same scale as BellVolume. Supported by xterm.
+func MarginBellVolume(volume int) string {
+	return Esc + strconv.Itoa(bellVolume(volume)) + " u"
+}
+
+// bellVolume clamps a volume and converts it to the parameter of DECSWBV and
+// DECSMBV, which ranges from 1 (off) to 8.
+func bellVolume(volume int) int {
+	if volume < 0 {
+		volume = 0
+	} else if volume > 7 {
+		volume = 7
+	}
+	return volume + 1
+}
@@ -0,0 +1,86 @@
+package escapes
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Buffer accumulates text and escape sequences in memory until Flush is
+// called, which writes everything in a single call to the underlying writer.
+// Unlike bufio.Writer, a Buffer never flushes on its own, so an escape
+// sequence is never split across two writes; a whole frame can be buffered and
+// sent at once.
+//
+// A Buffer is not safe for concurrent use.
+type Buffer struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewBuffer returns a Buffer that flushes to w.
+func NewBuffer(w io.Writer) *Buffer {
+	return &Buffer{w: w}
+}
+
+// Write appends p to the buffer. It always succeeds.
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// WriteString appends s to the buffer. It always succeeds.
+func (b *Buffer) WriteString(s string) (int, error) {
+	b.buf = append(b.buf, s...)
+	return len(s), nil
+}
+
+// WriteByte appends c to the buffer. It always succeeds.
+func (b *Buffer) WriteByte(c byte) error {
+	b.buf = append(b.buf, c)
+	return nil
+}
+
+// WriteRune appends the UTF-8 encoding of r to the buffer. It always succeeds.
+func (b *Buffer) WriteRune(r rune) (int, error) {
+	n := len(b.buf)
+	b.buf = utf8.AppendRune(b.buf, r)
+	return len(b.buf) - n, nil
+}
+
+// Len returns the number of bytes waiting to be flushed.
+func (b *Buffer) Len() int {
+	return len(b.buf)
+}
+
+// Bytes returns the bytes waiting to be flushed. The slice is only valid until
+// the next modification of the buffer.
+func (b *Buffer) Bytes() []byte {
+	return b.buf
+}
+
+// Reset discards the buffered bytes without writing them.
+func (b *Buffer) Reset() {
+	b.buf = b.buf[:0]
+}
+
+// Flush writes the buffered bytes to the underlying writer in a single call,
+// then empties the buffer. If the write fails, the bytes that were not written
+// are kept, so that Flush can be retried.
+func (b *Buffer) Flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+
+	n, err := b.w.Write(b.buf)
+	if err == nil && n < len(b.buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		if n > 0 && n < len(b.buf) {
+			b.buf = b.buf[:copy(b.buf, b.buf[n:])]
+		}
+		return err
+	}
+	b.buf = b.buf[:0]
+	return nil
+}
@@ -0,0 +1,39 @@
+package escapes
+
+import (
+	"io"
+	"strings"
+)
+
+// C1 returns seq with its introducer, and its string terminator if it has one,
+// encoded as an 8-bit C1 control instead of ESC followed by a character, such
+// as 0x9B for CSI and 0x9D for OSC, saving a byte per control. Sequences with
+// no C1 form, such as ESC 7, are returned as they are.
+//
+// Most terminals only accept 8-bit controls when not in UTF-8 mode, since
+// these bytes are not valid UTF-8 on their own, so 7-bit controls remain the
+// default throughout this package.
+func C1(seq string) string {
+	if len(seq) < 2 || seq[0] != AsciiEscape || seq[1] < 0x40 || seq[1] > 0x5F {
+		return seq
+	}
+	intro := seq[1]
+	body := seq[2:]
+	switch intro {
+	case ']', 'P', '_', '^', 'X':
+		if strings.HasSuffix(body, St) {
+			body = body[:len(body)-2] + "\x9C"
+		}
+	}
+	return string([]byte{intro + 0x40}) + body
+}
+
+// NewC1Writer returns a writer that rewrites the escape sequences written to
+// it with 8-bit C1 controls, as with C1, for links to terminals that accept
+// them where bandwidth matters. The writer must be flushed with Flush once
+// the output is complete.
+func NewC1Writer(w io.Writer) *TransformWriter {
+	return NewSequenceWriter(w, func(t Token) string {
+		return C1(t.Value)
+	})
+}
@@ -0,0 +1,135 @@
+package escapes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Image formats whose dimensions ImageFitCells can read
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ReportCellSizeITerm requests the size of a character cell from iTerm2,
+// replied to as OSC 1337 ; ReportCellSize=height;width;scale ST, in points.
+const ReportCellSizeITerm = Osc + "1337;ReportCellSize" + Bel
+
+// DefaultCellSize is the cell size assumed until another is set, with the
+// usual aspect ratio of 1:2.
+var DefaultCellSize = PixelSize{Width: 8, Height: 16}
+
+var (
+	cellSizeMu sync.RWMutex
+	cellSize   = DefaultCellSize
+)
+
+// CellSize returns the size of a character cell in pixels used to lay out
+// images, as set by SetCellSize or DetectCellSize.
+func CellSize() PixelSize {
+	cellSizeMu.RLock()
+	defer cellSizeMu.RUnlock()
+	return cellSize
+}
+
+// SetCellSize sets the size of a character cell in pixels used to lay out
+// images. Sizes that are not positive are ignored.
+func SetCellSize(size PixelSize) {
+	if size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+	cellSizeMu.Lock()
+	cellSize = size
+	cellSizeMu.Unlock()
+}
+
+// DetectCellSize queries the size of a character cell with both ReportCellSize
+// and ReportCellSizeITerm, using whichever reply arrives first, and sets it
+// with SetCellSize. See Query for the requirements on w and r.
+func DetectCellSize(w io.Writer, r io.Reader, timeout time.Duration) (*PixelSize, error) {
+	var size *PixelSize
+	_, err := Query(w, r, ReportCellSize+ReportCellSizeITerm, func(b []byte) bool {
+		if s, err := ParseCellSize(b); err == nil {
+			size = s
+		} else if s, err := ParseITermCellSize(b); err == nil {
+			size = s
+		}
+		return size != nil
+	}, timeout)
+	if err != nil {
+		return nil, err
+	}
+	SetCellSize(*size)
+	return size, nil
+}
+
+// ParseITermCellSize parses the reply to ReportCellSizeITerm, converting the
+// size from points to pixels.
+func ParseITermCellSize(reply []byte) (*PixelSize, error) {
+	const prefix = Osc + "1337;ReportCellSize="
+	i := bytes.Index(reply, []byte(prefix))
+	if i < 0 {
+		return nil, ErrInvalidReply
+	}
+	body := string(reply[i+len(prefix):])
+	end := strings.IndexAny(body, Bel+"\u001B")
+	if end < 0 {
+		return nil, ErrInvalidReply
+	}
+
+	fields := strings.Split(body[:end], ";")
+	if len(fields) < 2 {
+		return nil, ErrInvalidReply
+	}
+	vals := []float64{0, 0, 1}
+	for i, f := range fields {
+		if i >= len(vals) {
+			break
+		}
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, ErrInvalidReply
+		}
+		vals[i] = v
+	}
+	return &PixelSize{
+		Width:  int(math.Round(vals[1] * vals[2])),
+		Height: int(math.Round(vals[0] * vals[2])),
+	}, nil
+}
+
+// ImageFitCells returns an escape sequence to display an image scaled to fit
+// within an area of cols by rows cells, preserving its aspect ratio. The size
+// in pixels is computed from the image's dimensions and CellSize, so the
+// image is scaled exactly rather than left for the terminal to guess. If the
+// image's format is not recognized, the terminal is left to fit the image in
+// the area.
+func ImageFitCells(img []byte, cols, rows int) string {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 {
+		return Osc + "1337;File=inline=1;size=" + strconv.Itoa(len(img)) +
+			";width=" + strconv.Itoa(cols) + ";height=" + strconv.Itoa(rows) + ":" +
+			base64.StdEncoding.EncodeToString(img) + oscEnd()
+	}
+
+	w, h := fitPixels(cfg.Width, cfg.Height, cols, rows, CellSize())
+	return Osc + "1337;File=inline=1;size=" + strconv.Itoa(len(img)) +
+		";width=" + strconv.Itoa(w) + "px;height=" + strconv.Itoa(h) + "px:" +
+		base64.StdEncoding.EncodeToString(img) + oscEnd()
+}
+
+// fitPixels scales an image of imgW by imgH pixels to fit in cols by rows
+// cells of the given size, preserving its aspect ratio.
+func fitPixels(imgW, imgH, cols, rows int, cell PixelSize) (w, h int) {
+	boxW, boxH := float64(cols*cell.Width), float64(rows*cell.Height)
+	scale := math.Min(boxW/float64(imgW), boxH/float64(imgH))
+	w = int(math.Max(1, math.Floor(float64(imgW)*scale)))
+	h = int(math.Max(1, math.Floor(float64(imgH)*scale)))
+	return w, h
+}
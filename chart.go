@@ -0,0 +1,97 @@
+package escapes
+
+import (
+	"math"
+	"strings"
+)
+
+// ChartScale is the color scale used by the chart helpers, ordered from the
+// lowest to the highest values. Set it to nil to draw charts without colors.
+var ChartScale = []string{TextColorGreen, TextColorYellow, TextColorRed}
+
+// Block elements of increasing height and width, in eighths
+var (
+	sparkRunes = []rune("▁▂▃▄▅▆▇█")
+	barRunes   = []rune(" ▏▎▍▌▋▊▉█")
+)
+
+// Sparkline returns a single-line chart of values, one column per value, where
+// the height of each block is relative to the minimum and maximum values. NaN
+// and infinite values are drawn as blanks.
+func Sparkline(values []float64) string {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+
+	var (
+		b    strings.Builder
+		prev string
+	)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			b.WriteByte(' ')
+			continue
+		}
+
+		frac := 0.5
+		if hi > lo {
+			frac = (v - lo) / (hi - lo)
+		}
+		if c := chartColor(frac); c != prev {
+			b.WriteString(c)
+			prev = c
+		}
+		b.WriteRune(sparkRunes[int(math.Round(frac*float64(len(sparkRunes)-1)))])
+	}
+	if prev != "" {
+		b.WriteString(ColorReset)
+	}
+	return b.String()
+}
+
+// HBar returns a horizontal bar exactly width columns wide, filled in
+// proportion to value/max with a resolution of an eighth of a column. The bar
+// is empty if value/max is NaN, such as when both are infinite.
+func HBar(value, max float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	var frac float64
+	if f := value / max; max > 0 && !math.IsNaN(f) {
+		frac = math.Max(0, math.Min(1, f))
+	}
+	eighths := int(math.Round(frac * float64(width*8)))
+	full, part := eighths/8, eighths%8
+
+	var b strings.Builder
+	c := chartColor(frac)
+	b.WriteString(c)
+	b.WriteString(strings.Repeat(string(barRunes[8]), full))
+	if part > 0 {
+		b.WriteRune(barRunes[part])
+		full++
+	}
+	if c != "" {
+		b.WriteString(ColorReset)
+	}
+	b.WriteString(strings.Repeat(" ", width-full))
+	return b.String()
+}
+
+// chartColor returns the color from ChartScale for a value in [0, 1].
+func chartColor(frac float64) string {
+	if len(ChartScale) == 0 {
+		return ""
+	}
+	i := int(frac * float64(len(ChartScale)))
+	if i >= len(ChartScale) {
+		i = len(ChartScale) - 1
+	}
+	return ChartScale[i]
+}
@@ -0,0 +1,58 @@
+package escapes
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSparkline(t *testing.T) {
+	defer func(scale []string) { ChartScale = scale }(ChartScale)
+	ChartScale = nil
+
+	inf := math.Inf(1)
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{nil, ""},
+		{[]float64{0, 7}, "▁█"},
+		{[]float64{1, 1}, "▅▅"},
+		{[]float64{0, math.NaN(), 7}, "▁ █"},
+		{[]float64{0, inf, -inf, 7}, "▁  █"},
+		{[]float64{inf, -inf}, "  "},
+	}
+	for _, tt := range tests {
+		if got := Sparkline(tt.values); got != tt.want {
+			t.Errorf("Sparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestHBar(t *testing.T) {
+	defer func(scale []string) { ChartScale = scale }(ChartScale)
+	ChartScale = nil
+
+	inf := math.Inf(1)
+	tests := []struct {
+		value, max float64
+		width      int
+		want       string
+	}{
+		{5, 10, 4, "██  "},
+		{1, 8, 1, "▏"},
+		{20, 10, 2, "██"},
+		{-1, 10, 2, "  "},
+		{math.NaN(), 10, 2, "  "},
+		{inf, 10, 2, "██"},
+		{-inf, 10, 2, "  "},
+		{inf, inf, 2, "  "},
+		{5, inf, 2, "  "},
+		{5, 0, 2, "  "},
+		{5, 10, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := HBar(tt.value, tt.max, tt.width); got != tt.want {
+			t.Errorf("HBar(%v, %v, %d) = %q, want %q", tt.value, tt.max, tt.width, got, tt.want)
+		}
+	}
+}
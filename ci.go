@@ -0,0 +1,70 @@
+package escapes
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+)
+
+// ErrNotInteractive is returned by queries when interactive sequences are
+// disabled, such as on continuous integration services.
+var ErrNotInteractive = errors.New("escapes: interactive sequences are disabled")
+
+// Environment variables set by continuous integration services
+var ciEnvs = []string{
+	"CI", "CONTINUOUS_INTEGRATION",
+	"GITHUB_ACTIONS", "GITLAB_CI", "CIRCLECI", "TRAVIS", "APPVEYOR",
+	"BUILDKITE", "DRONE", "JENKINS_URL", "TEAMCITY_VERSION", "TF_BUILD",
+	"BITBUCKET_BUILD_NUMBER", "CODEBUILD_BUILD_ID", "SEMAPHORE",
+	"WOODPECKER_CI", "GITEA_ACTIONS",
+}
+
+// IsCI reports whether the program runs on a known continuous integration
+// service, based on the environment variables they set.
+func IsCI() bool {
+	for _, env := range ciEnvs {
+		if v, ok := os.LookupEnv(env); ok && v != "false" && v != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// ciProfile returns the colors supported in the logs of the continuous
+// integration service the program runs on, which are usually not terminals.
+func ciProfile() Profile {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true" || os.Getenv("GITEA_ACTIONS") == "true":
+		return ProfileTrueColor
+	case os.Getenv("GITLAB_CI") != "", os.Getenv("BUILDKITE") != "",
+		os.Getenv("CIRCLECI") != "", os.Getenv("TRAVIS") != "",
+		os.Getenv("APPVEYOR") != "", os.Getenv("DRONE") != "":
+		return ProfileANSI
+	default:
+		return ProfileNone
+	}
+}
+
+var interactive = func() int32 {
+	if IsCI() {
+		return 0
+	}
+	return 1
+}()
+
+// Interactive reports whether interactive sequences are enabled: cursor
+// movement, live updates and queries. They are disabled on continuous
+// integration services, where queries never get a reply and cursor movement
+// garbles the logs, while colors are kept if the service supports them.
+func Interactive() bool {
+	return atomic.LoadInt32(&interactive) != 0
+}
+
+// SetInteractive overrides whether interactive sequences are enabled.
+func SetInteractive(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&interactive, v)
+}
@@ -0,0 +1,39 @@
+package escapes
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIsCI(t *testing.T) {
+	tests := []struct {
+		env, value string
+		want       bool
+	}{
+		{"", "", false},
+		{"CI", "true", true},
+		{"CI", "false", false},
+		{"CI", "0", false},
+		{"GITHUB_ACTIONS", "true", true},
+		{"BITBUCKET_BUILD_NUMBER", "12", true},
+		// Generic names also used outside of continuous integration
+		{"BUILD_NUMBER", "12", false},
+		{"RUN_ID", "12", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env+"="+tt.value, func(t *testing.T) {
+			// t.Setenv restores the variables after the test, even those
+			// unset here
+			for _, env := range ciEnvs {
+				t.Setenv(env, "")
+				os.Unsetenv(env)
+			}
+			if tt.env != "" {
+				t.Setenv(tt.env, tt.value)
+			}
+			if got := IsCI(); got != tt.want {
+				t.Errorf("IsCI() with %s=%q = %v, want %v", tt.env, tt.value, got, tt.want)
+			}
+		})
+	}
+}
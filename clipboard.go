@@ -0,0 +1,105 @@
+package escapes
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// SetClipboardSequence returns an escape sequence to set the clipboard to
+// text (OSC 52). Many terminals ignore it, or only accept it once the user
+// allows it; see SetClipboard.
+func SetClipboardSequence(text string) string {
+	return Osc + "52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + oscEnd()
+}
+
+// Clipboard sets the contents of the clipboard of the system.
+type Clipboard interface {
+	SetText(text string) error
+}
+
+// ClipboardCommand is a Clipboard that runs a command, such as pbcopy, with
+// the text as its standard input.
+type ClipboardCommand []string
+
+// SetText runs the command with text as its standard input.
+func (c ClipboardCommand) SetText(text string) error {
+	if len(c) == 0 {
+		return ErrNotSupported
+	}
+	cmd := exec.Command(c[0], c[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// SystemClipboard is a Clipboard that runs the first installed command of the
+// operating system: pbcopy on macOS, clip.exe on Windows and WSL, and wl-copy,
+// xclip or xsel elsewhere, depending on the display server. It returns
+// ErrNotSupported if none is installed.
+var SystemClipboard Clipboard = systemClipboard{}
+
+// ClipboardFallback is the Clipboard that SetClipboard uses when the terminal
+// is not known to support OSC 52. It is SystemClipboard by default; set it to
+// nil to never run commands.
+var ClipboardFallback = SystemClipboard
+
+// SetClipboard sets the clipboard to text, by writing SetClipboardSequence to
+// w if interactive sequences are enabled and the terminal is not known to
+// ignore it, as VTE-based terminals, Konsole and Apple's Terminal do, or else
+// with ClipboardFallback. Over SSH, the sequence is always used, since
+// commands would set the clipboard of the remote host. It returns
+// ErrNotSupported if neither can be used.
+func SetClipboard(w io.Writer, text string) error {
+	if Interactive() && (os.Getenv("SSH_TTY") != "" || !ignoresOSC52()) {
+		_, err := io.WriteString(w, SetClipboardSequence(text))
+		return err
+	}
+	if ClipboardFallback == nil {
+		return ErrNotSupported
+	}
+	return ClipboardFallback.SetText(text)
+}
+
+// ignoresOSC52 reports whether the terminal is known to ignore OSC 52,
+// guessed from the environment.
+func ignoresOSC52() bool {
+	if InTmux() {
+		// tmux sets the clipboard of the terminal itself
+		return false
+	}
+	return os.Getenv("VTE_VERSION") != "" || os.Getenv("KONSOLE_VERSION") != "" ||
+		os.Getenv("TERM_PROGRAM") == "Apple_Terminal"
+}
+
+type systemClipboard struct{}
+
+func (systemClipboard) SetText(text string) error {
+	var commands []ClipboardCommand
+	switch runtime.GOOS {
+	case "darwin":
+		commands = []ClipboardCommand{{"pbcopy"}}
+	case "windows":
+		commands = []ClipboardCommand{{"clip.exe"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			commands = append(commands, ClipboardCommand{"wl-copy"})
+		}
+		if os.Getenv("DISPLAY") != "" {
+			commands = append(commands,
+				ClipboardCommand{"xclip", "-selection", "clipboard"},
+				ClipboardCommand{"xsel", "--clipboard", "--input"})
+		}
+		// Windows' clipboard from WSL
+		commands = append(commands, ClipboardCommand{"clip.exe"})
+	}
+
+	for _, c := range commands {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c.SetText(text)
+		}
+	}
+	return ErrNotSupported
+}
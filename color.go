@@ -0,0 +1,278 @@
+package escapes
+
+import (
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Profile is the range of colors supported by a terminal.
+type Profile int
+
+// Color profiles, from the most to the least restrictive. Their values match
+// the color levels of the FORCE_COLOR convention.
+const (
+	ProfileNone      Profile = iota // No colors at all
+	ProfileANSI                     // The 16 basic ANSI colors
+	ProfileANSI256                  // The xterm 256 color palette
+	ProfileTrueColor                // 24-bit RGB colors
+)
+
+var (
+	profileOnce    sync.Once
+	currentProfile int32
+)
+
+// CurrentProfile returns the color profile that styles are rendered with. It is
+// detected from the environment on first use, and is ProfileNone if the
+// standard output is not a terminal, unless colors are forced with FORCE_COLOR
+// or the output goes to the logs of a continuous integration service known to
+// support them.
+func CurrentProfile() Profile {
+	profileOnce.Do(func() {
+		atomic.StoreInt32(&currentProfile, int32(initialProfile()))
+	})
+	return Profile(atomic.LoadInt32(&currentProfile))
+}
+
+// SetColorLevel overrides the color profile that styles are rendered with,
+// using the levels of the FORCE_COLOR convention: 0 disables colors, 1 allows
+// the 16 basic colors, 2 the 256 color palette and 3 24-bit colors. Levels
+// out of range are clamped.
+func SetColorLevel(level int) {
+	if level < int(ProfileNone) {
+		level = int(ProfileNone)
+	} else if level > int(ProfileTrueColor) {
+		level = int(ProfileTrueColor)
+	}
+	// The profile set is not replaced by the detected one
+	profileOnce.Do(func() {})
+	atomic.StoreInt32(&currentProfile, int32(level))
+}
+
+func initialProfile() Profile {
+	if p, ok := forcedProfile(); ok {
+		return p
+	}
+	if !IsTerminal(os.Stdout) && !IsCygwinTerminal(os.Stdout) {
+		if IsCI() {
+			return ciProfile()
+		}
+		return ProfileNone
+	}
+	return DetectProfile()
+}
+
+// forcedProfile returns the profile requested with the FORCE_COLOR
+// environment variable, if it is set to a valid value. Setting it without a
+// level, or to "true", forces at least the basic colors.
+func forcedProfile() (Profile, bool) {
+	v, ok := os.LookupEnv("FORCE_COLOR")
+	if !ok {
+		return 0, false
+	}
+
+	switch strings.ToLower(v) {
+	case "0", "false":
+		return ProfileNone, true
+	case "", "1", "true":
+		if p := detectEnvProfile(); p > ProfileANSI {
+			return p, true
+		}
+		return ProfileANSI, true
+	case "2":
+		return ProfileANSI256, true
+	case "3":
+		return ProfileTrueColor, true
+	default:
+		return 0, false
+	}
+}
+
+// DetectProfile guesses the color profile of the terminal from the
+// FORCE_COLOR, NO_COLOR, COLORTERM and TERM environment variables.
+func DetectProfile() Profile {
+	if p, ok := forcedProfile(); ok {
+		return p
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return ProfileNone
+	}
+	return detectEnvProfile()
+}
+
+// detectEnvProfile guesses the color profile from the terminal type.
+func detectEnvProfile() Profile {
+	term := os.Getenv("TERM")
+	switch colorTerm := strings.ToLower(os.Getenv("COLORTERM")); {
+	case colorTerm == "truecolor" || colorTerm == "24bit":
+		return ProfileTrueColor
+	case term == "dumb":
+		return ProfileNone
+	case strings.Contains(term, "256color"):
+		return ProfileANSI256
+	case strings.Contains(term, "truecolor") || strings.Contains(term, "direct"):
+		return ProfileTrueColor
+	default:
+		return ProfileANSI
+	}
+}
+
+type colorKind uint8
+
+const (
+	colorDefault colorKind = iota
+	colorANSI
+	colorIndexed
+	colorRGB
+)
+
+// Color is a terminal color: either the terminal's default color, one of the
+// 16 basic ANSI colors, an index into the 256 color palette, or a 24-bit RGB
+// color. The zero value is the default color.
+//
+// Color implements color.Color, using the xterm palette for ANSI and indexed
+// colors, so that it can be used with image/color.
+type Color struct {
+	kind    colorKind
+	index   uint8
+	r, g, b uint8
+}
+
+// DefaultColor is the terminal's default foreground or background color.
+var DefaultColor = Color{}
+
+// ANSIColor returns one of the 16 basic ANSI colors, where 0-7 are the normal
+// colors and 8-15 their bright variants.
+func ANSIColor(i int) Color {
+	return Color{kind: colorANSI, index: uint8(i & 15)}
+}
+
+// IndexedColor returns a color of the 256 color palette.
+func IndexedColor(i int) Color {
+	return Color{kind: colorIndexed, index: uint8(i)}
+}
+
+// RGB returns a 24-bit color.
+func RGB(r, g, b uint8) Color {
+	return Color{kind: colorRGB, r: r, g: g, b: b}
+}
+
+// FromColor converts any color.Color to a 24-bit color, ignoring its alpha.
+func FromColor(c color.Color) Color {
+	if c, ok := c.(Color); ok {
+		return c
+	}
+	r, g, b, _ := c.RGBA()
+	return RGB(uint8(r>>8), uint8(g>>8), uint8(b>>8))
+}
+
+// IsDefault reports whether c is the terminal's default color.
+func (c Color) IsDefault() bool {
+	return c.kind == colorDefault
+}
+
+// RGBA implements color.Color. The default color is fully transparent, since
+// its value is up to the terminal.
+func (c Color) RGBA() (r, g, b, a uint32) {
+	if c.kind == colorDefault {
+		return 0, 0, 0, 0
+	}
+	r8, g8, b8 := c.rgb()
+	return color.RGBA{R: r8, G: g8, B: b8, A: 0xFF}.RGBA()
+}
+
+func (c Color) rgb() (r, g, b uint8) {
+	switch c.kind {
+	case colorANSI, colorIndexed:
+		p := paletteRGB(int(c.index))
+		return p[0], p[1], p[2]
+	default:
+		return c.r, c.g, c.b
+	}
+}
+
+// Convert returns the closest color to c that is supported by profile p.
+func (c Color) Convert(p Profile) Color {
+	switch {
+	case c.kind == colorDefault:
+		return c
+	case p <= ProfileNone:
+		return DefaultColor
+	case c.kind == colorRGB && p == ProfileANSI256:
+		return IndexedColor(nearestPalette(c.r, c.g, c.b, 16, 256))
+	case c.kind == colorRGB && p == ProfileANSI:
+		return ANSIColor(nearestPalette(c.r, c.g, c.b, 0, 16))
+	case c.kind == colorIndexed && p == ProfileANSI:
+		if c.index < 16 {
+			return ANSIColor(int(c.index))
+		}
+		r, g, b := c.rgb()
+		return ANSIColor(nearestPalette(r, g, b, 0, 16))
+	default:
+		return c
+	}
+}
+
+// params returns the SGR parameters selecting c as the foreground or
+// background color.
+func (c Color) params(background bool) string {
+	return string(c.appendParams(nil, background))
+}
+
+// appendParams appends the SGR parameters of c to b.
+func (c Color) appendParams(b []byte, background bool) []byte {
+	base := 30
+	if background {
+		base = 40
+	}
+
+	switch c.kind {
+	case colorANSI:
+		if c.index >= 8 {
+			return strconv.AppendInt(b, int64(base+60+int(c.index)-8), 10)
+		}
+		return strconv.AppendInt(b, int64(base+int(c.index)), 10)
+	case colorIndexed:
+		b = strconv.AppendInt(b, int64(base+8), 10)
+		b = append(b, ";5;"...)
+		return strconv.AppendInt(b, int64(c.index), 10)
+	case colorRGB:
+		b = strconv.AppendInt(b, int64(base+8), 10)
+		b = append(b, ";2;"...)
+		b = strconv.AppendInt(b, int64(c.r), 10)
+		b = append(b, ';')
+		b = strconv.AppendInt(b, int64(c.g), 10)
+		b = append(b, ';')
+		return strconv.AppendInt(b, int64(c.b), 10)
+	default:
+		return strconv.AppendInt(b, int64(base+9), 10)
+	}
+}
+
+// The 16 basic colors as rendered by xterm
+var ansiRGB = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// paletteRGB returns the RGB value of a color of the 256 color palette.
+func paletteRGB(i int) [3]uint8 {
+	switch {
+	case i < 16:
+		return ansiRGB[i]
+	case i < 232:
+		i -= 16
+		return [3]uint8{cubeLevels[i/36], cubeLevels[i/6%6], cubeLevels[i%6]}
+	default:
+		v := uint8(8 + (i-232)*10)
+		return [3]uint8{v, v, v}
+	}
+}
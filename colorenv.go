@@ -0,0 +1,60 @@
+package escapes
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ColorEnv returns environ, a list of "key=value" variables such as returned
+// by os.Environ, updated so that child processes use the color profile p, as
+// when a program that decided whether to use colors runs other tools whose
+// output it passes on:
+//
+//	cmd.Env = escapes.ColorEnv(os.Environ(), escapes.CurrentProfile())
+//
+// With ProfileNone, NO_COLOR is set and colors are no longer forced. With
+// other profiles, FORCE_COLOR and CLICOLOR_FORCE force colors even though the
+// output of the children is a pipe, NO_COLOR is removed, COLORTERM is set for
+// 24-bit colors, and TERM is set if it is missing or dumb.
+func ColorEnv(environ []string, p Profile) []string {
+	set := map[string]string{}
+	if p <= ProfileNone {
+		set["NO_COLOR"] = "1"
+		set["FORCE_COLOR"] = "0"
+		set["CLICOLOR"] = "0"
+		set["CLICOLOR_FORCE"] = ""
+	} else {
+		set["NO_COLOR"] = ""
+		set["FORCE_COLOR"] = strconv.Itoa(int(min(p, ProfileTrueColor)))
+		set["CLICOLOR"] = "1"
+		set["CLICOLOR_FORCE"] = "1"
+		if p >= ProfileTrueColor {
+			set["COLORTERM"] = "truecolor"
+		}
+
+		term := "xterm"
+		if p >= ProfileANSI256 {
+			term = "xterm-256color"
+		}
+		set["TERM"] = term
+		for _, kv := range environ {
+			if v, ok := strings.CutPrefix(kv, "TERM="); ok && v != "" && v != "dumb" {
+				delete(set, "TERM")
+			}
+		}
+	}
+
+	env := make([]string, 0, len(environ)+len(set))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := set[key]; !ok {
+			env = append(env, kv)
+		}
+	}
+	for _, key := range []string{"TERM", "COLORTERM", "FORCE_COLOR", "CLICOLOR", "CLICOLOR_FORCE", "NO_COLOR"} {
+		if v, ok := set[key]; ok && v != "" {
+			env = append(env, key+"="+v)
+		}
+	}
+	return env
+}
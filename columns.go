@@ -0,0 +1,60 @@
+package escapes
+
+import "strings"
+
+// columnGap is the number of spaces between columns laid out by Columns.
+const columnGap = 2
+
+// Columns lays out items in columns, as ls lists files: items are ordered
+// down the columns, and each column is as wide as its widest item, measured
+// ignoring escape sequences so that items may be styled. The fewest rows that
+// fit in width columns are used, or a single column if no layout fits. Each
+// line ends with a newline, and has no trailing spaces.
+func Columns(items []string, width int) string {
+	if len(items) == 0 {
+		return ""
+	}
+	widths := make([]int, len(items))
+	for i, item := range items {
+		widths[i] = StringWidth(item)
+	}
+
+	rows := len(items)
+	var colWidths []int
+	for r := 1; r <= len(items); r++ {
+		cols := (len(items) + r - 1) / r
+		colWidths = colWidths[:0]
+		total := columnGap * (cols - 1)
+		for c := 0; c < cols; c++ {
+			w := 0
+			for _, iw := range widths[c*r : min((c+1)*r, len(items))] {
+				w = max(w, iw)
+			}
+			colWidths = append(colWidths, w)
+			total += w
+		}
+		if total <= width || r == len(items) {
+			rows = r
+			break
+		}
+	}
+
+	var b strings.Builder
+	for r := 0; r < rows; r++ {
+		for c := range colWidths {
+			i := c*rows + r
+			if i >= len(items) {
+				break
+			}
+			if c > 0 {
+				b.WriteString(strings.Repeat(" ", columnGap))
+			}
+			b.WriteString(items[i])
+			if next := i + rows; next < len(items) {
+				b.WriteString(strings.Repeat(" ", colWidths[c]-widths[i]))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package escapes
+
+import (
+	"image/color"
+	"io"
+	"math"
+	"strings"
+)
+
+// relativeLuminance returns the relative luminance of a color as defined by
+// WCAG, from 0 for black to 1 for white.
+func relativeLuminance(r, g, b uint8) float64 {
+	return 0.2126*srgbToLinear(r) + 0.7152*srgbToLinear(g) + 0.0722*srgbToLinear(b)
+}
+
+// contrastRatio returns the WCAG contrast ratio between two colors given as
+// RGB values, from 1 to 21.
+func contrastRatio(a, b [3]uint8) float64 {
+	la := relativeLuminance(a[0], a[1], a[2])
+	lb := relativeLuminance(b[0], b[1], b[2])
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// MinContrastRatio is the contrast ratio WCAG requires between text and its
+// background (level AA).
+const MinContrastRatio = 4.5
+
+// ContrastRatio returns the WCAG contrast ratio between two colors, from 1 for
+// identical colors to 21 for black and white.
+func ContrastRatio(a, b color.Color) float64 {
+	var ca, cb [3]uint8
+	ca[0], ca[1], ca[2] = FromColor(a).rgb()
+	cb[0], cb[1], cb[2] = FromColor(b).rgb()
+	return contrastRatio(ca, cb)
+}
+
+// BestForeground returns a style for text on background bg, with a foreground
+// that is a shade of bg with a contrast ratio of MinContrastRatio, or black or
+// white if no shade has enough contrast.
+func BestForeground(bg color.Color) Style {
+	c := FromColor(bg)
+	var rgb [3]uint8
+	rgb[0], rgb[1], rgb[2] = c.rgb()
+	return Style{Fg: contrastingForeground(Style{Fg: c, Bg: c}, rgb, rgb, MinContrastRatio), Bg: c}
+}
+
+// NewContrastWriter returns a writer that remaps foreground colors in the
+// output passing through it that have a contrast ratio lower than minRatio
+// against their background, for users with low vision. Colors are lightened or
+// darkened just enough to reach the ratio. If minRatio is not positive,
+// MinContrastRatio is used.
+//
+// background is the default background of the terminal, as reported by Probe;
+// if it is nil, a black background is assumed. The default foreground is
+// assumed to contrast with it. The writer must be flushed with Flush once the
+// output is complete.
+func NewContrastWriter(w io.Writer, background color.Color, minRatio float64) *TransformWriter {
+	if minRatio <= 0 {
+		minRatio = MinContrastRatio
+	}
+	bg := [3]uint8{0, 0, 0}
+	if background != nil {
+		bg[0], bg[1], bg[2] = FromColor(background).rgb()
+	}
+	fg := [3]uint8{255, 255, 255}
+	if relativeLuminance(bg[0], bg[1], bg[2]) > 0.18 {
+		fg = [3]uint8{0, 0, 0}
+	}
+
+	var (
+		style  Style
+		termFg Color // Foreground color currently set in the terminal
+	)
+	return newTransformWriter(w, func(s string) string {
+		if CurrentProfile() == ProfileNone {
+			return s
+		}
+
+		var b strings.Builder
+		for i := 0; i < len(s); {
+			n := sequenceLen(s[i:])
+			if n == 0 {
+				j := i + 1
+				for j < len(s) && s[j] != AsciiEscape {
+					j++
+				}
+				b.WriteString(s[i:j])
+				i = j
+				continue
+			}
+			seq := s[i : i+n]
+			i += n
+			b.WriteString(seq)
+
+			params, ok := sgrParams(seq)
+			if !ok {
+				continue
+			}
+			var effects sgrEffects
+			if style, effects = applySGR(style, params); effects&sgrForeground != 0 {
+				termFg = style.Fg
+			}
+			want := contrastingForeground(style, fg, bg, minRatio)
+			if want != termFg {
+				b.WriteString(Esc + want.Convert(CurrentProfile()).params(false) + "m")
+				termFg = want
+			}
+		}
+		return b.String()
+	})
+}
+
+// contrastingForeground returns the foreground color of s, adjusted to have
+// at least the given contrast ratio with its background. defaultFg and
+// defaultBg are the values of the default colors.
+func contrastingForeground(s Style, defaultFg, defaultBg [3]uint8, minRatio float64) Color {
+	if s.Attrs&(AttrReverse|AttrHidden) != 0 || (s.Fg.IsDefault() && s.Bg.IsDefault()) {
+		return s.Fg
+	}
+	fg, bg := defaultFg, defaultBg
+	if !s.Fg.IsDefault() {
+		fg[0], fg[1], fg[2] = s.Fg.rgb()
+	}
+	if !s.Bg.IsDefault() {
+		bg[0], bg[1], bg[2] = s.Bg.rgb()
+	}
+	if contrastRatio(fg, bg) >= minRatio {
+		return s.Fg
+	}
+
+	// Blend towards whichever of black and white contrasts more with the
+	// background, as little as possible
+	target := [3]uint8{255, 255, 255}
+	if contrastRatio([3]uint8{}, bg) > contrastRatio(target, bg) {
+		target = [3]uint8{}
+	}
+	blend := func(t float64) [3]uint8 {
+		var c [3]uint8
+		for i := range c {
+			c[i] = uint8(math.Round(float64(fg[i]) + (float64(target[i])-float64(fg[i]))*t))
+		}
+		return c
+	}
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 16; i++ {
+		if mid := (lo + hi) / 2; contrastRatio(blend(mid), bg) >= minRatio {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	c := blend(hi)
+	return RGB(c[0], c[1], c[2])
+}
@@ -0,0 +1,99 @@
+package escapes
+
+import (
+	"io"
+	"time"
+)
+
+// Requests of the cursor position. Like CursorPos, the positions replied are
+// decoded as 0-based.
+const (
+	// RequestCursorPosition requests the position of the cursor (DSR 6),
+	// replied to as CSI row ; column R (CPR).
+	RequestCursorPosition = Esc + "6n"
+
+	// RequestCursorPositionPage requests the position of the cursor and its
+	// page (DECXCPR), replied to as CSI ? row ; column ; page R. Unlike CPR,
+	// the reply cannot be mistaken for a key.
+	RequestCursorPositionPage = Esc + "?6n"
+)
+
+// Pos is a position of the cursor, where (0, 0) is the top-left corner.
+type Pos struct {
+	X, Y int
+
+	// Page is the page of the cursor, starting from 1, as reported by
+	// DECXCPR, or 0 for a plain CPR.
+	Page int
+}
+
+// CursorPositionEvent is a cursor position report decoded by an InputDecoder,
+// in reply to RequestCursorPosition or RequestCursorPositionPage.
+//
+// A plain CPR on the first row has the same form as F3 with modifiers, CSI 1 ;
+// modifiers R, so it is decoded as a key; request the position with
+// RequestCursorPositionPage to avoid this.
+type CursorPositionEvent Pos
+
+// ParseCPR parses a reply to RequestCursorPosition or
+// RequestCursorPositionPage.
+func ParseCPR(reply []byte) (Pos, error) {
+	pos, ok := findCPR(reply)
+	if !ok {
+		return Pos{}, ErrInvalidReply
+	}
+	return pos, nil
+}
+
+// QueryCursorPosition queries the position of the cursor. See Query for the
+// requirements on w and r.
+func QueryCursorPosition(w io.Writer, r io.Reader, timeout time.Duration) (Pos, error) {
+	reply, err := Query(w, r, RequestCursorPosition, func(b []byte) bool {
+		_, ok := findCPR(b)
+		return ok
+	}, timeout)
+	if err != nil {
+		return Pos{}, err
+	}
+	return ParseCPR(reply)
+}
+
+// findCPR finds a reply of the form CSI row ; column R, or CSI ? row ; column
+// ; page R.
+func findCPR(reply []byte) (Pos, bool) {
+	s := string(reply)
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+
+		if pos, ok := cprSequence(seq); ok {
+			return pos, true
+		}
+	}
+	return Pos{}, false
+}
+
+// cprSequence parses seq if it is a cursor position report.
+func cprSequence(seq string) (Pos, bool) {
+	if len(seq) < 3 || seq[:2] != Esc {
+		return Pos{}, false
+	}
+	prefix, params, final := parseCSI(seq)
+	switch {
+	case final != "R":
+	case prefix == "" && len(params) == 2:
+		return Pos{X: max(params[1]-1, 0), Y: max(params[0]-1, 0)}, true
+	case prefix == "?" && (len(params) == 2 || len(params) == 3):
+		pos := Pos{X: max(params[1]-1, 0), Y: max(params[0]-1, 0), Page: 1}
+		if len(params) == 3 {
+			pos.Page = params[2]
+		}
+		return pos, true
+	}
+	return Pos{}, false
+}
@@ -0,0 +1,157 @@
+package escapes
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// DefaultChunkSize is the default size of the chunks written by a
+// ContextWriter.
+const DefaultChunkSize = 4096
+
+// States of the output written by a ContextWriter
+const (
+	ctxGround    = iota // Text
+	ctxEscape           // After ESC
+	ctxCSI              // In a control sequence
+	ctxString           // In an OSC, DCS, APC, PM or SOS string
+	ctxStringEsc        // After ESC in a string, possibly starting ST
+)
+
+// ContextWriter writes output in chunks, and stops once its context is
+// canceled, so that long output such as large images can be interrupted.
+// Output is only cut between escape sequences or inside strings such as
+// image data, and the terminal is left in a consistent state: an unfinished
+// string is terminated, and an unfinished transfer of a kitty image is ended.
+type ContextWriter struct {
+	// ChunkSize is the size of the chunks written, between which the context
+	// is checked. It is DefaultChunkSize if zero.
+	ChunkSize int
+
+	ctx    context.Context
+	w      io.Writer
+	state  int
+	header []byte // Start of the current string, up to its payload
+	kitty  bool   // Whether a chunked kitty image transfer is unfinished
+	err    error
+}
+
+// NewContextWriter returns a writer to w that stops writing once ctx is
+// canceled.
+func NewContextWriter(ctx context.Context, w io.Writer) *ContextWriter {
+	return &ContextWriter{ctx: ctx, w: w}
+}
+
+// Write writes p in chunks, unless the context is canceled, in which case it
+// returns how much of p was written along with the error of the context. Once
+// the context is canceled, nothing more is written.
+func (c *ContextWriter) Write(p []byte) (int, error) {
+	size := c.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	written := 0
+	for written < len(p) && c.err == nil {
+		if err := c.ctx.Err(); err != nil {
+			c.err = err
+			if _, werr := io.WriteString(c.w, c.abort()); werr != nil {
+				return written, werr
+			}
+			break
+		}
+
+		end := min(written+size, len(p))
+		for i := written; i < end; i++ {
+			c.advance(p[i])
+		}
+		// Only cut output between sequences and characters
+		for end < len(p) && (c.state == ctxEscape || c.state == ctxCSI || c.state == ctxStringEsc ||
+			(c.state == ctxGround && p[end]&0xC0 == 0x80)) {
+			c.advance(p[end])
+			end++
+		}
+
+		n, err := writeFull(c.w, p[written:end])
+		written += n
+		if err != nil {
+			c.err = err
+		}
+	}
+	return written, c.err
+}
+
+// WriteString is like Write, but writes the contents of string s.
+func (c *ContextWriter) WriteString(s string) (int, error) {
+	return c.Write([]byte(s))
+}
+
+// advance updates the state with a byte of output.
+func (c *ContextWriter) advance(b byte) {
+	switch c.state {
+	case ctxGround:
+		if b == AsciiEscape {
+			c.state = ctxEscape
+		}
+	case ctxEscape:
+		switch b {
+		case '[':
+			c.state = ctxCSI
+		case ']', 'P', '_', '^', 'X':
+			c.state = ctxString
+			c.header = append(c.header[:0], b)
+		default:
+			if b < 0x20 || b > 0x2F {
+				c.state = ctxGround
+			}
+		}
+	case ctxCSI:
+		if b >= 0x40 && b <= 0x7E {
+			c.state = ctxGround
+		}
+	case ctxString:
+		switch {
+		case b == AsciiEscape:
+			c.state = ctxStringEsc
+		case b == AsciiBell && c.header[0] == ']':
+			c.endString()
+		case len(c.header) < 64 && !strings.ContainsRune(string(c.header), ';'):
+			c.header = append(c.header, b)
+		}
+	case ctxStringEsc:
+		if b == '\\' {
+			c.endString()
+		} else {
+			c.state = ctxString
+		}
+	}
+}
+
+// endString ends the current string, noting whether it starts or ends a
+// chunked transfer of a kitty image.
+func (c *ContextWriter) endString() {
+	c.state = ctxGround
+	if h := string(c.header); strings.HasPrefix(h, "_G") {
+		c.kitty = strings.Contains(","+strings.TrimSuffix(h[2:], ";")+",", ",m=1,")
+	}
+}
+
+// abort returns the sequences that leave the terminal in a consistent state
+// when output stops.
+func (c *ContextWriter) abort() string {
+	var s string
+	switch c.state {
+	case ctxString:
+		s = St
+		c.endString()
+	case ctxStringEsc:
+		s = "\\"
+		c.endString()
+	}
+	if c.kitty {
+		s += kittyPrefix + "m=0;" + kittySuffix
+		c.kitty = false
+	}
+	return s
+}
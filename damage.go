@@ -0,0 +1,104 @@
+package escapes
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Rect is a rectangle of cells whose top-left corner is (X, Y).
+type Rect struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Empty reports whether the rectangle contains no cells.
+func (r Rect) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// contains reports whether r contains every cell of o.
+func (r Rect) contains(o Rect) bool {
+	return o.X >= r.X && o.Y >= r.Y && o.X+o.Width <= r.X+r.Width && o.Y+o.Height <= r.Y+r.Height
+}
+
+// Damage collects the regions of a Screen that changed since it was last
+// drawn, so that applications that know what they changed can draw only those
+// regions, with DiffRects or Renderer.RenderScreenDamage, rather than compare
+// the whole screen. The zero value has no damage.
+type Damage struct {
+	rects []Rect
+}
+
+// MarkDirty records that the cells of r may have changed.
+func (d *Damage) MarkDirty(r Rect) {
+	if r.Empty() {
+		return
+	}
+	rects := d.rects[:0]
+	for _, o := range d.rects {
+		if o.contains(r) {
+			return
+		}
+		if !r.contains(o) {
+			rects = append(rects, o)
+		}
+	}
+	d.rects = append(rects, r)
+}
+
+// Dirty returns the regions recorded since the last Reset.
+func (d *Damage) Dirty() []Rect {
+	return append([]Rect(nil), d.rects...)
+}
+
+// Reset forgets the recorded regions, such as once they are drawn.
+func (d *Damage) Reset() {
+	d.rects = d.rects[:0]
+}
+
+// DiffRects is like Diff, but only compares the cells within rects, which are
+// assumed to hold every change since prev, such as recorded by a Damage. The
+// whole screen is redrawn if prev is nil or has different dimensions.
+func (s *Screen) DiffRects(prev *Screen, rects []Rect) string {
+	b := getBuffer()
+	defer putBuffer(b)
+	s.diffRects(b, prev, rects)
+	return b.String()
+}
+
+// diffRects writes the sequences returned by DiffRects to b.
+func (s *Screen) diffRects(b *bytes.Buffer, prev *Screen, rects []Rect) {
+	if prev == nil || prev.width != s.width || prev.height != s.height {
+		s.diff(b, prev)
+		return
+	}
+	s.diffRanges(b, prev, s.rowRanges(rects))
+}
+
+// rowRanges returns the ranges of cells of each row covered by rects, clipped
+// to the screen, in order and merged where they overlap.
+func (s *Screen) rowRanges(rects []Rect) []cellRange {
+	var ranges []cellRange
+	for _, r := range rects {
+		x0, x1 := max(r.X, 0), min(r.X+r.Width, s.width)
+		for y := max(r.Y, 0); y < min(r.Y+r.Height, s.height) && x0 < x1; y++ {
+			ranges = append(ranges, cellRange{y, x0, x1})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].y != ranges[j].y {
+			return ranges[i].y < ranges[j].y
+		}
+		return ranges[i].start < ranges[j].start
+	})
+
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && merged[n-1].y == r.y && r.start <= merged[n-1].end {
+			merged[n-1].end = max(merged[n-1].end, r.end)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,89 @@
+package escapes
+
+import (
+	"io"
+	"strings"
+)
+
+// NewDedupeWriter returns a writer that removes redundant SGR sequences from
+// the output passing through it, such as the same color set again before every
+// character, as naive renderers often produce. Consecutive SGR sequences are
+// replaced with the shortest sequence reaching the same style, or dropped if
+// the style does not change. Sequences with parameters that are not
+// understood, such as underline styles, are passed unchanged.
+//
+// Since styles only matter once text is written, sequences at the end of a
+// write may be held back until the next one; the writer must be flushed with
+// Flush once the output is complete.
+func NewDedupeWriter(w io.Writer) *TransformWriter {
+	var (
+		style Style    // Style currently set in the terminal
+		next  Style    // Style after the pending sequences
+		run   []string // Pending SGR sequences
+
+		// unknown is set while the terminal has effects of sequences that
+		// were not understood, until a reset
+		unknown    bool
+		runUnknown bool // Whether a pending sequence is not understood
+		runReset   bool // Whether a pending sequence resets the style
+	)
+	flush := func(b *strings.Builder) {
+		if len(run) == 0 {
+			return
+		}
+		switch {
+		case !runUnknown && !unknown:
+			b.WriteString(transition(style, next))
+		case !runUnknown && runReset:
+			b.WriteString(Esc + strings.Join(append([]string{""}, next.params()...), ";") + "m")
+			unknown = false
+		default:
+			for _, seq := range run {
+				b.WriteString(seq)
+			}
+			unknown = unknown || runUnknown
+		}
+		style, run, runUnknown, runReset = next, run[:0], false, false
+	}
+
+	t := newTransformWriter(w, func(s string) string {
+		var b strings.Builder
+		for i := 0; i < len(s); {
+			n := sequenceLen(s[i:])
+			if n == 0 {
+				flush(&b)
+				j := i + 1
+				for j < len(s) && s[j] != AsciiEscape {
+					j++
+				}
+				b.WriteString(s[i:j])
+				i = j
+				continue
+			}
+			seq := s[i : i+n]
+			i += n
+
+			params, ok := sgrParams(seq)
+			if !ok {
+				flush(&b)
+				b.WriteString(seq)
+				continue
+			}
+			var effects sgrEffects
+			next, effects = applySGR(next, params)
+			if effects&sgrReset != 0 {
+				// Effects of earlier sequences are undone
+				runUnknown, runReset = false, true
+			}
+			runUnknown = runUnknown || effects&sgrUnknown != 0
+			run = append(run, seq)
+		}
+		return b.String()
+	})
+	t.end = func() string {
+		var b strings.Builder
+		flush(&b)
+		return b.String()
+	}
+	return t
+}
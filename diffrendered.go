@@ -0,0 +1,55 @@
+package escapes
+
+import "strings"
+
+// DiffRenderedWidth is the width of the screen that DiffRendered renders
+// output on.
+var DiffRenderedWidth = 80
+
+// Difference is a cell that differs between two rendered outputs.
+type Difference struct {
+	X, Y int
+	A, B Cell
+}
+
+// DiffRendered renders two outputs on blank screens with an Emulator, and
+// returns the cells that differ, from the top-left corner, or nothing if
+// they look the same. Unlike comparing bytes, outputs drawing the same cells
+// with different sequences, such as in a different order or with redundant
+// styles, are equal. The screens are DiffRenderedWidth columns wide, and high
+// enough for the lines of both outputs once wrapped, so that neither scrolls.
+// Blank cells and spaces are equal.
+func DiffRendered(a, b string) []Difference {
+	height := max(renderedHeight(a), renderedHeight(b), 24) + 1
+	ea, eb := NewEmulator(DiffRenderedWidth, height), NewEmulator(DiffRenderedWidth, height)
+	ea.WriteString(a)
+	eb.WriteString(b)
+	sa, sb := ea.Screen(), eb.Screen()
+
+	var diffs []Difference
+	for y := 0; y < height; y++ {
+		for x := 0; x < DiffRenderedWidth; x++ {
+			if ca, cb := sa.Cell(x, y), sb.Cell(x, y); blankAsSpace(ca) != blankAsSpace(cb) {
+				diffs = append(diffs, Difference{X: x, Y: y, A: ca, B: cb})
+			}
+		}
+	}
+	return diffs
+}
+
+// renderedHeight returns the number of rows the lines of s take on a screen
+// DiffRenderedWidth columns wide, with long lines wrapped.
+func renderedHeight(s string) int {
+	height := 0
+	for _, line := range strings.Split(ExpandTabs(s, 8), "\n") {
+		height += max(1, (StringWidth(line)+DiffRenderedWidth-1)/DiffRenderedWidth)
+	}
+	return height
+}
+
+func blankAsSpace(c Cell) Cell {
+	if c.Rune == 0 {
+		c.Rune = ' '
+	}
+	return c
+}
@@ -0,0 +1,25 @@
+package escapes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffRendered(t *testing.T) {
+	if d := DiffRendered("\x1b[1mab\x1b[0m\x1b[1mc\x1b[0m", "\x1b[1mabc\x1b[0m"); len(d) != 0 {
+		t.Errorf("DiffRendered of the same cells = %+v, want none", d)
+	}
+	if d := DiffRendered("abc  ", "abc"); len(d) != 0 {
+		t.Errorf("DiffRendered with trailing spaces = %+v, want none", d)
+	}
+	d := DiffRendered("abc", "abd")
+	if len(d) != 1 || d[0].X != 2 || d[0].Y != 0 || d[0].A.Rune != 'c' || d[0].B.Rune != 'd' {
+		t.Errorf("DiffRendered(%q, %q) = %+v, want the third cell", "abc", "abd", d)
+	}
+
+	// Wrapped lines taller than the default height
+	long := strings.Repeat("x", 2100)
+	if d := DiffRendered("A"+long, "B"+long); len(d) != 1 || d[0].X != 0 || d[0].Y != 0 {
+		t.Errorf("DiffRendered of wrapped lines = %+v, want the first cell", d)
+	}
+}
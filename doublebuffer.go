@@ -0,0 +1,101 @@
+package escapes
+
+import (
+	"io"
+	"sync"
+)
+
+// DoubleBuffer is a pair of Screens: the front one, which the terminal shows,
+// and the back one, on which the next frame is built. Swap draws the back
+// screen and promotes it to the front, so that goroutines producing frames
+// can keep drawing while the previous frame is written.
+type DoubleBuffer struct {
+	w io.Writer
+
+	swapMu sync.Mutex // Held while a frame is written, to keep frames ordered
+	mu     sync.Mutex // Held while the screens are accessed
+	front  *Screen    // Screen shown, or nil if it must be redrawn in full
+	back   *Screen
+}
+
+// NewDoubleBuffer returns a DoubleBuffer of the given dimensions that draws
+// to w. Its first frame is drawn in full.
+func NewDoubleBuffer(w io.Writer, width, height int) *DoubleBuffer {
+	return &DoubleBuffer{w: w, back: NewScreen(width, height)}
+}
+
+// Draw calls fn with the back screen, which holds the frame last swapped
+// until fn changes it. Calls to Draw from different goroutines do not run
+// concurrently with each other, nor with the swap of the screens, so the
+// back screen must not be used once fn returns.
+func (d *DoubleBuffer) Draw(fn func(back *Screen)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fn(d.back)
+}
+
+// Front returns a copy of the screen shown on the terminal, or nil if none
+// was drawn yet.
+func (d *DoubleBuffer) Front() *Screen {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.front == nil {
+		return nil
+	}
+	return d.front.Clone()
+}
+
+// Swap draws the back screen, only redrawing the cells that changed since the
+// front screen, and promotes it to the front. The back screen then starts as
+// a copy of the new front. The frame is wrapped in synchronized update
+// sequences, so that terminals supporting them never show a partial frame. If
+// interactive sequences are disabled, frames are written as plain lines.
+func (d *DoubleBuffer) Swap() error {
+	d.swapMu.Lock()
+	defer d.swapMu.Unlock()
+
+	b := getBuffer()
+	defer putBuffer(b)
+	d.mu.Lock()
+	if Interactive() {
+		b.WriteString(SyncUpdateBegin)
+		d.back.diff(b, d.front)
+		if b.Len() == len(SyncUpdateBegin) {
+			b.Reset()
+		} else {
+			b.WriteString(SyncUpdateEnd)
+		}
+	} else {
+		d.back.writeLines(b)
+		b.WriteByte('\n')
+	}
+	if d.front == nil {
+		d.front = d.back.Clone()
+	} else {
+		d.front, d.back = d.back, d.front
+		copy(d.back.cells, d.front.cells)
+	}
+	d.mu.Unlock()
+
+	if b.Len() == 0 {
+		return nil
+	}
+	_, err := d.w.Write(b.Bytes())
+	return err
+}
+
+// Resize changes the dimensions of the screens, such as when the terminal is
+// resized, keeping the contents of the back screen that still fit. The next
+// frame is drawn in full.
+func (d *DoubleBuffer) Resize(width, height int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := NewScreen(width, height)
+	w, h := d.back.Size()
+	for y := 0; y < min(h, height); y++ {
+		for x := 0; x < min(w, width); x++ {
+			s.SetCell(x, y, d.back.Cell(x, y))
+		}
+	}
+	d.front, d.back = nil, s
+}
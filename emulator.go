@@ -0,0 +1,429 @@
+package escapes
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Emulator is a minimal terminal emulator that applies output to a Screen, so
+// that the effect of escape sequences can be checked, such as in tests. It
+// handles text with auto-wrap, control characters, cursor movement, erasing,
+// inserting and deleting, scroll regions, colors and attributes, hyperlinks,
+// and the alternate screen. Other sequences are ignored. Wide characters
+// occupy two cells, the second of which is a WideContinuation.
+type Emulator struct {
+	mu      sync.Mutex
+	screen  *Screen
+	main    *Screen // Main screen while the alternate screen is shown
+	x, y    int
+	wrap    bool // Whether the cursor is past the last column (pending wrap)
+	style   Style
+	url     string // Target of the open hyperlink, if any
+	hidden  bool
+	noWrap  bool
+	top     int // Scroll region, inclusive
+	bottom  int
+	saved   [3]int // Cursor saved by DECSC: x, y and whether it is set
+	savedSt Style
+	partial string // Incomplete sequence or rune at the end of output
+}
+
+// NewEmulator returns an emulator with a blank screen of the given size.
+func NewEmulator(width, height int) *Emulator {
+	e := &Emulator{}
+	e.reset(width, height)
+	return e
+}
+
+// reset puts the emulator in its initial state, with a blank screen.
+func (e *Emulator) reset(width, height int) {
+	e.screen, e.main = NewScreen(width, height), nil
+	e.x, e.y, e.wrap = 0, 0, false
+	e.style, e.url, e.hidden, e.noWrap = Style{}, "", false, false
+	e.top, e.bottom = 0, e.screen.height-1
+	e.saved, e.savedSt = [3]int{}, Style{}
+}
+
+// Write applies output to the screen. Sequences and runes split across writes
+// are handled.
+func (e *Emulator) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s := e.partial + string(p)
+	e.partial = ""
+	for i := 0; i < len(s); {
+		if s[i] == AsciiEscape {
+			n, complete := sequenceEnd(s[i:])
+			if !complete {
+				e.partial = s[i:]
+				break
+			}
+			e.sequence(s[i : i+n])
+			i += n
+			continue
+		}
+		if !utf8.FullRuneInString(s[i:]) {
+			e.partial = s[i:]
+			break
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		e.rune(r)
+	}
+	return len(p), nil
+}
+
+// WriteString is like Write, but writes the contents of string s.
+func (e *Emulator) WriteString(s string) (int, error) {
+	return e.Write([]byte(s))
+}
+
+// Screen returns a copy of the screen shown.
+func (e *Emulator) Screen() *Screen {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.screen.Clone()
+}
+
+// Cursor returns the position of the cursor, and whether it is visible.
+func (e *Emulator) Cursor() (x, y int, visible bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.x, e.y, !e.hidden
+}
+
+// String returns the text of the screen shown, without styles, as lines
+// without trailing blanks.
+func (e *Emulator) String() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	lines := make([]string, e.screen.height)
+	for y := range lines {
+		var b strings.Builder
+		for x := 0; x < e.screen.width; x++ {
+			c := e.screen.Cell(x, y)
+			switch c.Rune {
+			case WideContinuation:
+				continue
+			case 0:
+				c.Rune = ' '
+			}
+			b.WriteRune(c.Rune)
+			b.WriteString(c.Marks)
+		}
+		lines[y] = strings.TrimRight(b.String(), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rune applies a character of output.
+func (e *Emulator) rune(r rune) {
+	switch r {
+	case '\r':
+		e.x, e.wrap = 0, false
+	case '\n', '\v', '\f':
+		e.index()
+	case '\b':
+		e.moveTo(e.x-1, e.y)
+	case '\t':
+		e.moveTo((e.x/8+1)*8, e.y)
+	default:
+		w := RuneWidth(r)
+		if w == 0 && r >= 0x300 {
+			e.mark(r)
+			return
+		}
+		if r < 0x20 || r == AsciiDelete || w == 0 || e.screen.width == 0 {
+			return
+		}
+		if e.wrap || e.x+w > e.screen.width {
+			if e.noWrap {
+				e.x = e.screen.width - w
+			} else {
+				e.x = 0
+				e.index()
+			}
+		}
+		e.screen.SetCell(e.x, e.y, Cell{Rune: r, Style: e.style, URL: e.url})
+		e.x += w
+		e.wrap = false
+		if e.x >= e.screen.width {
+			e.x, e.wrap = e.screen.width-1, true
+		}
+	}
+}
+
+// mark adds a combining character to the cell before the cursor, which holds
+// the last character written.
+func (e *Emulator) mark(r rune) {
+	x := e.x - 1
+	if e.wrap {
+		x = e.x
+	}
+	if x > 0 && e.screen.Cell(x, e.y).Rune == WideContinuation {
+		x--
+	}
+	if e.screen.inBounds(x, e.y) {
+		e.screen.cells[e.y*e.screen.width+x].Marks += string(r)
+	}
+}
+
+// sequence applies an escape sequence.
+func (e *Emulator) sequence(seq string) {
+	switch seq {
+	case "\u001B7":
+		e.saved = [3]int{e.x, e.y, 1}
+		e.savedSt = e.style
+		return
+	case "\u001B8":
+		if e.saved[2] != 0 {
+			e.moveTo(e.saved[0], e.saved[1])
+			e.style = e.savedSt
+		}
+		return
+	case "\u001BD":
+		e.index()
+		return
+	case "\u001BE":
+		e.x = 0
+		e.index()
+		return
+	case "\u001BM":
+		e.reverseIndex()
+		return
+	case ClearScreen:
+		e.reset(e.screen.width, e.screen.height)
+		return
+	}
+	if _, url, ok := parseLinkSequence(seq); ok {
+		e.url = url
+		return
+	}
+	if !strings.HasPrefix(seq, Esc) {
+		return
+	}
+	if params, ok := sgrParams(seq); ok {
+		e.style, _ = applySGR(e.style, params)
+		return
+	}
+
+	prefix, params, final := parseCSI(seq)
+	param := func(i, def int) int {
+		if i < len(params) && params[i] > 0 {
+			return params[i]
+		}
+		return def
+	}
+	if prefix == "?" {
+		if final == "h" || final == "l" {
+			for _, mode := range params {
+				e.setMode(mode, final == "h")
+			}
+		}
+		return
+	}
+	if prefix != "" {
+		return
+	}
+
+	n := param(0, 1)
+	switch final {
+	case "A":
+		e.moveTo(e.x, max(e.y-n, min(e.top, e.y)))
+	case "B":
+		e.moveTo(e.x, min(e.y+n, max(e.bottom, e.y)))
+	case "C":
+		e.moveTo(e.x+n, e.y)
+	case "D":
+		e.moveTo(e.x-n, e.y)
+	case "E":
+		e.moveTo(0, e.y+n)
+	case "F":
+		e.moveTo(0, e.y-n)
+	case "G", "`":
+		e.moveTo(n-1, e.y)
+	case "d":
+		e.moveTo(e.x, n-1)
+	case "H", "f":
+		e.moveTo(param(1, 1)-1, n-1)
+	case "J":
+		e.erase(param(0, 0), true)
+	case "K":
+		e.erase(param(0, 0), false)
+	case "@":
+		e.shiftCells(e.x, n)
+	case "P":
+		e.shiftCells(e.x, -n)
+	case "X":
+		for x := e.x; x < min(e.x+n, e.screen.width); x++ {
+			e.screen.SetCell(x, e.y, Cell{Style: e.blank()})
+		}
+	case "L":
+		if e.y >= e.top && e.y <= e.bottom {
+			e.scroll(e.y, e.bottom, -n)
+			e.x, e.wrap = 0, false
+		}
+	case "M":
+		if e.y >= e.top && e.y <= e.bottom {
+			e.scroll(e.y, e.bottom, n)
+			e.x, e.wrap = 0, false
+		}
+	case "S":
+		e.scroll(e.top, e.bottom, n)
+	case "T":
+		e.scroll(e.top, e.bottom, -n)
+	case "r":
+		top, bottom := param(0, 1)-1, param(1, e.screen.height)-1
+		if top < bottom && bottom < e.screen.height {
+			e.top, e.bottom = top, bottom
+			e.moveTo(0, 0)
+		}
+	case "s":
+		e.saved = [3]int{e.x, e.y, 1}
+	case "u":
+		if e.saved[2] != 0 {
+			e.moveTo(e.saved[0], e.saved[1])
+		}
+	}
+}
+
+// setMode sets a private mode.
+func (e *Emulator) setMode(mode int, on bool) {
+	switch mode {
+	case 7:
+		e.noWrap = !on
+	case 25:
+		e.hidden = !on
+	case 47, 1047, 1049:
+		if on == (e.main != nil) {
+			return
+		}
+		if mode == 1049 && on {
+			e.saved = [3]int{e.x, e.y, 1}
+			e.savedSt = e.style
+		}
+		if on {
+			e.main = e.screen
+			e.screen = NewScreen(e.main.width, e.main.height)
+		} else {
+			e.screen, e.main = e.main, nil
+		}
+		if mode == 1049 && !on && e.saved[2] != 0 {
+			e.moveTo(e.saved[0], e.saved[1])
+			e.style = e.savedSt
+		}
+	}
+}
+
+// moveTo moves the cursor, limited to the screen.
+func (e *Emulator) moveTo(x, y int) {
+	e.x = clampInt(x, 0, max(e.screen.width-1, 0))
+	e.y = clampInt(y, 0, max(e.screen.height-1, 0))
+	e.wrap = false
+}
+
+// index moves the cursor down, scrolling the scroll region up if the cursor
+// is at its bottom.
+func (e *Emulator) index() {
+	e.wrap = false
+	switch {
+	case e.y == e.bottom:
+		e.scroll(e.top, e.bottom, 1)
+	case e.y < e.screen.height-1:
+		e.y++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling the scroll region down if the
+// cursor is at its top.
+func (e *Emulator) reverseIndex() {
+	e.wrap = false
+	switch {
+	case e.y == e.top:
+		e.scroll(e.top, e.bottom, -1)
+	case e.y > 0:
+		e.y--
+	}
+}
+
+// scroll moves the rows in [top, bottom] up by n, or down if n is negative,
+// blanking the rows exposed.
+func (e *Emulator) scroll(top, bottom, n int) {
+	s := e.screen
+	if bottom >= s.height || top > bottom {
+		return
+	}
+	height := bottom - top + 1
+	n = clampInt(n, -height, height)
+	row := func(y int) []Cell { return s.cells[y*s.width : (y+1)*s.width] }
+	if n > 0 {
+		for y := top; y <= bottom-n; y++ {
+			copy(row(y), row(y+n))
+		}
+		for y := bottom - n + 1; y <= bottom; y++ {
+			e.blankCells(row(y))
+		}
+	} else if n < 0 {
+		for y := bottom; y >= top-n; y-- {
+			copy(row(y), row(y+n))
+		}
+		for y := top; y < top-n; y++ {
+			e.blankCells(row(y))
+		}
+	}
+}
+
+// erase erases part of the screen (ED) if screen is true, or of the line
+// (EL): after the cursor for mode 0, before it for mode 1, and all of it for
+// mode 2.
+func (e *Emulator) erase(mode int, screen bool) {
+	s := e.screen
+	from, to := e.y*s.width, (e.y+1)*s.width
+	if screen {
+		from, to = 0, len(s.cells)
+	}
+	cursor := e.y*s.width + e.x
+	switch mode {
+	case 0:
+		from = cursor
+	case 1:
+		to = cursor + 1
+	case 2, 3:
+	default:
+		return
+	}
+	e.blankCells(s.cells[from:to])
+}
+
+// shiftCells moves the cells of the cursor row from x to the right by n, or
+// to the left if n is negative, blanking those exposed.
+func (e *Emulator) shiftCells(x, n int) {
+	s := e.screen
+	row := s.cells[e.y*s.width : (e.y+1)*s.width]
+	if x >= len(row) {
+		return
+	}
+	rest := row[x:]
+	n = clampInt(n, -len(rest), len(rest))
+	if n > 0 {
+		copy(rest[n:], rest)
+		e.blankCells(rest[:n])
+	} else if n < 0 {
+		copy(rest, rest[-n:])
+		e.blankCells(rest[len(rest)+n:])
+	}
+	e.wrap = false
+}
+
+// blankCells erases cells, which keep the current background color.
+func (e *Emulator) blankCells(cells []Cell) {
+	for i := range cells {
+		cells[i] = Cell{Style: e.blank()}
+	}
+}
+
+func (e *Emulator) blank() Style {
+	return Style{Bg: e.style.Bg}
+}
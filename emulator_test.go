@@ -0,0 +1,143 @@
+package escapes
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEmulator(t *testing.T) {
+	tests := []struct {
+		name   string
+		out    string
+		want   string
+		x, y   int
+		hidden bool
+	}{
+		{"text", "ab\r\ncd", "ab\ncd\n\n", 2, 1, false},
+		{"wrap", "abcdefg", "abcde\nfg\n\n", 2, 1, false},
+		{"pending wrap", "abcde\r\nf", "abcde\nf\n\n", 1, 1, false},
+		{"no wrap", "\x1b[?7labcdefg", "abcdg\n\n\n", 4, 0, false},
+		{"scroll", "1\r\n2\r\n3\r\n4\r\n5", "2\n3\n4\n5", 1, 3, false},
+		{"cursor", "\x1b[3;2Hx\x1b[Ay\x1b[2Dz", "\n zy\n x\n", 2, 1, false},
+		{"erase line", "abcde\x1b[1;3H\x1b[K", "ab\n\n\n", 2, 0, false},
+		{"erase screen", "ab\r\ncd\x1b[2J", "\n\n\n", 2, 1, false},
+		{"insert and delete", "abcde\x1b[1;2H\x1b[2@\x1b[1P", "a bc\n\n\n", 1, 0, false},
+		{"scroll region", "1\r\n2\r\n3\r\n4\x1b[2;3r\x1b[3;1H\n", "1\n3\n\n4", 0, 2, false},
+		{"wide", "日本\x1b[1;2Hx", " x本\n\n\n", 2, 0, false},
+		{"combining", "e\u0301", "e\u0301\n\n\n", 1, 0, false},
+		{"hidden cursor", "\x1b[?25lab", "ab\n\n\n", 2, 0, true},
+		{"save and restore", "a\x1b7\x1b[3;3Hb\x1b8c", "ac\n\n  b\n", 2, 0, false},
+		{"alternate screen", "main\x1b[?1049halt\x1b[?1049l", "main\n\n\n", 4, 0, false},
+		{"split sequence", "\x1b[", "\n\n\n", 0, 0, false},
+	}
+	for _, tt := range tests {
+		e := NewEmulator(5, 4)
+		e.WriteString(tt.out)
+		if got := e.String(); got != tt.want {
+			t.Errorf("%s: screen = %q, want %q", tt.name, got, tt.want)
+		}
+		if x, y, visible := e.Cursor(); x != tt.x || y != tt.y || visible == tt.hidden {
+			t.Errorf("%s: cursor = %d, %d, %v, want %d, %d, %v", tt.name, x, y, visible, tt.x, tt.y, !tt.hidden)
+		}
+	}
+}
+
+func TestEmulatorSplitWrites(t *testing.T) {
+	e := NewEmulator(10, 1)
+	for _, s := range []string{"\x1b", "[31", "mé"[:2], "mé"[2:], "\x1b]8;;http://x\x1b", "\\x\x1b]8;;\x1b\\"} {
+		e.WriteString(s)
+	}
+	want := Cell{Rune: 'x', Style: Style{Fg: ANSIColor(1)}, URL: "http://x"}
+	if got := e.Screen().Cell(1, 0); got != want {
+		t.Errorf("cell = %+v, want %+v", got, want)
+	}
+	if got := e.String(); got != "éx" {
+		t.Errorf("screen = %q, want %q", got, "éx")
+	}
+}
+
+// checkScreen reports the cells of the emulator that differ from s.
+func checkScreen(t *testing.T, name string, e *Emulator, s *Screen) {
+	t.Helper()
+	got := e.Screen()
+	w, h := s.Size()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if g, want := blankCell(got.Cell(x, y)), blankCell(s.Cell(x, y)); g != want {
+				t.Errorf("%s: cell (%d, %d) = %+v, want %+v", name, x, y, g, want)
+			}
+		}
+	}
+}
+
+// blankCell returns c with a zero rune drawn as a space.
+func blankCell(c Cell) Cell {
+	if c.Rune == 0 {
+		c.Rune = ' '
+	}
+	return c
+}
+
+// logScreen returns a screen showing the lines of a log, from first on.
+func logScreen(first int) *Screen {
+	s := NewScreen(12, 6)
+	s.SetString(0, 0, "header", Style{Attrs: AttrBold})
+	for y := 1; y < 6; y++ {
+		s.SetString(0, y, fmt.Sprintf("line %d", first+y), Style{Fg: ANSIColor(y)})
+	}
+	return s
+}
+
+func TestEmulatorRender(t *testing.T) {
+	defer SetColorLevel(int(CurrentProfile()))
+	SetColorLevel(3)
+
+	s := NewScreen(12, 3)
+	s.SetString(0, 0, "plain", Style{})
+	s.SetString(6, 0, "bold", Style{Attrs: AttrBold | AttrUnderline})
+	s.SetString(0, 1, "日本é", Style{Fg: RGB(10, 20, 30), Bg: IndexedColor(200)})
+	s.SetLinkString(0, 2, "link", "https://example.com", Style{Fg: ANSIColor(4)})
+
+	e := NewEmulator(12, 3)
+	e.WriteString(s.Render())
+	checkScreen(t, "Render", e, s)
+}
+
+func TestEmulatorDiff(t *testing.T) {
+	defer SetColorLevel(int(CurrentProfile()))
+	SetColorLevel(3)
+
+	changed := logScreen(0)
+	changed.SetString(5, 3, "X", Style{Bg: ANSIColor(2)})
+	wide := logScreen(0)
+	wide.SetString(2, 2, "日本", Style{})
+
+	tests := []struct {
+		name       string
+		prev, next *Screen
+	}{
+		{"same", logScreen(0), logScreen(0)},
+		{"changed cell", logScreen(0), changed},
+		{"wide characters", logScreen(0), wide},
+		{"wide characters removed", wide, logScreen(0)},
+		{"scrolled up", logScreen(0), logScreen(2)},
+		{"scrolled down", logScreen(2), logScreen(0)},
+		{"cleared", logScreen(0), NewScreen(12, 6)},
+	}
+	for _, tt := range tests {
+		e := NewEmulator(12, 6)
+		e.WriteString(tt.prev.Render())
+		d := tt.next.Diff(tt.prev)
+		e.WriteString(d)
+		checkScreen(t, tt.name, e, tt.next)
+		if tt.name == "same" && d != "" {
+			t.Errorf("same: Diff = %q, want nothing", d)
+		}
+	}
+
+	// A screen of another size is redrawn in full
+	e := NewEmulator(12, 6)
+	e.WriteString("garbage")
+	e.WriteString(logScreen(0).Diff(NewScreen(3, 3)))
+	checkScreen(t, "resized", e, logScreen(0))
+}
@@ -1,9 +1,6 @@
 package escapes
 
-import (
-	"encoding/base64"
-	"strconv"
-)
+import "strconv"
 
 // ConsoleDim represents the dimensions of a console in rows and columns.
 type ConsoleDim struct {
@@ -16,6 +13,12 @@ const (
 	Esc = "\u001B["
 	Osc = "\u001B]"
 	Bel = "\u0007"
+
+	St  = "\u001B\\" // String terminator, ending DCS, APC, PM and OSC sequences
+	Ss3 = "\u001BO"  // Single shift 3, starting keys sent in application mode
+	Dcs = "\u001BP"  // Device control string
+	Apc = "\u001B_"  // Application program command
+	Pm  = "\u001B^"  // Privacy message
 )
 
 // Common ANSI escapes sequences. These should be used when the desired action
@@ -39,9 +42,46 @@ const (
 	CursorShow         = Esc + "?25h"
 	CursorHide         = Esc + "?25l"
 
+	AltScreenEnable  = Esc + "?1049h"
+	AltScreenDisable = Esc + "?1049l"
+
+	MouseNormalEnable  = Esc + "?1000h"
+	MouseNormalDisable = Esc + "?1000l"
+	MouseButtonEnable  = Esc + "?1002h"
+	MouseButtonDisable = Esc + "?1002l"
+	MouseAnyEnable     = Esc + "?1003h"
+	MouseAnyDisable    = Esc + "?1003l"
+	MouseSGREnable     = Esc + "?1006h"
+	MouseSGRDisable    = Esc + "?1006l"
+
+	// MouseSGRPixelsEnable makes mouse reports use the format of
+	// MouseSGREnable with coordinates in pixels, in terminals supporting it
+	// (xterm, kitty, WezTerm, foot). Set InputDecoder.MousePixels to decode
+	// them.
+	MouseSGRPixelsEnable  = Esc + "?1016h"
+	MouseSGRPixelsDisable = Esc + "?1016l"
+
+	// AlternateScrollEnable makes the mouse wheel send Up and Down keys while
+	// the alternate screen is shown and mouse reporting is disabled, so that
+	// pagers scroll without handling the mouse. They are decoded as KeyEvents
+	// indistinguishable from the arrow keys; enable mouse reporting instead
+	// to tell them apart, as MouseWheelUp and MouseWheelDown. Some terminals,
+	// such as VTE-based ones, behave this way by default.
+	AlternateScrollEnable  = Esc + "?1007h"
+	AlternateScrollDisable = Esc + "?1007l"
+
+	FocusReportEnable  = Esc + "?1004h"
+	FocusReportDisable = Esc + "?1004l"
+
+	BracketedPasteEnable  = Esc + "?2004h"
+	BracketedPasteDisable = Esc + "?2004l"
+
 	ScrollUp   = Esc + "S"
 	ScrollDown = Esc + "T"
 
+	SyncUpdateBegin = Esc + "?2026h"
+	SyncUpdateEnd   = Esc + "?2026l"
+
 	TextInsertChar = Esc + "@"
 	TextDeleteChar = Esc + "P"
 	TextEraseChar  = Esc + "X"
@@ -94,6 +134,89 @@ const (
 	ClearScreen = "\u001Bc"
 )
 
+// Faint and rapidly blinking text. TextNormalIntensity turns off both faint and
+// bold text, and BlinkOff both kinds of blinking, so an attribute sharing the
+// reset must be enabled again if needed; Style transitions handle this.
+const (
+	TextDim             = Esc + "2m"
+	TextNormalIntensity = Esc + "22m"
+	BlinkFast           = Esc + "6m"
+	BlinkOff            = Esc + "25m"
+)
+
+// Less common text attributes (SGR). Support varies: overline is honored by
+// most modern terminals (kitty, WezTerm, foot, VTE, Windows Terminal), while
+// alternate fonts, fraktur, and framed or encircled text are ignored by
+// nearly all of them, and mostly matter to emulators of hardware terminals.
+const (
+	TextOverline    = Esc + "53m"
+	TextOverlineOff = Esc + "55m"
+
+	// TextFontPrimary selects the default font, and TextFontAlt1 to
+	// TextFontAlt9 alternate fonts chosen by the terminal.
+	TextFontPrimary = Esc + "10m"
+	TextFontAlt1    = Esc + "11m"
+	TextFontAlt2    = Esc + "12m"
+	TextFontAlt3    = Esc + "13m"
+	TextFontAlt4    = Esc + "14m"
+	TextFontAlt5    = Esc + "15m"
+	TextFontAlt6    = Esc + "16m"
+	TextFontAlt7    = Esc + "17m"
+	TextFontAlt8    = Esc + "18m"
+	TextFontAlt9    = Esc + "19m"
+
+	// TextFraktur selects a blackletter font. It is turned off by
+	// TextItalicOff, as is italic text.
+	TextFraktur   = Esc + "20m"
+	TextItalicOff = Esc + "23m"
+
+	TextFramed    = Esc + "51m"
+	TextEncircled = Esc + "52m"
+	TextFramedOff = Esc + "54m" // Turns off both framed and encircled text
+)
+
+// Left and right margins mode (DECLRMM), supported by VT420-compatible
+// terminals such as xterm. While it is enabled, SetLeftRightMargins restricts
+// scrolling, inserting and deleting to a range of columns, and CursorSave is
+// no longer recognized; use "\u001B7" and "\u001B8" to save and restore the
+// cursor instead. Disabling the mode removes the margins.
+const (
+	LeftRightMarginsEnable  = Esc + "?69h"
+	LeftRightMarginsDisable = Esc + "?69l"
+)
+
+// Column mode (DECCOLM) switches the screen between 132 and 80 columns,
+// clearing it and resetting the margins. xterm only allows it once
+// Allow132ColumnsEnable is set.
+const (
+	Columns132            = Esc + "?3h"
+	Columns80             = Esc + "?3l"
+	Allow132ColumnsEnable = Esc + "?40h"
+
+	// ScreenAlignmentTest fills the screen with the letter E, and resets the
+	// margins and cursor position (DECALN).
+	ScreenAlignmentTest = "\u001B#8"
+)
+
+// Cursor styles (DECSCUSR). CursorStyleDefault restores the style configured
+// by the user, which TerminalGuard does when a style was set through it.
+const (
+	CursorStyleDefault      = Esc + "0 q"
+	CursorBlinkingBlock     = Esc + "1 q"
+	CursorSteadyBlock       = Esc + "2 q"
+	CursorBlinkingUnderline = Esc + "3 q"
+	CursorSteadyUnderline   = Esc + "4 q"
+	CursorBlinkingBar       = Esc + "5 q"
+	CursorSteadyBar         = Esc + "6 q"
+)
+
+// SetCursorStyle returns an escape sequence to set the style of the cursor to
+// a value of DECSCUSR, from 0 to 6 as in the constants above; terminals may
+// support more.
+func SetCursorStyle(style int) string {
+	return Esc + strconv.Itoa(style) + " q"
+}
+
 // CursorPosX returns an escape sequence to move the cursor to an x-coordinate
 // (column) at the current y-coordinate (row), where 0 is the leftmost.
 func CursorPosX(x int) string {
@@ -175,9 +298,29 @@ func TextDeleteLines(n int) string {
 	return Esc + strconv.Itoa(n) + "M"
 }
 
+// SetScrollRegion returns an escape sequence to restrict scrolling to rows top
+// through bottom, inclusive, where 0 is the topmost (DECSTBM). Scrolling, and
+// line feeds on the bottom row of the region, then leave the rows outside of
+// it in place. The cursor is moved to the top-left corner.
+func SetScrollRegion(top, bottom int) string {
+	return Esc + strconv.Itoa(top+1) + ";" + strconv.Itoa(bottom+1) + "r"
+}
+
+// ScrollRegionReset removes the scroll region set by SetScrollRegion, and moves
+// the cursor to the top-left corner.
+const ScrollRegionReset = Esc + "r"
+
+// SetLeftRightMargins returns an escape sequence to set the left and right
+// margins to columns left and right, inclusive, where 0 is the leftmost
+// (DECSLRM). It only takes effect while LeftRightMarginsEnable is set, and
+// moves the cursor to the top-left corner.
+func SetLeftRightMargins(left, right int) string {
+	return Esc + strconv.Itoa(left+1) + ";" + strconv.Itoa(right+1) + "s"
+}
+
 // Link returns an escape sequence to represent linked text.
 func Link(url, text string) string {
-	return Osc + "8;;" + url + Bel + text + Osc + "8;;" + Bel
+	return Osc + "8;;" + url + oscEnd() + text + Osc + "8;;" + oscEnd()
 }
 
 // Image returns an escape sequence to display an image, preserving the original
@@ -188,21 +331,25 @@ func Image(img []byte) string {
 
 // ImageWidthHeight returns an escape sequence to display an image.
 func ImageWidthHeight(img []byte, height, width int, preserveAspectRatio bool) string {
-	s := Osc + "1337;File=inline=1"
+	b := getBuffer()
+	defer putBuffer(b)
+	b.WriteString(Osc + "1337;File=inline=1")
 	if height > 0 {
-		s += ";width=" + strconv.Itoa(height)
+		b.WriteString(";width=" + strconv.Itoa(height))
 	}
 	if width > 0 {
-		s += ";height=" + strconv.Itoa(width)
+		b.WriteString(";height=" + strconv.Itoa(width))
 	}
 	if !preserveAspectRatio {
-		s += ";preserveAspectRatio=0"
+		b.WriteString(";preserveAspectRatio=0")
 	}
-
-	return s + ":" + base64.StdEncoding.EncodeToString(img) + Bel
+	b.WriteByte(':')
+	writeBase64(b, img)
+	b.WriteString(oscEnd())
+	return b.String()
 }
 
 // SetCwd returns an escape sequence to set the current working directory.
 func SetCwd(dir string) string {
-	return Osc + "50;CurrentDir=" + dir + Bel
+	return Osc + "50;CurrentDir=" + dir + oscEnd()
 }
@@ -0,0 +1,41 @@
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package escapes
+
+import (
+	"os"
+	"time"
+)
+
+// There are no resizes to notify nor poll on this platform.
+const resizePollInterval time.Duration = 0
+
+// notifyResize does nothing, as there is no terminal to resize.
+func notifyResize(c chan<- os.Signal) {}
+
+// GetConsoleSize gets the dimensions of the console. It is not supported on
+// this platform, and returns ErrNotSupported.
+func GetConsoleSize(fd uintptr) (*ConsoleDim, error) {
+	return nil, ErrNotSupported
+}
+
+// TerminalState is the state of a terminal, as saved by MakeRaw.
+type TerminalState struct{}
+
+// MakeRaw puts the terminal connected to fd in raw mode. It is not supported
+// on this platform, and returns ErrNotSupported.
+func MakeRaw(fd uintptr) (*TerminalState, error) {
+	return nil, ErrNotSupported
+}
+
+// RestoreTerminal restores the terminal connected to fd to a previously saved
+// state. It is not supported on this platform, and returns ErrNotSupported.
+func RestoreTerminal(fd uintptr, state *TerminalState) error {
+	return ErrNotSupported
+}
+
+// IsTerminal reports whether f is connected to a terminal, which is never the
+// case on this platform.
+func IsTerminal(f *os.File) bool {
+	return false
+}
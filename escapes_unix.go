@@ -3,9 +3,21 @@
 package escapes
 
 import (
+	"os"
+	"os/signal"
+	"time"
+
 	"golang.org/x/sys/unix"
 )
 
+// Terminals notify resizes with SIGWINCH, so the size is not polled.
+const resizePollInterval time.Duration = 0
+
+// notifyResize relays resizes of the terminal to c.
+func notifyResize(c chan<- os.Signal) {
+	signal.Notify(c, unix.SIGWINCH)
+}
+
 func GetConsoleSize(fd uintptr) (*ConsoleDim, error) {
 	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
 	if err != nil {
@@ -18,3 +30,46 @@ func GetConsoleSize(fd uintptr) (*ConsoleDim, error) {
 		Cols: int(ws.Col),
 	}, nil
 }
+
+// TerminalState is the state of a terminal, as saved by MakeRaw.
+type TerminalState struct {
+	termios unix.Termios
+}
+
+// MakeRaw puts the terminal connected to fd in raw mode, where input is
+// available byte by byte without echo or line editing, and signals are not
+// generated for control keys. It returns the previous state of the terminal,
+// to be restored with RestoreTerminal.
+func MakeRaw(fd uintptr) (*TerminalState, error) {
+	termios, err := unix.IoctlGetTermios(int(fd), ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	state := &TerminalState{termios: *termios}
+
+	// Same settings as cfmakeraw(3)
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP |
+		unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(int(fd), ioctlSetTermios, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// RestoreTerminal restores the terminal connected to fd to a previously saved
+// state.
+func RestoreTerminal(fd uintptr, state *TerminalState) error {
+	return unix.IoctlSetTermios(int(fd), ioctlSetTermios, &state.termios)
+}
+
+// IsTerminal reports whether f is connected to a terminal.
+func IsTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetTermios(int(f.Fd()), ioctlGetTermios)
+	return err == nil
+}
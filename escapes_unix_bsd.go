@@ -0,0 +1,11 @@
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package escapes
+
+import "golang.org/x/sys/unix"
+
+// ioctl requests to get and set the termios structure
+const (
+	ioctlGetTermios = unix.TIOCGETA
+	ioctlSetTermios = unix.TIOCSETA
+)
@@ -0,0 +1,11 @@
+// +build aix linux solaris
+
+package escapes
+
+import "golang.org/x/sys/unix"
+
+// ioctl requests to get and set the termios structure
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
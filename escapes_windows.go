@@ -3,7 +3,12 @@
 package escapes
 
 import (
+	"os"
+	"strings"
 	"syscall"
+	"time"
+	"unicode/utf16"
+	"unsafe"
 
 	"golang.org/x/sys/windows"
 )
@@ -40,6 +45,12 @@ func DisableVirtualTerminal(fd uintptr) error {
 	return nil
 }
 
+// Consoles do not notify resizes with a signal, so the size is polled.
+const resizePollInterval = 250 * time.Millisecond
+
+// notifyResize does nothing, as there is no signal for resizes.
+func notifyResize(c chan<- os.Signal) {}
+
 // GetConsoleSize gets the dimensions of the console.
 func GetConsoleSize(fd uintptr) (*ConsoleDim, error) {
 	var info windows.ConsoleScreenBufferInfo
@@ -53,3 +64,73 @@ func GetConsoleSize(fd uintptr) (*ConsoleDim, error) {
 		Cols: int(info.Window.Right - info.Window.Left),
 	}, nil
 }
+
+// TerminalState is the state of a terminal, as saved by MakeRaw.
+type TerminalState struct {
+	mode uint32
+}
+
+// MakeRaw puts the console input handle fd in raw mode, where input is
+// available key by key without echo or line editing, and is delivered as
+// virtual terminal sequences. It returns the previous state of the console, to
+// be restored with RestoreTerminal.
+func MakeRaw(fd uintptr) (*TerminalState, error) {
+	var mode uint32
+	if err := syscall.GetConsoleMode(syscall.Handle(fd), &mode); err != nil {
+		return nil, err
+	}
+	raw := mode &^ (windows.ENABLE_ECHO_INPUT | windows.ENABLE_PROCESSED_INPUT | windows.ENABLE_LINE_INPUT)
+	raw |= windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+
+	if err := windows.SetConsoleMode(windows.Handle(fd), raw); err != nil {
+		return nil, err
+	}
+	return &TerminalState{mode: mode}, nil
+}
+
+// RestoreTerminal restores the console handle fd to a previously saved state.
+func RestoreTerminal(fd uintptr, state *TerminalState) error {
+	return windows.SetConsoleMode(windows.Handle(fd), state.mode)
+}
+
+// IsTerminal reports whether f is connected to a console.
+func IsTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
+
+var procGetFileInformationByHandleEx = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetFileInformationByHandleEx")
+
+// IsCygwinTerminal reports whether f is connected to a Cygwin or MSYS2
+// terminal, such as mintty. These terminals are pipes rather than consoles, so
+// IsTerminal reports false for them.
+func IsCygwinTerminal(f *os.File) bool {
+	h := windows.Handle(f.Fd())
+	if t, err := windows.GetFileType(h); err != nil || t != windows.FILE_TYPE_PIPE {
+		return false
+	}
+	if procGetFileInformationByHandleEx.Find() != nil {
+		return false
+	}
+
+	// FILE_NAME_INFO, with room for the longest path
+	var info struct {
+		length uint32
+		name   [syscall.MAX_PATH]uint16
+	}
+	const fileNameInfo = 2
+	r, _, _ := procGetFileInformationByHandleEx.Call(uintptr(h), fileNameInfo,
+		uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+	if r == 0 || info.length/2 > syscall.MAX_PATH {
+		return false
+	}
+
+	// The pipe is named like \msys-1888ae32e00d56aa-pty0-to-master
+	name := string(utf16.Decode(info.name[:info.length/2]))
+	parts := strings.Split(name, "-")
+	return len(parts) >= 5 &&
+		(parts[0] == `\msys` || parts[0] == `\cygwin`) &&
+		strings.HasPrefix(parts[2], "pty") &&
+		(parts[3] == "from" || parts[3] == "to") &&
+		parts[4] == "master"
+}
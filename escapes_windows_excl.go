@@ -2,6 +2,8 @@
 
 package escapes
 
+import "os"
+
 // We make the assumption that non-Windows OSes support escape sequences by
 // default. Thus, EnableVirtualTerminal and DisableVirtualTerminal are defined
 // as no-ops.
@@ -19,3 +21,10 @@ func EnableVirtualTerminal(fd uintptr) error {
 func DisableVirtualTerminal(fd uintptr) error {
 	return nil
 }
+
+// IsCygwinTerminal reports whether f is connected to a Cygwin or MSYS2
+// terminal. It is only effective when built for Windows. On other OSes, it
+// will simply return false.
+func IsCygwinTerminal(f *os.File) bool {
+	return false
+}
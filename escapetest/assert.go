@@ -0,0 +1,181 @@
+package escapetest
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	escapes "github.com/bbfh-dev/ansi-escapes"
+)
+
+// AssertEqual reports an error to t if got differs from want. The error shows
+// both sides as a diff of their escape sequences and text, one per line, as
+// written by Describe. It returns whether they are equal.
+func AssertEqual(t testing.TB, got, want string) bool {
+	t.Helper()
+	if got == want {
+		return true
+	}
+	t.Errorf("output differs (-want +got):\n%s", Diff(Describe(want), Describe(got)))
+	return false
+}
+
+// Describe splits s into escape sequences and text, and returns them one per
+// line in readable form: text is quoted, and sequences are spelled out with
+// their introducer, parameters and final bytes, followed by the name of the
+// common ones, such as
+//
+//	CSI 1;31 m  SGR
+//	"hello"
+//	CSI 0 m  SGR
+//
+// An escape sequence cut off at the end of s is described as incomplete,
+// such as "incomplete CSI 12".
+func Describe(s string) []string {
+	var lines []string
+	for _, t := range escapes.Tokenize(s) {
+		lines = append(lines, describeToken(t))
+	}
+	return lines
+}
+
+// Names of common escape sequences, by final byte
+var (
+	csiNames = map[string]string{
+		"@": "ICH", "A": "CUU", "B": "CUD", "C": "CUF", "D": "CUB",
+		"E": "CNL", "F": "CPL", "G": "CHA", "H": "CUP", "J": "ED",
+		"K": "EL", "L": "IL", "M": "DL", "P": "DCH", "S": "SU",
+		"T": "SD", "X": "ECH", "d": "VPA", "f": "HVP", "m": "SGR",
+		"n": "DSR", "r": "DECSTBM", "s": "SCOSC", "u": "SCORC",
+		"?h": "DECSET", "?l": "DECRST", "c": "DA", " q": "DECSCUSR",
+	}
+	escNames = map[string]string{
+		"7": "DECSC", "8": "DECRC", "D": "IND", "E": "NEL", "M": "RI",
+		"c": "RIS", "=": "DECKPAM", ">": "DECKPNM", "\\": "ST",
+	}
+	introNames = map[escapes.TokenKind]string{
+		escapes.TokenCSI: "CSI", escapes.TokenOSC: "OSC", escapes.TokenDCS: "DCS",
+		escapes.TokenAPC: "APC", escapes.TokenPM: "PM", escapes.TokenSOS: "SOS",
+	}
+)
+
+func describeToken(t escapes.Token) string {
+	v := t.Value
+	if incomplete(t) {
+		// Cut off at the end of the output, with the introducer and
+		// whatever follows it
+		s, body := "incomplete ESC", v[1:]
+		if intro, ok := introNames[t.Kind]; ok {
+			s, body = "incomplete "+intro, v[2:]
+		}
+		if body != "" {
+			s += " " + quoteBytes(body)
+		}
+		return s
+	}
+	switch t.Kind {
+	case escapes.TokenText:
+		return strconv.Quote(v)
+	case escapes.TokenCSI:
+		body := v[2:]
+		i := len(body) - 1
+		for i > 0 && body[i-1] >= 0x20 && body[i-1] <= 0x2F {
+			i-- // Intermediate bytes belong with the final byte
+		}
+		params, final := body[:i], body[i:]
+		prefix := ""
+		if params != "" && params[0] >= '<' && params[0] <= '?' {
+			prefix, params = params[:1], params[1:]
+		}
+		s := "CSI " + prefix + params + " " + quoteBytes(final)
+		if name, ok := csiNames[prefix+final]; ok {
+			s += "  " + name
+		} else if name, ok := csiNames[final]; ok && prefix == "" {
+			s += "  " + name
+		}
+		return s
+	case escapes.TokenOSC, escapes.TokenDCS, escapes.TokenAPC, escapes.TokenPM, escapes.TokenSOS:
+		body, term := v[2:], ""
+		switch {
+		case strings.HasSuffix(body, escapes.St):
+			body, term = body[:len(body)-2], " ST"
+		case strings.HasSuffix(body, "\a"):
+			body, term = body[:len(body)-1], " BEL"
+		}
+		return introNames[t.Kind] + " " + quoteBytes(body) + term
+	default:
+		s := "ESC " + quoteBytes(v[1:])
+		if name, ok := escNames[v[1:]]; ok {
+			s += "  " + name
+		}
+		return s
+	}
+}
+
+// incomplete reports whether t is an escape sequence missing its final byte
+// or terminator.
+func incomplete(t escapes.Token) bool {
+	v := t.Value
+	last := v[len(v)-1]
+	switch t.Kind {
+	case escapes.TokenText:
+		return false
+	case escapes.TokenCSI:
+		return len(v) < 3 || last < 0x40 || last > 0x7E
+	case escapes.TokenOSC, escapes.TokenDCS, escapes.TokenAPC, escapes.TokenPM, escapes.TokenSOS:
+		if t.Kind == escapes.TokenOSC && len(v) > 2 && last == '\a' {
+			return false
+		}
+		return len(v) < 4 || !strings.HasSuffix(v, escapes.St)
+	default:
+		return len(v) < 2 || (last >= 0x20 && last <= 0x2F)
+	}
+}
+
+// quoteBytes returns s as it is if it is printable ASCII, or quoted
+// otherwise.
+func quoteBytes(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] >= 0x7F {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// Diff returns a line by line diff from the lines of want to those of got,
+// with lines only in want prefixed by "-", those only in got by "+", and
+// common ones by a space.
+func Diff(want, got []string) string {
+	// Longest common subsequence, from the end
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			b.WriteString("  " + want[i] + "\n")
+			i, j = i+1, j+1
+		case i < len(want) && (j == len(got) || lcs[i+1][j] >= lcs[i][j+1]):
+			b.WriteString("- " + want[i] + "\n")
+			i++
+		default:
+			b.WriteString("+ " + got[j] + "\n")
+			j++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package escapetest_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	escapes "github.com/bbfh-dev/ansi-escapes"
+	"github.com/bbfh-dev/ansi-escapes/escapetest"
+)
+
+func TestDescribe(t *testing.T) {
+	got := escapetest.Describe("\x1b[1;31mhi\x1b[0m\x1b[?25l\x1b7\x1b]8;;http://x\x1b\\\x1b[ q\x01")
+	want := []string{
+		"CSI 1;31 m  SGR",
+		`"hi"`,
+		"CSI 0 m  SGR",
+		"CSI ?25 l  DECRST",
+		"ESC 7  DECSC",
+		"OSC 8;;http://x ST",
+		"CSI   q  DECSCUSR",
+		`"\x01"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Describe =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDescribeIncomplete(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"ab\x1b[", []string{`"ab"`, "incomplete CSI"}},
+		{"\x1b[12", []string{"incomplete CSI 12"}},
+		{"\x1b]8;;http://x", []string{"incomplete OSC 8;;http://x"}},
+		{"\x1b]0;t\x1b", []string{`incomplete OSC "0;t\x1b"`}},
+		{"a\x1b", []string{`"a"`, "incomplete ESC"}},
+		{"\x1b(", []string{"incomplete ESC ("}},
+	}
+	for _, tt := range tests {
+		if got := escapetest.Describe(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Describe(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := escapetest.Diff([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})
+	want := "  a\n- b\n+ x\n  c\n+ d\n"
+	if got != want {
+		t.Errorf("Diff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAssertEqual(t *testing.T) {
+	if !escapetest.AssertEqual(t, escapes.CursorPos(1, 2), "\x1b[3;2H") {
+		return
+	}
+	var r recorder
+	if escapetest.AssertEqual(&r, "\x1b[1mx", "\x1b[2mx") {
+		t.Errorf("AssertEqual of different strings = true")
+	}
+	if want := "- CSI 2 m  SGR\n+ CSI 1 m  SGR\n  \"x\""; !strings.Contains(r.errors, want) {
+		t.Errorf("AssertEqual reported %q, want it to contain %q", r.errors, want)
+	}
+}
+
+// recorder records the errors reported to it.
+type recorder struct {
+	testing.TB
+	errors string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors += fmt.Sprintf(format, args...)
+}
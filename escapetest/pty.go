@@ -0,0 +1,225 @@
+// Package escapetest provides helpers to test programs writing escape
+// sequences, by running them under a pseudo-terminal and inspecting what they
+// wrote and the resulting screen.
+package escapetest
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	escapes "github.com/bbfh-dev/ansi-escapes"
+)
+
+// ErrUnsupported is returned when pseudo-terminals are not supported on the
+// platform.
+var ErrUnsupported = errors.New("escapetest: pseudo-terminals are not supported on this platform")
+
+// ErrTimeout is returned when the function or command run did not finish in
+// time.
+var ErrTimeout = errors.New("escapetest: timed out")
+
+// Options configure a run under a pseudo-terminal.
+type Options struct {
+	// Width and Height are the size of the terminal, 80 by 24 if zero.
+	Width, Height int
+
+	// Input is typed into the terminal once the run starts, one string at a
+	// time, each after InputDelay, so that keys such as Esc are not merged.
+	Input []string
+
+	// InputDelay is the delay before each string of Input, 50ms if zero.
+	InputDelay time.Duration
+
+	// Timeout is the time after which the run is abandoned, 10s if zero.
+	Timeout time.Duration
+}
+
+// Result is the outcome of a run under a pseudo-terminal.
+type Result struct {
+	// Output is everything written to the terminal, including the echo of
+	// the input unless the terminal was put in raw mode.
+	Output []byte
+
+	// Terminal is the state of the terminal after the output was written.
+	Terminal *escapes.Emulator
+}
+
+// Screen returns the text shown on the screen after the run, without styles.
+func (r *Result) Screen() string {
+	return r.Terminal.String()
+}
+
+// Run calls fn with the slave side of a new pseudo-terminal, which is both its
+// input and output, and returns what fn wrote once it returns.
+func Run(fn func(tty *os.File), opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+	master, tty, err := openPTY(opts.Width, opts.Height)
+	if err != nil {
+		return nil, err
+	}
+	defer master.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(tty)
+	}()
+	r := newRun(master, opts)
+	select {
+	case <-done:
+	case <-time.After(opts.Timeout):
+		tty.Close()
+		return r.result(), ErrTimeout
+	}
+
+	// Closing the slave side ends the output once it is read, unless it is
+	// still used, such as by a goroutine reading input with the file in
+	// blocking mode, so the output also ends once it was all read
+	tty.Close()
+	return r.drain(opts.Timeout)
+}
+
+// RunCommand runs cmd in a new session whose controlling terminal is a new
+// pseudo-terminal, and returns what it wrote once it exits. The standard
+// input, output and error of cmd are the terminal unless they are set. The
+// error is that of cmd.Wait if the command ran, such as an *exec.ExitError.
+func RunCommand(cmd *exec.Cmd, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+	master, tty, err := openPTY(opts.Width, opts.Height)
+	if err != nil {
+		return nil, err
+	}
+	defer master.Close()
+
+	if cmd.Stdin == nil {
+		cmd.Stdin = tty
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = tty
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = tty
+	}
+	setControllingTerminal(cmd)
+	err = cmd.Start()
+	tty.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	r := newRun(master, opts)
+	res, err := r.wait(opts.Timeout)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return res, err
+	}
+	return res, cmd.Wait()
+}
+
+func (o Options) withDefaults() Options {
+	if o.Width <= 0 {
+		o.Width = 80
+	}
+	if o.Height <= 0 {
+		o.Height = 24
+	}
+	if o.InputDelay <= 0 {
+		o.InputDelay = 50 * time.Millisecond
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return o
+}
+
+// run records the output of a pseudo-terminal, and types its input.
+type run struct {
+	mu       sync.Mutex
+	output   []byte
+	terminal *escapes.Emulator
+	done     chan struct{}
+}
+
+func newRun(master *os.File, opts Options) *run {
+	r := &run{
+		terminal: escapes.NewEmulator(opts.Width, opts.Height),
+		done:     make(chan struct{}),
+	}
+	go r.read(master)
+	go func() {
+		for _, s := range opts.Input {
+			select {
+			case <-time.After(opts.InputDelay):
+			case <-r.done:
+				return
+			}
+			if _, err := io.WriteString(master, s); err != nil {
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// read reads output until the slave side is closed everywhere, which makes
+// reading fail.
+func (r *run) read(master *os.File) {
+	defer close(r.done)
+	p := make([]byte, 4096)
+	for {
+		n, err := master.Read(p)
+		r.mu.Lock()
+		r.output = append(r.output, p[:n]...)
+		r.terminal.Write(p[:n])
+		r.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wait waits for the output to end.
+func (r *run) wait(timeout time.Duration) (*Result, error) {
+	select {
+	case <-r.done:
+		return r.result(), nil
+	case <-time.After(timeout):
+		return r.result(), ErrTimeout
+	}
+}
+
+// drain waits for the output to end, or to stop for a moment.
+func (r *run) drain(timeout time.Duration) (*Result, error) {
+	const idle = 50 * time.Millisecond
+	ticker := time.NewTicker(idle)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	n := -1
+	for {
+		select {
+		case <-r.done:
+			return r.result(), nil
+		case <-ticker.C:
+			r.mu.Lock()
+			stopped := len(r.output) == n
+			n = len(r.output)
+			r.mu.Unlock()
+			if stopped {
+				return r.result(), nil
+			}
+		case <-deadline:
+			return r.result(), ErrTimeout
+		}
+	}
+}
+
+func (r *run) result() *Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Result{Output: append([]byte(nil), r.output...), Terminal: r.terminal}
+}
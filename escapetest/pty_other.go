@@ -0,0 +1,14 @@
+// +build !linux
+
+package escapetest
+
+import (
+	"os"
+	"os/exec"
+)
+
+func openPTY(width, height int) (master, slave *os.File, err error) {
+	return nil, nil, ErrUnsupported
+}
+
+func setControllingTerminal(cmd *exec.Cmd) {}
@@ -0,0 +1,82 @@
+package escapetest_test
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	escapes "github.com/bbfh-dev/ansi-escapes"
+	"github.com/bbfh-dev/ansi-escapes/escapetest"
+)
+
+func TestRun(t *testing.T) {
+	res, err := escapetest.Run(func(tty *os.File) {
+		tty.WriteString("one\r\ntwo" + escapes.CursorPos(1, 0) + "X" + escapes.CursorHide)
+	}, escapetest.Options{Width: 10, Height: 3})
+	if err == escapetest.ErrUnsupported {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := res.Screen(), "oXe\ntwo\n"; got != want {
+		t.Errorf("Screen() = %q, want %q", got, want)
+	}
+	if x, y, visible := res.Terminal.Cursor(); x != 2 || y != 0 || visible {
+		t.Errorf("Cursor() = %d, %d, %v, want 2, 0, false", x, y, visible)
+	}
+}
+
+func TestRunInput(t *testing.T) {
+	res, err := escapetest.Run(func(tty *os.File) {
+		state, err := escapes.MakeRaw(tty.Fd())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer escapes.RestoreTerminal(tty.Fd(), state)
+
+		d := escapes.NewInputDecoder(tty)
+		for {
+			ev, err := d.ReadEvent()
+			if err != nil {
+				return
+			}
+			k, ok := ev.(escapes.KeyEvent)
+			if !ok || k.Key == escapes.KeyEnter {
+				return
+			}
+			tty.WriteString(k.String() + " ")
+		}
+	}, escapetest.Options{Input: []string{"a", "\x1b[A", "\x03", "\r"}})
+	if err == escapetest.ErrUnsupported {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Raw mode turns off the echo of the input
+	if got, want := string(res.Output), "a up ctrl+c "; got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip(err)
+	}
+	cmd := exec.Command("sh", "-c", `printf 'a\033[1;31mb\033[0m'; test -t 1 && exit 3`)
+	res, err := escapetest.RunCommand(cmd, escapetest.Options{Width: 10, Height: 2})
+	if err == escapetest.ErrUnsupported {
+		t.Skip(err)
+	}
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 3 {
+		t.Fatalf("RunCommand error = %v, want exit status 3", err)
+	}
+	if got, want := string(res.Output), "a\x1b[1;31mb\x1b[0m"; got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+	if got := res.Terminal.Screen().Cell(1, 0).Style; got.Fg != escapes.ANSIColor(1) || got.Attrs != escapes.AttrBold {
+		t.Errorf("style of b = %+v, want bold red", got)
+	}
+}
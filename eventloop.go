@@ -0,0 +1,147 @@
+package escapes
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// ResizeEvent reports the size of the terminal, when an EventLoop starts and
+// whenever the terminal is resized.
+type ResizeEvent ConsoleDim
+
+// TickEvent reports that the tick interval of an EventLoop elapsed, such as
+// to animate spinners.
+type TickEvent struct {
+	Time time.Time
+}
+
+// EventLoop merges the input events of an InputDecoder, the resizes of the
+// terminal and periodic ticks into a single channel of events.
+type EventLoop struct {
+	// TickInterval is the interval between TickEvents, or 0 for none.
+	TickInterval time.Duration
+
+	d   *InputDecoder
+	out *os.File
+	err error
+}
+
+// NewEventLoop returns an event loop reading input events from d, and the
+// size of the terminal from out, which may be nil to not report resizes.
+func NewEventLoop(d *InputDecoder, out *os.File) *EventLoop {
+	return &EventLoop{d: d, out: out}
+}
+
+// Run starts the loop, and returns the channel of its events: input events,
+// ResizeEvents and TickEvents. The channel is closed when ctx is canceled or
+// reading input fails, after which Err returns the reason. Events are not
+// buffered, so they should be received promptly. Run must be called only once,
+// as the InputDecoder keeps being read until its reader fails.
+func (l *EventLoop) Run(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go l.run(ctx, events)
+	return events
+}
+
+// Err returns the reason why the events of the loop stopped: the error of ctx,
+// or the error reading input, such as io.EOF. It must be called only once the
+// channel of events is closed.
+func (l *EventLoop) Err() error {
+	return l.err
+}
+
+func (l *EventLoop) run(ctx context.Context, events chan<- Event) {
+	defer close(events)
+
+	input := make(chan readEvent)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			ev, err := l.d.ReadEvent()
+			select {
+			case input <- readEvent{ev, err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var (
+		size    ConsoleDim
+		resize  = make(chan os.Signal, 1)
+		poll    <-chan time.Time
+		tick    <-chan time.Time
+		pending []Event // Events waiting to be received
+	)
+	if l.out != nil {
+		if dim, err := GetConsoleSize(l.out.Fd()); err == nil {
+			size = *dim
+			pending = append(pending, ResizeEvent(size))
+		}
+		notifyResize(resize)
+		defer signal.Stop(resize)
+		if resizePollInterval > 0 {
+			ticker := time.NewTicker(resizePollInterval)
+			defer ticker.Stop()
+			poll = ticker.C
+		}
+	}
+	if l.TickInterval > 0 {
+		ticker := time.NewTicker(l.TickInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	resized := func() {
+		if dim, err := GetConsoleSize(l.out.Fd()); err == nil && *dim != size {
+			size = *dim
+			pending = append(pending, ResizeEvent(size))
+		}
+	}
+	for {
+		// Input is only read once the previous events were received
+		var (
+			send chan<- Event
+			next Event
+			in   = input
+		)
+		if len(pending) > 0 {
+			send, next, in = events, pending[0], nil
+		}
+
+		select {
+		case <-ctx.Done():
+			l.err = ctx.Err()
+			return
+		case send <- next:
+			pending = pending[1:]
+		case res := <-in:
+			if res.err != nil {
+				l.err = res.err
+				return
+			}
+			pending = append(pending, res.ev)
+		case <-resize:
+			resized()
+		case <-poll:
+			resized()
+		case t := <-tick:
+			// Ticks are dropped rather than piling up behind slow receivers
+			if len(pending) == 0 {
+				pending = append(pending, TickEvent{t})
+			}
+		}
+	}
+}
+
+// readEvent is the result of InputDecoder.ReadEvent.
+type readEvent struct {
+	ev  Event
+	err error
+}
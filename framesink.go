@@ -0,0 +1,147 @@
+package escapes
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+)
+
+// FrameSink displays a stream of frames at the same place, such as a video or
+// a shared screen, whatever the graphics protocol. WriteFrame must not be
+// called concurrently.
+type FrameSink interface {
+	// WriteFrame displays img in place of the previous frame.
+	WriteFrame(img image.Image) error
+
+	// Close frees the resources held by the terminal for the frames.
+	Close() error
+}
+
+// NewFrameSink returns a FrameSink writing frames to w with protocol p, such
+// as the one returned by DetectGraphicsProtocol, converted with opts; see
+// Sixel and HalfBlocks. With GraphicsKitty, opts.Width is the number of
+// columns frames are scaled to.
+//
+// Frames are drawn at the cursor position with GraphicsKitty and
+// GraphicsSixel, and at the top-left corner of the screen with
+// GraphicsHalfBlocks. Each frame is written in a single call, wrapped in
+// synchronized update sequences, and only the cells that changed are redrawn
+// with GraphicsHalfBlocks. Frames identical to the previous one are skipped.
+//
+// If interactive sequences are disabled, frames are written as plain lines
+// with GraphicsHalfBlocks, and WriteFrame returns ErrNotInteractive with the
+// other protocols.
+func NewFrameSink(w io.Writer, p GraphicsProtocol, opts RasterOptions) FrameSink {
+	switch p {
+	case GraphicsKitty:
+		return &kittySink{w: w, cols: opts.Width, ids: [2]int{NewKittyID(), NewKittyID()}}
+	case GraphicsSixel:
+		return &sixelSink{w: w, opts: opts}
+	}
+	return &halfBlockSink{w: w, opts: opts}
+}
+
+// halfBlockSink draws frames with half blocks on a DoubleBuffer, so that only
+// the cells that changed are redrawn.
+type halfBlockSink struct {
+	w             io.Writer
+	opts          RasterOptions
+	buf           *DoubleBuffer
+	width, height int // Size of the screens of buf, in cells
+}
+
+func (s *halfBlockSink) WriteFrame(img image.Image) error {
+	h := newHalfBlockImage(img, s.opts)
+	if s.buf == nil {
+		s.buf = NewDoubleBuffer(s.w, h.width, h.rows())
+	} else if s.width != h.width || s.height != h.rows() {
+		s.buf.Resize(h.width, h.rows())
+	}
+	s.width, s.height = h.width, h.rows()
+	s.buf.Draw(func(back *Screen) {
+		for y := 0; y < h.rows(); y++ {
+			for x := 0; x < h.width; x++ {
+				style, ch, _ := h.cell(x, y)
+				back.SetCell(x, y, Cell{Rune: ch, Style: style})
+			}
+		}
+	})
+	return s.buf.Swap()
+}
+
+func (s *halfBlockSink) Close() error {
+	return nil
+}
+
+// sixelSink draws frames as sixel graphics, in full, returning the cursor to
+// where the first frame was drawn.
+type sixelSink struct {
+	w     io.Writer
+	opts  RasterOptions
+	front string // Sequence of the frame shown
+}
+
+func (s *sixelSink) WriteFrame(img image.Image) error {
+	if !Interactive() {
+		return ErrNotInteractive
+	}
+	frame := Sixel(img, s.opts)
+	if frame == s.front {
+		return nil
+	}
+	s.front = frame
+	_, err := io.WriteString(s.w, SyncUpdateBegin+CursorSave+frame+CursorRestore+SyncUpdateEnd)
+	return err
+}
+
+func (s *sixelSink) Close() error {
+	return nil
+}
+
+// kittySink displays frames with the kitty graphics protocol, alternating
+// between two images: each frame is transmitted to the image not shown, and
+// placed before the other one is deleted, so that no blank is ever shown.
+type kittySink struct {
+	w     io.Writer
+	cols  int
+	ids   [2]int
+	back  int    // Index in ids of the image the next frame is sent to
+	front []byte // PNG data of the frame shown
+	shown bool
+}
+
+func (s *kittySink) WriteFrame(img image.Image) error {
+	if !Interactive() {
+		return ErrNotInteractive
+	}
+	b := getBuffer()
+	defer putBuffer(b)
+	if err := png.Encode(b, img); err != nil {
+		return err
+	}
+	if s.shown && bytes.Equal(b.Bytes(), s.front) {
+		return nil
+	}
+	s.front = append(s.front[:0], b.Bytes()...)
+
+	id := s.ids[s.back]
+	frame := SyncUpdateBegin + KittyTransmit(id, s.front) +
+		KittyPlace(id, KittyPlacement{Cols: s.cols, KeepCursor: true})
+	if s.shown {
+		frame += KittyDeleteImage(s.ids[1-s.back])
+	}
+	frame += SyncUpdateEnd
+	s.back, s.shown = 1-s.back, true
+	_, err := io.WriteString(s.w, frame)
+	return err
+}
+
+func (s *kittySink) Close() error {
+	if !s.shown {
+		return nil
+	}
+	s.shown = false
+	_, err := io.WriteString(s.w, KittyDeleteImage(s.ids[0])+KittyDeleteImage(s.ids[1]))
+	return err
+}
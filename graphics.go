@@ -0,0 +1,41 @@
+package escapes
+
+import "os"
+
+// GraphicsProtocol is a way of displaying images in a terminal.
+type GraphicsProtocol int
+
+// Graphics protocols
+const (
+	// GraphicsHalfBlocks draws images with colored half blocks, which works
+	// on any terminal supporting colors; see HalfBlocks.
+	GraphicsHalfBlocks GraphicsProtocol = iota
+
+	// GraphicsKitty displays images with the kitty graphics protocol, at
+	// the full resolution of the terminal; see KittyImage.
+	GraphicsKitty
+
+	// GraphicsSixel draws images as sixel graphics, at the full resolution
+	// of the terminal but with at most 256 colors; see Sixel. It is never
+	// guessed from the environment; see Capabilities.SupportsSixel.
+	GraphicsSixel
+)
+
+// DetectGraphicsProtocol guesses the best graphics protocol of the terminal
+// from the environment: GraphicsKitty in kitty, WezTerm and Ghostty, and
+// GraphicsHalfBlocks otherwise.
+func DetectGraphicsProtocol() GraphicsProtocol {
+	if InTmux() {
+		return GraphicsHalfBlocks
+	}
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || term == "xterm-ghostty":
+		return GraphicsKitty
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "WezTerm", "ghostty":
+		return GraphicsKitty
+	}
+	return GraphicsHalfBlocks
+}
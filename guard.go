@@ -0,0 +1,196 @@
+package escapes
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Private modes that are enabled by default; all others are assumed to be
+// disabled by default.
+var defaultModes = map[int]bool{
+	7:  true, // Auto-wrap
+	25: true, // Cursor visible
+}
+
+// TerminalGuard is a writer that keeps track of the terminal modes changed by
+// the sequences written through it, such as the alternate screen, the hidden
+// cursor, mouse reporting and bracketed paste, as well as the cursor style and
+// raw mode. Restore puts all of them back to their defaults, so that the
+// user's terminal is left usable even if the program is interrupted or panics.
+type TerminalGuard struct {
+	w io.Writer
+
+	mu    sync.Mutex
+	order []int        // Changed modes, in the order they were first changed
+	modes map[int]bool // Current value of each changed mode
+	style bool         // Whether the cursor style was changed
+	rawFd uintptr
+	raw   *TerminalState
+
+	signals chan os.Signal
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewTerminalGuard returns a TerminalGuard that writes to w. When the process
+// receives an interrupt or termination signal, the guard restores the terminal
+// and exits with the status of the signal, 128 plus its number, as shells do:
+// 130 for an interrupt and 143 for a termination. Use Close to stop handling
+// signals.
+func NewTerminalGuard(w io.Writer) *TerminalGuard {
+	g := &TerminalGuard{
+		w:       w,
+		modes:   make(map[int]bool),
+		signals: make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+	}
+	signal.Notify(g.signals, os.Interrupt, syscall.SIGTERM)
+	go g.handleSignals()
+	return g
+}
+
+// Write writes p to the underlying writer, recording the private modes that p
+// enables or disables.
+func (g *TerminalGuard) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.record(string(p))
+	return g.w.Write(p)
+}
+
+// WriteString is like Write, but writes the contents of s.
+func (g *TerminalGuard) WriteString(s string) (int, error) {
+	return g.Write([]byte(s))
+}
+
+// MakeRaw puts the terminal connected to fd in raw mode, to be restored by
+// Restore.
+func (g *TerminalGuard) MakeRaw(fd uintptr) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	state, err := MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	if g.raw == nil {
+		g.rawFd, g.raw = fd, state
+	}
+	return nil
+}
+
+// Restore resets every mode changed through the guard to its default, in the
+// reverse order they were changed, resets the cursor and text styles, and
+// leaves raw mode.
+func (g *TerminalGuard) Restore() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var b strings.Builder
+	for i := len(g.order) - 1; i >= 0; i-- {
+		mode := g.order[i]
+		def := defaultModes[mode]
+		if g.modes[mode] == def {
+			continue
+		}
+		b.WriteString(Esc + "?" + strconv.Itoa(mode))
+		if def {
+			b.WriteByte('h')
+		} else {
+			b.WriteByte('l')
+		}
+	}
+	if g.style {
+		b.WriteString(CursorStyleDefault)
+	}
+	b.WriteString(ColorReset)
+	g.order, g.modes, g.style = nil, make(map[int]bool), false
+
+	_, err := io.WriteString(g.w, b.String())
+	if g.raw != nil {
+		if rerr := RestoreTerminal(g.rawFd, g.raw); err == nil {
+			err = rerr
+		}
+		g.raw = nil
+	}
+	return err
+}
+
+// RestoreOnPanic restores the terminal if the calling goroutine is panicking,
+// then continues panicking. It must be deferred directly:
+//
+//	defer guard.RestoreOnPanic()
+func (g *TerminalGuard) RestoreOnPanic() {
+	if r := recover(); r != nil {
+		g.Restore()
+		panic(r)
+	}
+}
+
+// Close stops handling signals and restores the terminal.
+func (g *TerminalGuard) Close() error {
+	g.once.Do(func() {
+		signal.Stop(g.signals)
+		close(g.done)
+	})
+	return g.Restore()
+}
+
+func (g *TerminalGuard) handleSignals() {
+	select {
+	case sig := <-g.signals:
+		g.Restore()
+		os.Exit(exitStatus(sig))
+	case <-g.done:
+	}
+}
+
+// exitStatus returns the exit status of a process terminated by one of the
+// signals handled by TerminalGuard. The numbers are those of POSIX, which are
+// also used on Windows.
+func exitStatus(sig os.Signal) int {
+	if sig == syscall.SIGTERM {
+		return 128 + 15
+	}
+	return 128 + 2
+}
+
+// record updates the modes with the DECSET and DECRST sequences in s.
+func (g *TerminalGuard) record(s string) {
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+
+		if strings.HasPrefix(seq, Esc) && strings.HasSuffix(seq, " q") {
+			g.style = true
+			continue
+		}
+		if len(seq) < 4 || seq[1] != '[' || seq[2] != '?' {
+			continue
+		}
+		final := seq[len(seq)-1]
+		if final != 'h' && final != 'l' {
+			continue
+		}
+		for _, param := range strings.Split(seq[3:len(seq)-1], ";") {
+			mode, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			if _, ok := g.modes[mode]; !ok {
+				g.order = append(g.order, mode)
+			}
+			g.modes[mode] = final == 'h'
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package escapes
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		sig  os.Signal
+		want int
+	}{
+		{os.Interrupt, 130},
+		{syscall.SIGTERM, 143},
+	}
+	for _, tt := range tests {
+		if got := exitStatus(tt.sig); got != tt.want {
+			t.Errorf("exitStatus(%v) = %d, want %d", tt.sig, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package escapes
+
+import (
+	"image"
+	"strings"
+)
+
+// HalfBlocks returns an image drawn with colored half blocks, two pixels per
+// cell, which works on any terminal supporting colors. Lines are separated by
+// newlines and end with the default style. Transparent pixels are left blank.
+// The height is scaled by the aspect ratio of cells, from CellSize, so that
+// the image is not stretched on terminals whose cells are not twice as high
+// as they are wide.
+//
+// With a palette of up to 256 colors, the image is dithered to the 256-color
+// palette, excluding the basic colors whose values depend on the terminal's
+// theme, or to the 16 basic colors.
+func HalfBlocks(img image.Image, opts RasterOptions) string {
+	h := newHalfBlockImage(img, opts)
+	var (
+		b      strings.Builder
+		active Style
+	)
+	for y := 0; y < h.rows(); y++ {
+		if y > 0 {
+			b.WriteString(Transition(active, Style{}) + "\n")
+			active = Style{}
+		}
+		for x := 0; x < h.width; x++ {
+			s, ch, ok := h.cell(x, y)
+			if !ok {
+				s = Style{Fg: active.Fg}
+			}
+			b.WriteString(Transition(active, s))
+			b.WriteRune(ch)
+			active = s
+		}
+	}
+	b.WriteString(Transition(active, Style{}))
+	return b.String()
+}
+
+// halfBlockImage is an image mapped to colors to be drawn with half blocks,
+// two pixels per cell.
+type halfBlockImage struct {
+	width, height int // In pixels
+	colors        []Color
+	opaque        []bool
+}
+
+// newHalfBlockImage scales img and maps its pixels to colors, as HalfBlocks
+// does.
+func newHalfBlockImage(img image.Image, opts RasterOptions) *halfBlockImage {
+	colors := opts.Colors
+	if colors <= 0 {
+		switch CurrentProfile() {
+		case ProfileTrueColor:
+			colors = 1 << 24
+		case ProfileANSI256:
+			colors = 256
+		default:
+			colors = 16
+		}
+	}
+
+	r := newRaster(img, opts.Width, halfBlockScale(CellSize()))
+
+	// Map every pixel to a color, or to the default color if transparent
+	h := &halfBlockImage{
+		width:  r.width,
+		height: r.height,
+		colors: make([]Color, len(r.pix)),
+		opaque: make([]bool, len(r.pix)),
+	}
+	if colors > 256 {
+		for i, p := range r.pix {
+			h.colors[i], h.opaque[i] = RGB(p.R, p.G, p.B), p.A >= 128
+		}
+		return h
+	}
+
+	table, toColor := ansi256Table, IndexedColor
+	if colors <= 16 {
+		table, toColor = ansiTable, ANSIColor
+	}
+	from := table.from
+	palette := make([][3]uint8, table.to-from)
+	for i := range palette {
+		palette[i] = paletteRGB(from + i)
+	}
+	nearest := func(c [3]float64) int {
+		return table.nearest(c) - from
+	}
+	for i, idx := range quantize(r, palette, nearest, opts.Dither) {
+		if idx >= 0 {
+			h.colors[i], h.opaque[i] = toColor(from+idx), true
+		}
+	}
+	return h
+}
+
+// rows returns the number of rows of cells of the image.
+func (h *halfBlockImage) rows() int {
+	return (h.height + 1) / 2
+}
+
+// cell returns the style and character of the cell at (x, y), or false if
+// both of its pixels are transparent.
+func (h *halfBlockImage) cell(x, y int) (Style, rune, bool) {
+	top := 2*y*h.width + x
+	bottom := top + h.width
+	hasBottom := 2*y+1 < h.height && h.opaque[bottom]
+	switch {
+	case h.opaque[top] && hasBottom:
+		return Style{Fg: h.colors[top], Bg: h.colors[bottom]}, '▀', true
+	case h.opaque[top]:
+		return Style{Fg: h.colors[top]}, '▀', true
+	case hasBottom:
+		return Style{Fg: h.colors[bottom]}, '▄', true
+	}
+	return Style{}, ' ', false
+}
+
+// halfBlockScale returns the factor by which HalfBlocks scales the height of
+// images so that their pixels, half a cell high, look square: 1 for cells
+// twice as high as they are wide.
+func halfBlockScale(cell PixelSize) float64 {
+	return 2 * float64(cell.Width) / float64(cell.Height)
+}
@@ -0,0 +1,58 @@
+package escapes
+
+// RotateHue returns c with its hue rotated by the given number of degrees,
+// keeping its saturation and lightness.
+func (c Color) RotateHue(degrees float64) Color {
+	h, s, l := c.HSL()
+	return FromHSL(h+degrees, s, l)
+}
+
+// Complementary returns c and the color opposite to it on the color wheel.
+func Complementary(c Color) []Color {
+	return harmony(c, 0, 180)
+}
+
+// SplitComplementary returns c and the two colors adjacent to its complement,
+// which contrast less harshly than the complement itself.
+func SplitComplementary(c Color) []Color {
+	return harmony(c, 0, 150, 210)
+}
+
+// Triadic returns c and the two colors evenly spaced from it on the color
+// wheel.
+func Triadic(c Color) []Color {
+	return harmony(c, 0, 120, 240)
+}
+
+// Analogous returns c between its two neighbors on the color wheel, 30 degrees
+// apart, in order of hue.
+func Analogous(c Color) []Color {
+	return harmony(c, -30, 0, 30)
+}
+
+func harmony(c Color, degrees ...float64) []Color {
+	colors := make([]Color, len(degrees))
+	for i, d := range degrees {
+		colors[i] = c.RotateHue(d)
+	}
+	return colors
+}
+
+// ThemeFromColor returns a theme derived from a base color: the accent is the
+// base color, info its analogous neighbor, and warnings and errors the colors
+// of its split complement. Success keeps the conventional green hue, adjusted
+// to the base color's saturation and lightness. The scale goes from a dark
+// shade of the base color to its complement.
+func ThemeFromColor(base Color) Theme {
+	h, s, l := base.HSL()
+	split := SplitComplementary(base)
+	return Theme{
+		Muted:   Style{Fg: FromHSL(h, s*0.2, 0.55)},
+		Accent:  Style{Fg: base},
+		Success: Style{Fg: FromHSL(130, s, l)},
+		Warning: Style{Fg: split[1]},
+		Error:   Style{Fg: split[2], Attrs: AttrBold},
+		Info:    Style{Fg: base.RotateHue(-30)},
+		Scale:   Gradient{FromHSL(h, s, l*0.4), base, base.RotateHue(180)},
+	}
+}
@@ -0,0 +1,48 @@
+package escapes
+
+import "math"
+
+// Gradient is a color scale made of colors evenly spaced between 0 and 1.
+type Gradient []Color
+
+// HeatGradient is the gradient used by HeatColor, going from green for low
+// values through yellow to red for high values.
+var HeatGradient = Gradient{
+	RGB(0x2E, 0xCC, 0x40),
+	RGB(0xFF, 0xDC, 0x00),
+	RGB(0xFF, 0x41, 0x36),
+}
+
+// At returns the color at position t of the gradient, interpolating linearly
+// between neighbouring colors. t is clamped to [0, 1].
+func (g Gradient) At(t float64) Color {
+	switch {
+	case len(g) == 0:
+		return DefaultColor
+	case len(g) == 1 || t <= 0 || math.IsNaN(t):
+		return g[0]
+	case t >= 1:
+		return g[len(g)-1]
+	}
+
+	pos := t * float64(len(g)-1)
+	i := int(pos)
+	frac := pos - float64(i)
+	r1, g1, b1 := g[i].rgb()
+	r2, g2, b2 := g[i+1].rgb()
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*frac))
+	}
+	return RGB(lerp(r1, r2), lerp(g1, g2), lerp(b1, b2))
+}
+
+// HeatColor returns a style whose foreground is the color of v on
+// HeatGradient, scaled between min and max. Like every style, it is rendered
+// using the closest colors available in the current color profile.
+func HeatColor(v, min, max float64) Style {
+	t := 0.0
+	if max > min {
+		t = (v - min) / (max - min)
+	}
+	return Style{Fg: HeatGradient.At(t)}
+}
@@ -0,0 +1,41 @@
+package escapes
+
+import (
+	"encoding/hex"
+	"image/color"
+)
+
+// SetHighlightBackground returns an escape sequence to set the background
+// color of selected text (OSC 17), so that a fullscreen program can match the
+// selection to its theme. Supported by xterm, foot and VTE; kitty and others
+// ignore it.
+func SetHighlightBackground(c color.Color) string {
+	return Osc + "17;" + xColor(c) + oscEnd()
+}
+
+// SetHighlightForeground returns an escape sequence to set the foreground
+// color of selected text (OSC 19). Terminals that do not support it keep the
+// colors of the text, or reverse them.
+func SetHighlightForeground(c color.Color) string {
+	return Osc + "19;" + xColor(c) + oscEnd()
+}
+
+// ResetHighlightBackground returns an escape sequence to restore the default
+// background color of selected text (OSC 117).
+func ResetHighlightBackground() string {
+	return Osc + "117" + oscEnd()
+}
+
+// ResetHighlightForeground returns an escape sequence to restore the default
+// foreground color of selected text (OSC 119).
+func ResetHighlightForeground() string {
+	return Osc + "119" + oscEnd()
+}
+
+// xColor formats c as rgb:RR/GG/BB, the format of XParseColor understood by
+// the color sequences of terminals.
+func xColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return "rgb:" + hex.EncodeToString([]byte{uint8(r >> 8)}) + "/" +
+		hex.EncodeToString([]byte{uint8(g >> 8)}) + "/" + hex.EncodeToString([]byte{uint8(b >> 8)})
+}
@@ -0,0 +1,106 @@
+package escapes
+
+import "math"
+
+// FromHSL returns the color with hue h in degrees, and saturation s and
+// lightness l between 0 and 1. The hue wraps around, and s and l are clamped.
+func FromHSL(h, s, l float64) Color {
+	s, l = clamp01(s), clamp01(l)
+	c := (1 - math.Abs(2*l-1)) * s
+	return hueToRGB(h, c, l-c/2)
+}
+
+// FromHSV returns the color with hue h in degrees, and saturation s and value
+// v between 0 and 1. The hue wraps around, and s and v are clamped.
+func FromHSV(h, s, v float64) Color {
+	s, v = clamp01(s), clamp01(v)
+	c := v * s
+	return hueToRGB(h, c, v-c)
+}
+
+// HSL returns the hue of c in degrees, from 0 to 360, and its saturation and
+// lightness, from 0 to 1. The hue of grays is 0. The default color is black.
+func (c Color) HSL() (h, s, l float64) {
+	h, max, min := c.hue()
+	l = (max + min) / 2
+	if max > min {
+		s = (max - min) / (1 - math.Abs(2*l-1))
+	}
+	return h, s, l
+}
+
+// HSV returns the hue of c in degrees, from 0 to 360, and its saturation and
+// value, from 0 to 1. The hue of grays is 0. The default color is black.
+func (c Color) HSV() (h, s, v float64) {
+	h, max, min := c.hue()
+	if max > 0 {
+		s = (max - min) / max
+	}
+	return h, s, max
+}
+
+// hue returns the hue of c in degrees, and its largest and smallest RGB
+// components, from 0 to 1.
+func (c Color) hue() (h, max, min float64) {
+	r8, g8, b8 := c.rgb()
+	r, g, b := float64(r8)/255, float64(g8)/255, float64(b8)/255
+	max, min = math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+
+	d := max - min
+	switch {
+	case d == 0:
+		return 0, max, min
+	case max == r:
+		h = math.Mod((g-b)/d, 6)
+	case max == g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, max, min
+}
+
+// hueToRGB returns the color with hue h, chroma c, and m added to every
+// component.
+func hueToRGB(h, c, m float64) Color {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	channel := func(v float64) uint8 {
+		return uint8(math.Round(clamp01(v+m) * 255))
+	}
+	return RGB(channel(r), channel(g), channel(b))
+}
+
+func clamp01(v float64) float64 {
+	switch {
+	case v < 0 || math.IsNaN(v):
+		return 0
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
@@ -0,0 +1,523 @@
+package escapes
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Event is an input event decoded by an InputDecoder, such as a KeyEvent or
+// a MouseEvent.
+type Event interface{}
+
+// UnknownEvent is an escape sequence the decoder does not recognize, such as a
+// reply to a query.
+type UnknownEvent string
+
+// Key identifies a key that does not produce a character, or KeyRune for
+// those that do.
+type Key int
+
+// Keys
+const (
+	KeyRune Key = iota
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyEscape
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyInsert
+	KeyDelete
+	KeyPageUp
+	KeyPageDown
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+)
+
+var keyNames = []string{
+	"rune", "enter", "tab", "backspace", "esc", "up", "down", "right", "left",
+	"home", "end", "insert", "delete", "pgup", "pgdown",
+	"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12",
+}
+
+func (k Key) String() string {
+	if k >= 0 && int(k) < len(keyNames) {
+		return keyNames[k]
+	}
+	return "unknown"
+}
+
+// Modifier is a set of modifier keys held during a key press.
+type Modifier int
+
+// Modifiers, combinable with the bitwise OR operator. Their values are those
+// used by xterm to encode modifiers, minus one.
+const (
+	ModShift Modifier = 1 << iota
+	ModAlt
+	ModCtrl
+	ModMeta
+)
+
+// KeyEvent is a key press. Keys producing characters have the Key KeyRune and
+// the character in Rune. Control characters are reported as the letter or
+// symbol typed with Ctrl, such as Ctrl+C for 0x03, except for those with
+// dedicated keys such as Enter and Tab.
+//
+// Key events are the same whichever encoding the terminal uses: legacy
+// sequences, xterm's modifyOtherKeys, or the kitty keyboard protocol (CSI u).
+// Characters typed with Shift are reported as the shifted character without
+// ModShift, as most terminals send nothing more.
+type KeyEvent struct {
+	Key    Key
+	Rune   rune
+	Mod    Modifier
+	Action KeyAction
+}
+
+// KeyAction is the kind of a key event. Terminals only report repeats and
+// releases with the kitty keyboard protocol, when requested.
+type KeyAction int
+
+// Key actions
+const (
+	KeyPress KeyAction = iota
+	KeyRepeat
+	KeyRelease
+)
+
+// String returns the name of the key combination, such as "ctrl+c",
+// "alt+enter" or "shift+f5".
+func (k KeyEvent) String() string {
+	var b strings.Builder
+	for _, m := range []struct {
+		mod  Modifier
+		name string
+	}{{ModCtrl, "ctrl+"}, {ModAlt, "alt+"}, {ModMeta, "meta+"}, {ModShift, "shift+"}} {
+		if k.Mod&m.mod != 0 {
+			b.WriteString(m.name)
+		}
+	}
+	switch {
+	case k.Key != KeyRune:
+		b.WriteString(k.Key.String())
+	case k.Rune == ' ':
+		b.WriteString("space")
+	default:
+		b.WriteRune(k.Rune)
+	}
+	return b.String()
+}
+
+// DefaultEscTimeout is how long InputDecoder waits for the rest of an escape
+// sequence by default. Sequences sent by terminals arrive at once, so this
+// only needs to cover slow connections.
+const DefaultEscTimeout = 50 * time.Millisecond
+
+// DefaultLookahead is the length beyond which InputDecoder gives up on an
+// incomplete escape sequence by default.
+const DefaultLookahead = 256
+
+// InputDecoder decodes the input from a terminal in raw mode into events.
+//
+// Pressing Esc sends the same byte that starts escape sequences, and holding
+// Alt while pressing a key sends that byte followed by the key. An ESC
+// followed by more input is decoded as a sequence or an Alt chord, and a
+// lone ESC as the Esc key once no more input arrives within EscTimeout.
+type InputDecoder struct {
+	// EscTimeout is how long to wait for the rest of an incomplete escape
+	// sequence (or UTF-8 character) before decoding the input received so
+	// far, where an ESC alone is the Esc key.
+	EscTimeout time.Duration
+
+	// Lookahead is the number of bytes an incomplete escape sequence may
+	// span before the decoder stops waiting for its end, and decodes its
+	// bytes as keys.
+	Lookahead int
+
+	// MousePixels reports whether MouseSGRPixelsEnable is set, so that the
+	// coordinates of mouse events are in pixels.
+	MousePixels bool
+
+	r       io.Reader
+	input   chan readResult
+	buf     []byte
+	err     error
+	pasting bool // Whether the end of a bracketed paste is pending
+}
+
+// NewInputDecoder returns a decoder reading from r with the default escape
+// timeout and lookahead.
+func NewInputDecoder(r io.Reader) *InputDecoder {
+	return &InputDecoder{EscTimeout: DefaultEscTimeout, Lookahead: DefaultLookahead, r: r}
+}
+
+// ReadEvent returns the next input event. The first call starts reading from
+// the underlying reader in a separate goroutine, which keeps reading until it
+// returns an error; the reader should not be used by anything else.
+func (d *InputDecoder) ReadEvent() (Event, error) {
+	if d.input == nil {
+		d.input = make(chan readResult, 1)
+		go d.read()
+	}
+
+	for {
+		if len(d.buf) > 0 {
+			if ev, n := d.decode(d.buf, d.err != nil); n > 0 {
+				d.buf = d.buf[n:]
+				return ev, nil
+			}
+		} else if d.err != nil {
+			return nil, d.err
+		}
+
+		// Wait for more input, but only until the timeout if some is pending
+		var (
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+		if len(d.buf) > 0 {
+			timer = time.NewTimer(d.EscTimeout)
+			timeout = timer.C
+		}
+		select {
+		case res := <-d.input:
+			if timer != nil {
+				timer.Stop()
+			}
+			d.buf = append(d.buf, res.p...)
+			d.err = res.err
+		case <-timeout:
+			ev, n := d.decode(d.buf, true)
+			d.buf = d.buf[n:]
+			return ev, nil
+		}
+	}
+}
+
+func (d *InputDecoder) read() {
+	for {
+		p := make([]byte, 256)
+		n, err := d.r.Read(p)
+		d.input <- readResult{p[:n], err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// decode decodes the event at the start of b, returning its length, or 0 if b
+// holds an incomplete event and final is false. If final is true, incomplete
+// sequences are decoded as they are.
+func (d *InputDecoder) decode(b []byte, final bool) (Event, int) {
+	if d.pasting {
+		return d.decodePaste(b, final)
+	}
+	if bytes.HasPrefix(b, []byte(BracketedPasteStart)) {
+		d.pasting = true
+		ev, n := d.decodePaste(b[len(BracketedPasteStart):], final)
+		if ev == nil {
+			d.pasting = false
+			return nil, 0
+		}
+		return ev, len(BracketedPasteStart) + n
+	}
+
+	c := b[0]
+	if c != AsciiEscape {
+		if c < 0x20 || c == AsciiDelete {
+			return controlKey(c), 1
+		}
+		if !final && !utf8.FullRune(b) {
+			return nil, 0
+		}
+		r, size := utf8.DecodeRune(b)
+		return KeyEvent{Key: KeyRune, Rune: r}, size
+	}
+
+	if len(b) == 1 {
+		if !final {
+			return nil, 0
+		}
+		return KeyEvent{Key: KeyEscape}, 1
+	}
+
+	s := string(b)
+	if s[1] == '[' || s[1] == 'O' {
+		if ev, n, ok := d.decodeSequence(s, final); ok {
+			return ev, n
+		}
+	}
+	if s[1] == ']' || s[1] == 'P' || s[1] == '_' || s[1] == '^' || s[1] == 'X' {
+		// Replies to queries, passed on as they are
+		n, complete := sequenceEnd(s)
+		if complete || final || len(s) >= d.Lookahead {
+			return UnknownEvent(s[:n]), n
+		}
+		return nil, 0
+	}
+
+	// An Alt chord: ESC followed by a key, possibly itself a sequence
+	ev, n := d.decode(b[1:], final)
+	if n == 0 {
+		return nil, 0
+	}
+	if k, ok := ev.(KeyEvent); ok {
+		k.Mod |= ModAlt
+		return k, n + 1
+	}
+	return KeyEvent{Key: KeyEscape}, 1
+}
+
+// decodeSequence decodes a CSI or SS3 sequence at the start of s. It returns
+// false if s does not start with one, but with an Alt chord using [ or O.
+func (d *InputDecoder) decodeSequence(s string, final bool) (Event, int, bool) {
+	if s[1] == 'O' {
+		// SS3 followed by a single character, with modifiers as digits in
+		// some terminals
+		i := 2
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == len(s) {
+			return nil, 0, !final
+		}
+		var mod Modifier
+		if i > 2 {
+			p, _ := strconv.Atoi(s[2:i])
+			mod = keyModifier(p)
+		}
+		if k, ok := ss3Keys[s[i]]; ok {
+			return KeyEvent{Key: k, Mod: mod}, i + 1, true
+		}
+		return UnknownEvent(s[:i+1]), i + 1, true
+	}
+
+	// The Linux console sends CSI [ A to CSI [ E for F1 to F5
+	if len(s) >= 3 && s[2] == '[' {
+		if len(s) < 4 {
+			return nil, 0, !final
+		}
+		if s[3] >= 'A' && s[3] <= 'E' {
+			return KeyEvent{Key: KeyF1 + Key(s[3]-'A')}, 4, true
+		}
+		return nil, 0, false
+	}
+
+	n, complete := sequenceEnd(s)
+	for i := 2; i < n-1 || (!complete && i < n); i++ {
+		if s[i] < 0x20 || s[i] > 0x3F {
+			// Not a valid sequence: only parameter and intermediate bytes
+			// may precede the final byte
+			return nil, 0, false
+		}
+	}
+	if !complete {
+		if final || len(s) >= d.Lookahead {
+			return nil, 0, false
+		}
+		return nil, 0, true
+	}
+	seq := s[:n]
+	if seq == Esc+"M" {
+		// Legacy mouse report: CSI M followed by the button and coordinates
+		// as bytes offset by 32
+		if len(s) < n+3 {
+			return nil, 0, !final
+		}
+		return mouseEvent(int(s[n])-32, int(s[n+1])-32, int(s[n+2])-32, false, false), n + 3, true
+	}
+	if strings.HasPrefix(seq, Esc+"<") && (seq[n-1] == 'M' || seq[n-1] == 'm') {
+		// SGR mouse report: CSI < button ; x ; y M, or m for releases
+		_, params, _ := parseCSI(seq)
+		if len(params) == 3 {
+			return mouseEvent(params[0], params[1], params[2], seq[n-1] == 'm', d.MousePixels), n, true
+		}
+	}
+	if pos, ok := cprSequence(seq); ok && (pos.Page > 0 || pos.Y > 0) {
+		return CursorPositionEvent(pos), n, true
+	}
+	if ev, ok := csiEvent(seq); ok {
+		return ev, n, true
+	}
+	return UnknownEvent(seq), n, true
+}
+
+// csiEvent decodes a complete CSI sequence.
+func csiEvent(seq string) (Event, bool) {
+	params, final, ok := keyParams(seq)
+	if !ok {
+		return nil, false
+	}
+	// The second parameter holds the modifiers, and with the kitty keyboard
+	// protocol, the event type as a sub-parameter
+	var (
+		mod    Modifier
+		action KeyAction
+	)
+	if len(params) > 1 {
+		mod = keyModifier(params[1][0])
+		if len(params[1]) > 1 && params[1][1] > 1 {
+			action = KeyAction(params[1][1] - 1)
+		}
+	}
+
+	switch final {
+	case '~':
+		if params[0][0] == 27 && len(params) > 2 {
+			// xterm modifyOtherKeys: CSI 27 ; modifiers ; code ~
+			return codeKey(params[2][0], 0, mod, action)
+		}
+		if k, ok := tildeKeys[params[0][0]]; ok {
+			return KeyEvent{Key: k, Mod: mod, Action: action}, true
+		}
+	case 'u':
+		// kitty keyboard protocol and fixterms: CSI code[:shifted] ;
+		// modifiers[:event] u
+		shifted := 0
+		if len(params[0]) > 1 {
+			shifted = params[0][1]
+		}
+		return codeKey(params[0][0], shifted, mod, action)
+	case 'Z':
+		return KeyEvent{Key: KeyTab, Mod: mod | ModShift, Action: action}, true
+	default:
+		if k, ok := ss3Keys[final]; ok {
+			return KeyEvent{Key: k, Mod: mod, Action: action}, true
+		}
+	}
+	return nil, false
+}
+
+// keyParams splits a CSI sequence sent for a key into its parameters, each
+// with its sub-parameters, and its final byte. Missing parameters are 1. It
+// returns false if the sequence has a private prefix or intermediate bytes.
+func keyParams(seq string) (params [][]int, final byte, ok bool) {
+	body := seq[len(Esc) : len(seq)-1]
+	if body != "" && (body[0] < '0' || body[0] > ';') {
+		return nil, 0, false
+	}
+	for _, p := range strings.Split(body, ";") {
+		var sub []int
+		for _, f := range strings.Split(p, ":") {
+			n := 1
+			if f != "" {
+				var err error
+				if n, err = strconv.Atoi(f); err != nil {
+					return nil, 0, false
+				}
+			}
+			sub = append(sub, n)
+		}
+		params = append(params, sub)
+	}
+	return params, seq[len(seq)-1], true
+}
+
+// keyModifier decodes modifiers encoded as 1 plus a bitmask, where the kitty
+// keyboard protocol adds Super (reported as Meta), Hyper, Meta, and the lock
+// keys, which are ignored.
+func keyModifier(p int) Modifier {
+	if p < 1 {
+		return 0
+	}
+	bits := p - 1
+	mod := Modifier(bits) & (ModShift | ModAlt | ModCtrl | ModMeta)
+	if bits&32 != 0 {
+		mod |= ModMeta
+	}
+	return mod
+}
+
+// codeKey returns the event for a key sent as its Unicode code point, with
+// the code point it produces with Shift if known.
+func codeKey(code, shifted int, mod Modifier, action KeyAction) (Event, bool) {
+	k := KeyEvent{Key: KeyRune, Mod: mod, Action: action}
+	switch code {
+	case AsciiCarriageReturn:
+		k.Key = KeyEnter
+	case AsciiHorizontalTab:
+		k.Key = KeyTab
+	case AsciiEscape:
+		k.Key = KeyEscape
+	case AsciiBackspace, AsciiDelete:
+		k.Key = KeyBackspace
+	default:
+		if code < 0x20 || (code >= 0xE000 && code <= 0xF8FF) || !utf8.ValidRune(rune(code)) {
+			// Other control characters, and functional keys the kitty
+			// protocol encodes in the private use area
+			return nil, false
+		}
+		k.Rune = rune(code)
+
+		// Report shifted characters as they are typed in other encodings,
+		// without Shift
+		if mod&ModShift != 0 {
+			switch {
+			case shifted > 0:
+				k.Rune = rune(shifted)
+			case unicode.IsLower(k.Rune):
+				k.Rune = unicode.ToUpper(k.Rune)
+			default:
+				return k, true
+			}
+			k.Mod &^= ModShift
+		}
+	}
+	return k, true
+}
+
+// Keys sent as SS3 or CSI followed by a letter
+var ss3Keys = map[byte]Key{
+	'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft,
+	'H': KeyHome, 'F': KeyEnd,
+	'P': KeyF1, 'Q': KeyF2, 'R': KeyF3, 'S': KeyF4,
+}
+
+// Keys sent as CSI followed by a number and ~
+var tildeKeys = map[int]Key{
+	1: KeyHome, 2: KeyInsert, 3: KeyDelete, 4: KeyEnd, 5: KeyPageUp, 6: KeyPageDown,
+	7: KeyHome, 8: KeyEnd,
+	11: KeyF1, 12: KeyF2, 13: KeyF3, 14: KeyF4, 15: KeyF5,
+	17: KeyF6, 18: KeyF7, 19: KeyF8, 20: KeyF9, 21: KeyF10, 23: KeyF11, 24: KeyF12,
+}
+
+// controlKey returns the key event for a control character.
+func controlKey(c byte) KeyEvent {
+	switch c {
+	case AsciiCarriageReturn, AsciiLineFeed:
+		return KeyEvent{Key: KeyEnter}
+	case AsciiHorizontalTab:
+		return KeyEvent{Key: KeyTab}
+	case AsciiBackspace, AsciiDelete:
+		return KeyEvent{Key: KeyBackspace}
+	case AsciiNull:
+		return KeyEvent{Key: KeyRune, Rune: ' ', Mod: ModCtrl}
+	case AsciiEscape:
+		return KeyEvent{Key: KeyEscape}
+	}
+	if c <= 0x1A {
+		return KeyEvent{Key: KeyRune, Rune: rune('a' + c - 1), Mod: ModCtrl}
+	}
+	// Ctrl with \ ] ^ and _
+	return KeyEvent{Key: KeyRune, Rune: rune(c + 0x40), Mod: ModCtrl}
+}
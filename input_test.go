@@ -0,0 +1,78 @@
+package escapes
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readEvents returns the events decoded from in.
+func readEvents(t *testing.T, in string) []Event {
+	t.Helper()
+	d := NewInputDecoder(strings.NewReader(in))
+	var events []Event
+	for {
+		ev, err := d.ReadEvent()
+		if err == io.EOF {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("ReadEvent(%q): %v", in, err)
+		}
+		events = append(events, ev)
+	}
+}
+
+func TestInputDecoder(t *testing.T) {
+	cell := CellSize()
+	tests := []struct {
+		in   string
+		want []Event
+	}{
+		{"a", []Event{KeyEvent{Key: KeyRune, Rune: 'a'}}},
+		{"é!", []Event{KeyEvent{Key: KeyRune, Rune: 'é'}, KeyEvent{Key: KeyRune, Rune: '!'}}},
+		{"\x03", []Event{KeyEvent{Key: KeyRune, Rune: 'c', Mod: ModCtrl}}},
+		{"\r", []Event{KeyEvent{Key: KeyEnter}}},
+		{"\x7f", []Event{KeyEvent{Key: KeyBackspace}}},
+		{"\x1b", []Event{KeyEvent{Key: KeyEscape}}},
+		{"\x1ba", []Event{KeyEvent{Key: KeyRune, Rune: 'a', Mod: ModAlt}}},
+		{"\x1b[A\x1b[B", []Event{KeyEvent{Key: KeyUp}, KeyEvent{Key: KeyDown}}},
+		{"\x1b[1;5C", []Event{KeyEvent{Key: KeyRight, Mod: ModCtrl}}},
+		{"\x1bOP", []Event{KeyEvent{Key: KeyF1}}},
+		{"\x1b[15~", []Event{KeyEvent{Key: KeyF5}}},
+		{"\x1b[3;2~", []Event{KeyEvent{Key: KeyDelete, Mod: ModShift}}},
+		// modifyOtherKeys and the kitty keyboard protocol
+		{"\x1b[27;5;13~", []Event{KeyEvent{Key: KeyEnter, Mod: ModCtrl}}},
+		{"\x1b[97;5u", []Event{KeyEvent{Key: KeyRune, Rune: 'a', Mod: ModCtrl}}},
+		{"\x1b[<0;10;5M\x1b[<0;10;5m", []Event{
+			MouseEvent{X: 9, Y: 4, PixelX: 9 * cell.Width, PixelY: 4 * cell.Height, Button: MouseLeft, Action: MousePress},
+			MouseEvent{X: 9, Y: 4, PixelX: 9 * cell.Width, PixelY: 4 * cell.Height, Button: MouseLeft, Action: MouseRelease},
+		}},
+		{"\x1b[<64;1;1M", []Event{MouseEvent{Button: MouseWheelUp, Action: MousePress}}},
+		{"\x1b[200~hi\x1b[A\x1b[201~x", []Event{PasteEvent("hi\x1b[A"), KeyEvent{Key: KeyRune, Rune: 'x'}}},
+		{"\x1b]11;rgb:0/0/0\a", []Event{UnknownEvent("\x1b]11;rgb:0/0/0\a")}},
+	}
+	for _, tt := range tests {
+		if got := readEvents(t, tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("events of %q = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestKeyEventString(t *testing.T) {
+	tests := []struct {
+		k    KeyEvent
+		want string
+	}{
+		{KeyEvent{Key: KeyRune, Rune: 'c', Mod: ModCtrl}, "ctrl+c"},
+		{KeyEvent{Key: KeyEnter, Mod: ModAlt}, "alt+enter"},
+		{KeyEvent{Key: KeyF5, Mod: ModShift | ModCtrl}, "ctrl+shift+f5"},
+		{KeyEvent{Key: KeyRune, Rune: ' '}, "space"},
+	}
+	for _, tt := range tests {
+		if got := tt.k.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.k, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package escapes
+
+// Conversions to the styles of the tcell (github.com/gdamore/tcell/v2) and
+// termbox (github.com/nsf/termbox-go) libraries. To avoid depending on them,
+// values are exchanged as the integers underlying their types, so that
+// converting a tcell style looks like:
+//
+//	fg, bg, attrs := st.Decompose()
+//	s := escapes.FromTcell(int64(fg), int64(bg), int(attrs))
+//
+// and back:
+//
+//	fg, bg, attrs := s.Tcell()
+//	st := tcell.StyleDefault.Foreground(tcell.Color(fg)).
+//		Background(tcell.Color(bg)).Attributes(tcell.AttrMask(attrs))
+
+// Bits of tcell.Color
+const (
+	tcellColorValid = 1 << 32
+	tcellColorIsRGB = 1 << 33
+)
+
+// Attributes in the order of the bits of tcell.AttrMask, starting with the
+// lowest
+var tcellAttrs = []Attr{AttrBold, AttrBlink, AttrReverse, AttrUnderline, AttrDim, AttrItalic, AttrStrikethrough}
+
+// Tcell returns s as the values of a tcell.Color foreground and background,
+// and tcell.AttrMask attributes. Attributes tcell does not support, such as
+// hidden text, are omitted, and rapid blinking becomes blinking.
+func (s Style) Tcell() (fg, bg int64, attrs int) {
+	if s.Attrs&AttrRapidBlink != 0 {
+		s.Attrs |= AttrBlink
+	}
+	for i, a := range tcellAttrs {
+		if s.Attrs&a != 0 {
+			attrs |= 1 << uint(i)
+		}
+	}
+	return tcellColor(s.Fg), tcellColor(s.Bg), attrs
+}
+
+// FromTcell returns the style with the given tcell.Color foreground and
+// background, and tcell.AttrMask attributes.
+func FromTcell(fg, bg int64, attrs int) Style {
+	s := Style{Fg: fromTcellColor(fg), Bg: fromTcellColor(bg)}
+	for i, a := range tcellAttrs {
+		if attrs&(1<<uint(i)) != 0 {
+			s.Attrs |= a
+		}
+	}
+	return s
+}
+
+func tcellColor(c Color) int64 {
+	switch c.kind {
+	case colorDefault:
+		return 0
+	case colorRGB:
+		return tcellColorValid | tcellColorIsRGB | int64(c.r)<<16 | int64(c.g)<<8 | int64(c.b)
+	default:
+		return tcellColorValid | int64(c.index)
+	}
+}
+
+func fromTcellColor(v int64) Color {
+	switch {
+	case v&tcellColorValid == 0:
+		return DefaultColor
+	case v&tcellColorIsRGB != 0:
+		return RGB(uint8(v>>16), uint8(v>>8), uint8(v))
+	case v&0xFFFFFF < 16:
+		return ANSIColor(int(v & 0xF))
+	case v&0xFFFFFF < 256:
+		return IndexedColor(int(v & 0xFF))
+	default:
+		return DefaultColor
+	}
+}
+
+// Attributes in the order of the bits of termbox.Attribute, starting with bit
+// 9, above the colors
+var termboxAttrs = []Attr{AttrBold, AttrBlink, AttrHidden, AttrDim, AttrUnderline, AttrItalic, AttrReverse}
+
+const termboxAttrShift = 9
+
+// Termbox returns s as the values of termbox.Attribute foreground and
+// background, for the 256-color output mode, where colors are palette indices
+// plus one. Other colors are converted to the closest palette color, and
+// attributes termbox does not support are omitted.
+func (s Style) Termbox() (fg, bg uint64) {
+	fg, bg = termboxColor(s.Fg), termboxColor(s.Bg)
+	if s.Attrs&AttrRapidBlink != 0 {
+		s.Attrs |= AttrBlink
+	}
+	for i, a := range termboxAttrs {
+		if s.Attrs&a != 0 {
+			fg |= 1 << uint(termboxAttrShift+i)
+		}
+	}
+	return fg, bg
+}
+
+// FromTermbox returns the style with the given termbox.Attribute foreground
+// and background, in the normal or 256-color output modes. Attributes may be
+// set on either.
+func FromTermbox(fg, bg uint64) Style {
+	s := Style{Fg: fromTermboxColor(fg), Bg: fromTermboxColor(bg)}
+	for i, a := range termboxAttrs {
+		if (fg|bg)&(1<<uint(termboxAttrShift+i)) != 0 {
+			s.Attrs |= a
+		}
+	}
+	return s
+}
+
+func termboxColor(c Color) uint64 {
+	if c.IsDefault() {
+		return 0
+	}
+	if c.kind == colorRGB {
+		c = c.Convert(ProfileANSI256)
+	}
+	return uint64(c.index) + 1
+}
+
+func fromTermboxColor(v uint64) Color {
+	i := int(v&(1<<termboxAttrShift-1)) - 1
+	switch {
+	case i < 0:
+		return DefaultColor
+	case i < 16:
+		return ANSIColor(i)
+	case i < 256:
+		return IndexedColor(i)
+	default:
+		return DefaultColor
+	}
+}
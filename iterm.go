@@ -0,0 +1,61 @@
+package escapes
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+// FileChunkSize is the size of the data above which DownloadFile splits a file
+// into several sequences, to stay within the limits of terminals, multiplexers
+// and SSH sessions on the length of a single sequence. It should be a multiple
+// of 3, so that every chunk but the last is encoded without padding.
+var FileChunkSize = 3 * 1024 * 256
+
+// DownloadFile returns escape sequences to transfer a file to the user's
+// machine through the terminal, which works over SSH. If inline is true, the
+// file is displayed instead, if it is an image. Files larger than FileChunkSize
+// are sent in several parts. Supported by iTerm2 and WezTerm; files larger
+// than FileChunkSize require iTerm2 3.5 or later.
+func DownloadFile(name string, data []byte, inline bool) string {
+	args := "name=" + base64.StdEncoding.EncodeToString([]byte(name)) +
+		";size=" + strconv.Itoa(len(data))
+	if inline {
+		args += ";inline=1"
+	} else {
+		args += ";inline=0"
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	if FileChunkSize <= 0 || len(data) <= FileChunkSize {
+		b.WriteString(Osc + "1337;File=" + args + ":")
+		writeBase64(b, data)
+		b.WriteString(oscEnd())
+		return b.String()
+	}
+
+	b.WriteString(Osc + "1337;MultipartFile=" + args + oscEnd())
+	for len(data) > 0 {
+		n := FileChunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		b.WriteString(Osc + "1337;FilePart=")
+		writeBase64(b, data[:n])
+		b.WriteString(oscEnd())
+		data = data[n:]
+	}
+	b.WriteString(Osc + "1337;FileEnd" + oscEnd())
+	return b.String()
+}
+
+// SetWeztermUserVar returns an escape sequence to set a user variable of the
+// current pane, which WezTerm exposes to its configuration for status bars and
+// pane automation. The sequence is wrapped with TmuxPassthrough inside tmux.
+func SetWeztermUserVar(name, value string) string {
+	seq := Osc + "1337;SetUserVar=" + name + "=" + base64.StdEncoding.EncodeToString([]byte(value)) + oscEnd()
+	if InTmux() {
+		return TmuxPassthrough(seq)
+	}
+	return seq
+}
@@ -0,0 +1,124 @@
+package escapes
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ErrNotSupported is returned when the terminal replied to a query in a way
+// showing that it does not support the feature queried.
+var ErrNotSupported = errors.New("escapes: the terminal does not support the feature")
+
+// KeyboardFlags are the enhancements of the kitty keyboard protocol, which
+// make terminals report keys unambiguously as CSI u sequences.
+type KeyboardFlags int
+
+// Keyboard enhancements, combinable with the bitwise OR operator
+const (
+	// KeyboardDisambiguate reports keys that are otherwise ambiguous, such as
+	// Esc, Alt chords and Ctrl with punctuation, as CSI u sequences.
+	KeyboardDisambiguate KeyboardFlags = 1 << iota
+
+	// KeyboardReportEvents reports repeats and releases of keys.
+	KeyboardReportEvents
+
+	// KeyboardReportAlternates reports the shifted character of keys.
+	KeyboardReportAlternates
+
+	// KeyboardReportAllKeys reports all keys as sequences, including Enter,
+	// Tab, Backspace and those typing text.
+	KeyboardReportAllKeys
+
+	// KeyboardReportText reports the text typed by keys along with them.
+	KeyboardReportText
+)
+
+// RequestKeyboardFlags requests the keyboard enhancements in effect, replied
+// to as CSI ? flags u by terminals supporting the kitty keyboard protocol.
+const RequestKeyboardFlags = Esc + "?u"
+
+// PushKeyboardFlags returns an escape sequence to enable keyboard
+// enhancements, saving the current ones on a stack.
+func PushKeyboardFlags(flags KeyboardFlags) string {
+	return Esc + ">" + strconv.Itoa(int(flags)) + "u"
+}
+
+// PopKeyboardFlags returns an escape sequence to restore the keyboard
+// enhancements saved by the last n calls to PushKeyboardFlags.
+func PopKeyboardFlags(n int) string {
+	return Esc + "<" + strconv.Itoa(n) + "u"
+}
+
+// ParseKeyboardFlags parses the reply to RequestKeyboardFlags.
+func ParseKeyboardFlags(reply []byte) (KeyboardFlags, error) {
+	flags, ok := findKeyboardFlags(reply)
+	if !ok {
+		return 0, ErrInvalidReply
+	}
+	return flags, nil
+}
+
+// QueryKeyboardFlags queries the keyboard enhancements in effect, to know
+// whether key releases or disambiguated keys will be reported before relying
+// on them. It returns ErrNotSupported if the terminal does not support the
+// kitty keyboard protocol. See Query for the requirements on w and r.
+func QueryKeyboardFlags(w io.Writer, r io.Reader, timeout time.Duration) (KeyboardFlags, error) {
+	// Primary device attributes follow, answered by every terminal, so that
+	// the lack of support is known without waiting for the timeout
+	reply, err := Query(w, r, RequestKeyboardFlags+Esc+"c", findDeviceAttributes, timeout)
+	if err != nil {
+		return 0, err
+	}
+	if flags, ok := findKeyboardFlags(reply); ok {
+		return flags, nil
+	}
+	return 0, ErrNotSupported
+}
+
+// findKeyboardFlags finds a reply of the form CSI ? flags u.
+func findKeyboardFlags(reply []byte) (KeyboardFlags, bool) {
+	s := string(reply)
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+
+		if len(seq) < 3 || seq[:2] != Esc {
+			continue
+		}
+		prefix, params, final := parseCSI(seq)
+		if prefix == "?" && final == "u" && len(params) == 1 {
+			return KeyboardFlags(params[0]), true
+		}
+	}
+	return 0, false
+}
+
+// findDeviceAttributes reports whether reply contains the reply to a primary
+// device attributes request, CSI ? params c.
+func findDeviceAttributes(reply []byte) bool {
+	s := string(reply)
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+
+		if len(seq) < 3 || seq[:2] != Esc {
+			continue
+		}
+		if prefix, _, final := parseCSI(seq); prefix == "?" && final == "c" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package escapes
+
+import "strings"
+
+// KeyValue is a single entry of a key/value listing or definition list.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// KeyValueFormat configures how key/value pairs and definition lists are laid
+// out. The zero value is usable.
+type KeyValueFormat struct {
+	// KeyStyle is an escape sequence applied to every key, such as
+	// TextColorCyan. Keys are followed by ColorReset when it is set.
+	KeyStyle string
+
+	// Separator is placed between the key column and the value. Defaults to
+	// ": " for key/value listings.
+	Separator string
+
+	// AlignRight right-aligns keys within the key column.
+	AlignRight bool
+
+	// Width is the total width in columns available for each line. Values that
+	// do not fit are wrapped with a hanging indent. No wrapping is done if
+	// Width is 0.
+	Width int
+
+	// Indent is the number of columns definitions are indented by in
+	// definition lists. Defaults to 4.
+	Indent int
+}
+
+// FormatKeyValues renders key/value pairs in two aligned columns, one pair per
+// line. Widths are measured ignoring escape sequences, so keys and values may
+// already be styled.
+func FormatKeyValues(pairs []KeyValue, f KeyValueFormat) string {
+	sep := f.Separator
+	if sep == "" {
+		sep = ": "
+	}
+
+	var keyWidth int
+	for _, p := range pairs {
+		if w := StringWidth(p.Key); w > keyWidth {
+			keyWidth = w
+		}
+	}
+	indent := keyWidth + StringWidth(sep)
+	hanging := strings.Repeat(" ", indent)
+
+	var b strings.Builder
+	for _, p := range pairs {
+		pad := strings.Repeat(" ", keyWidth-StringWidth(p.Key))
+		if f.AlignRight {
+			b.WriteString(pad)
+		}
+		b.WriteString(f.styleKey(p.Key))
+		if !f.AlignRight {
+			b.WriteString(pad)
+		}
+		b.WriteString(sep)
+
+		for i, line := range f.valueLines(p.Value, indent) {
+			if i > 0 {
+				b.WriteString(hanging)
+			}
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// FormatDefinitions renders a definition list, where each key is printed on
+// its own line and followed by its value, indented by f.Indent columns.
+func FormatDefinitions(pairs []KeyValue, f KeyValueFormat) string {
+	indent := f.Indent
+	if indent <= 0 {
+		indent = 4
+	}
+	prefix := strings.Repeat(" ", indent)
+
+	var b strings.Builder
+	for _, p := range pairs {
+		b.WriteString(f.styleKey(p.Key))
+		b.WriteByte('\n')
+		for _, line := range f.valueLines(p.Value, indent) {
+			b.WriteString(prefix)
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func (f KeyValueFormat) styleKey(key string) string {
+	if f.KeyStyle == "" {
+		return key
+	}
+	return f.KeyStyle + key + ColorReset
+}
+
+// valueLines splits a value into lines, wrapping it to the space left after
+// indent columns if a width is set.
+func (f KeyValueFormat) valueLines(value string, indent int) []string {
+	if f.Width <= 0 {
+		return strings.Split(value, "\n")
+	}
+	return wrapLines(value, f.Width-indent)
+}
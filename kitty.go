@@ -0,0 +1,217 @@
+package escapes
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Commands of the kitty graphics protocol are sent as APC G control ; payload
+// ST, where the control data is a list of key=value pairs. All commands here
+// set q=2, so that the terminal does not reply to them.
+const (
+	kittyPrefix = Apc + "G"
+	kittySuffix = St
+
+	// kittyChunkSize is the maximum size of the encoded payload of a command.
+	kittyChunkSize = 4096
+)
+
+// KittyImage returns an escape sequence to display a PNG image at the cursor
+// position with the kitty graphics protocol. Supported by kitty, WezTerm,
+// Konsole and Ghostty.
+func KittyImage(png []byte) string {
+	return kittyCommand("a=T,f=100", png)
+}
+
+// KittyTransmit returns an escape sequence to transmit a PNG image with the
+// given id, greater than 0, without displaying it. The image becomes the first
+// frame of an animation if frames are added with KittyAddFrame.
+func KittyTransmit(id int, png []byte) string {
+	return kittyCommand("a=t,f=100,i="+strconv.Itoa(id), png)
+}
+
+var kittyNextID uint32
+
+// NewKittyID returns a new id for an image or a placement, unique within the
+// program. Ids are not shared with other programs using the same terminal.
+func NewKittyID() int {
+	return int(atomic.AddUint32(&kittyNextID, 1) & 0x7FFFFFFF)
+}
+
+// KittyPlacement describes how KittyPlace displays a transmitted image.
+type KittyPlacement struct {
+	// ID identifies the placement among those of the same image, so that it
+	// can be moved or deleted. Placing an image again with the same id moves
+	// the existing placement instead of adding another one. 0 assigns no id.
+	ID int
+
+	// Cols and Rows are the size of the area the image is scaled to, in
+	// cells. If either is 0, it is computed from the image's size.
+	Cols, Rows int
+
+	// Z orders the image relative to text and other images. Images with a
+	// negative Z are drawn below text, and those below -1073741824 below
+	// cells with a non-default background as well.
+	Z int
+
+	// KeepCursor leaves the cursor in place rather than moving it after the
+	// image.
+	KeepCursor bool
+
+	// Virtual creates a placement that is not drawn by itself, but in the
+	// cells of Unicode placeholders, such as those written by
+	// Screen.SetKittyImage, which then move and scroll like text. Cols and
+	// Rows should be set, and an image has at most one virtual placement.
+	Virtual bool
+}
+
+// KittyPlace returns an escape sequence to display the image with the given
+// id, transmitted with KittyTransmit, at the cursor position.
+func KittyPlace(id int, p KittyPlacement) string {
+	control := "a=p,i=" + strconv.Itoa(id)
+	if p.ID > 0 {
+		control += ",p=" + strconv.Itoa(p.ID)
+	}
+	if p.Cols > 0 {
+		control += ",c=" + strconv.Itoa(p.Cols)
+	}
+	if p.Rows > 0 {
+		control += ",r=" + strconv.Itoa(p.Rows)
+	}
+	if p.Z != 0 {
+		control += ",z=" + strconv.Itoa(p.Z)
+	}
+	if p.KeepCursor {
+		control += ",C=1"
+	}
+	if p.Virtual {
+		control += ",U=1"
+	}
+	return kittyCommand(control, nil)
+}
+
+// KittyPlaceAt is like KittyPlace, but displays the image at a coordinate pair,
+// where (0, 0) is the origin, and leaves the cursor where it was.
+func KittyPlaceAt(x, y, id int, p KittyPlacement) string {
+	p.KeepCursor = true
+	return CursorSave + CursorPos(x, y) + KittyPlace(id, p) + CursorRestore
+}
+
+// KittyDeletePlacement returns an escape sequence to delete a placement of the
+// image with the given id, keeping the image so that it can be placed again.
+// A placement id of 0 deletes all placements of the image.
+func KittyDeletePlacement(id, placement int) string {
+	control := "a=d,d=i,i=" + strconv.Itoa(id)
+	if placement > 0 {
+		control += ",p=" + strconv.Itoa(placement)
+	}
+	return kittyCommand(control, nil)
+}
+
+// KittyDeleteImage returns an escape sequence to delete all placements of the
+// image with the given id, and free its data.
+func KittyDeleteImage(id int) string {
+	return kittyCommand("a=d,d=I,i="+strconv.Itoa(id), nil)
+}
+
+// KittyDeleteAll returns an escape sequence to delete all images displayed on
+// the screen, and free the data of those not displayed elsewhere.
+func KittyDeleteAll() string {
+	return kittyCommand("a=d,d=A", nil)
+}
+
+// KittyAnimationState is the state of an animation played by the terminal.
+type KittyAnimationState int
+
+// Animation states for KittyAnimate
+const (
+	// KittyAnimationStop stops the animation at the current frame.
+	KittyAnimationStop KittyAnimationState = 1
+
+	// KittyAnimationLoading plays the animation, waiting at the last frame
+	// for more frames to be added.
+	KittyAnimationLoading KittyAnimationState = 2
+
+	// KittyAnimationRun plays the animation, looping after the last frame.
+	KittyAnimationRun KittyAnimationState = 3
+)
+
+// KittyAddFrame returns an escape sequence to add a PNG image as a frame of
+// the animation of the image with the given id. The frame is shown for gap
+// before the next one; a gap of 0 uses the terminal's default of 40ms.
+func KittyAddFrame(id int, png []byte, gap time.Duration) string {
+	control := "a=f,f=100,i=" + strconv.Itoa(id)
+	if gap > 0 {
+		control += ",z=" + kittyMillis(gap)
+	}
+	return kittyCommand(control, png)
+}
+
+// KittyComposeFrame returns an escape sequence to copy the whole of frame src
+// onto frame dst of the animation of the image with the given id, blending it
+// with the frame's contents. Frames are numbered from 1.
+func KittyComposeFrame(id, src, dst int) string {
+	return kittyCommand("a=c,i="+strconv.Itoa(id)+",r="+strconv.Itoa(src)+",c="+strconv.Itoa(dst), nil)
+}
+
+// KittyAnimate returns an escape sequence to change the state of the animation
+// of the image with the given id. The animation plays loops times, or forever
+// if loops is 0.
+func KittyAnimate(id int, state KittyAnimationState, loops int) string {
+	if loops < 0 {
+		loops = 0
+	}
+	return kittyCommand("a=a,i="+strconv.Itoa(id)+",s="+strconv.Itoa(int(state))+",v="+strconv.Itoa(loops+1), nil)
+}
+
+// KittySetFrame returns an escape sequence to show a frame of the animation of
+// the image with the given id. Frames are numbered from 1.
+func KittySetFrame(id, frame int) string {
+	return kittyCommand("a=a,i="+strconv.Itoa(id)+",c="+strconv.Itoa(frame), nil)
+}
+
+// KittyFrameGap returns an escape sequence to change how long a frame of the
+// animation of the image with the given id is shown. Frames are numbered from 1.
+func KittyFrameGap(id, frame int, gap time.Duration) string {
+	return kittyCommand("a=a,i="+strconv.Itoa(id)+",r="+strconv.Itoa(frame)+",z="+kittyMillis(gap), nil)
+}
+
+// kittyCommand returns a graphics command with the given control data and
+// payload, split into several commands if the payload is too large.
+func kittyCommand(control string, payload []byte) string {
+	enc := getBuffer()
+	defer putBuffer(enc)
+	writeBase64(enc, payload)
+	data := enc.Bytes()
+	if len(data) <= kittyChunkSize {
+		if len(data) == 0 {
+			return kittyPrefix + control + ",q=2" + kittySuffix
+		}
+		return kittyPrefix + control + ",q=2;" + string(data) + kittySuffix
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	for i := 0; i < len(data); i += kittyChunkSize {
+		end := i + kittyChunkSize
+		more := "1"
+		if end >= len(data) {
+			end, more = len(data), "0"
+		}
+
+		// Only the first chunk carries the control data
+		b.WriteString(kittyPrefix)
+		if i == 0 {
+			b.WriteString(control + ",q=2,")
+		}
+		b.WriteString("m=" + more + ";")
+		b.Write(data[i:end])
+		b.WriteString(kittySuffix)
+	}
+	return b.String()
+}
+
+func kittyMillis(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Millisecond), 10)
+}
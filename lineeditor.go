@@ -0,0 +1,306 @@
+package escapes
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned when the user interrupts an interactive
+// component with Ctrl+C.
+var ErrInterrupted = errors.New("escapes: interrupted")
+
+// LineEditor reads lines of input interactively, with the usual editing keys
+// of shells:
+//
+//	Left, Right, Ctrl+B, Ctrl+F          move by character
+//	Ctrl+Left, Ctrl+Right, Alt+B, Alt+F  move by word
+//	Home, End, Ctrl+A, Ctrl+E            move to the start or end of the line
+//	Backspace, Delete, Ctrl+D            delete a character
+//	Ctrl+W, Alt+Backspace, Alt+D         delete a word
+//	Ctrl+U, Ctrl+K                       delete to the start or end of the line
+//	Up, Down, Ctrl+P, Ctrl+N             browse the history
+//	Ctrl+L                               clear the screen
+//
+// The line is redrawn in place after each key, scrolling horizontally when
+// it does not fit in Width. The terminal must be in raw mode.
+//
+// Text pasted while bracketed paste is enabled, such as with BracketedPaste,
+// is inserted at once, including its line breaks, rather than typed, so that
+// a pasted line break does not end the line.
+type LineEditor struct {
+	// Prompt is written before the line, on the same row. It may contain
+	// escape sequences.
+	Prompt string
+
+	// Width is the width of the terminal, or 0 if unknown, in which case
+	// lines are not scrolled.
+	Width int
+
+	// History are the previous lines, from the oldest. ReadLine appends the
+	// lines it reads, except empty ones and repetitions of the last one.
+	History []string
+
+	// BracketedPaste makes ReadLine enable bracketed paste while it reads a
+	// line. It disables it again when it returns, so leave this unset if
+	// bracketed paste is enabled otherwise, such as by a Session.
+	BracketedPaste bool
+
+	// PasteFilter returns the text to insert for pasted text, such as to
+	// review it or to remove characters, or "" to insert nothing. The default
+	// is SanitizePaste, which removes escape sequences and control
+	// characters other than line breaks and tabs.
+	PasteFilter func(text string) string
+
+	w io.Writer
+	d *InputDecoder
+
+	line    []rune
+	pos     int
+	history int    // Index in History of the line shown, or len(History)
+	edited  string // Line being edited before browsing the history
+}
+
+// NewLineEditor returns a line editor writing to w, and reading input from d.
+func NewLineEditor(w io.Writer, d *InputDecoder) *LineEditor {
+	return &LineEditor{w: w, d: d}
+}
+
+// ReadLine reads a line, and returns it once Enter is pressed. It returns
+// ErrInterrupted if Ctrl+C is pressed, and io.EOF if Ctrl+D is pressed on an
+// empty line.
+func (e *LineEditor) ReadLine() (line string, err error) {
+	e.line, e.pos = nil, 0
+	e.history, e.edited = len(e.History), ""
+	if e.BracketedPaste {
+		if _, err := io.WriteString(e.w, BracketedPasteEnable); err != nil {
+			return "", err
+		}
+		defer func() {
+			if _, werr := io.WriteString(e.w, BracketedPasteDisable); err == nil {
+				err = werr
+			}
+		}()
+	}
+	if err := e.redraw(); err != nil {
+		return "", err
+	}
+
+	for {
+		ev, err := e.d.ReadEvent()
+		if err != nil {
+			return "", err
+		}
+		if p, ok := ev.(PasteEvent); ok {
+			e.insert(e.filterPaste(string(p)))
+			if err := e.redraw(); err != nil {
+				return "", err
+			}
+			continue
+		}
+		k, ok := ev.(KeyEvent)
+		if !ok || k.Action == KeyRelease {
+			continue
+		}
+
+		switch k.String() {
+		case "enter":
+			line := string(e.line)
+			if line != "" && (len(e.History) == 0 || e.History[len(e.History)-1] != line) {
+				e.History = append(e.History, line)
+			}
+			_, err := io.WriteString(e.w, "\r\n")
+			return line, err
+		case "ctrl+c":
+			_, err := io.WriteString(e.w, "\r\n")
+			if err == nil {
+				err = ErrInterrupted
+			}
+			return "", err
+		case "ctrl+d":
+			if len(e.line) == 0 {
+				_, err := io.WriteString(e.w, "\r\n")
+				if err == nil {
+					err = io.EOF
+				}
+				return "", err
+			}
+			e.delete(e.pos, e.pos+1)
+		case "delete":
+			e.delete(e.pos, e.pos+1)
+		case "backspace", "ctrl+h":
+			e.delete(e.pos-1, e.pos)
+		case "left", "ctrl+b":
+			e.move(e.pos - 1)
+		case "right", "ctrl+f":
+			e.move(e.pos + 1)
+		case "ctrl+left", "alt+left", "alt+b":
+			e.move(e.wordStart())
+		case "ctrl+right", "alt+right", "alt+f":
+			e.move(e.wordEnd())
+		case "home", "ctrl+a":
+			e.move(0)
+		case "end", "ctrl+e":
+			e.move(len(e.line))
+		case "ctrl+w", "alt+backspace", "ctrl+backspace":
+			e.delete(e.wordStart(), e.pos)
+		case "alt+d", "ctrl+delete":
+			e.delete(e.pos, e.wordEnd())
+		case "ctrl+u":
+			e.delete(0, e.pos)
+		case "ctrl+k":
+			e.delete(e.pos, len(e.line))
+		case "up", "ctrl+p":
+			e.browse(e.history - 1)
+		case "down", "ctrl+n":
+			e.browse(e.history + 1)
+		case "ctrl+l":
+			if _, err := io.WriteString(e.w, EraseScreen+CursorTopLeft); err != nil {
+				return "", err
+			}
+		default:
+			if k.Key != KeyRune || k.Mod&^ModShift != 0 || !unicode.IsPrint(k.Rune) {
+				continue
+			}
+			e.insert(string(k.Rune))
+		}
+		if err := e.redraw(); err != nil {
+			return "", err
+		}
+	}
+}
+
+// insert inserts text at the cursor.
+func (e *LineEditor) insert(text string) {
+	r := []rune(text)
+	e.line = append(e.line[:e.pos], append(r, e.line[e.pos:]...)...)
+	e.pos += len(r)
+}
+
+// filterPaste returns the text to insert for pasted text.
+func (e *LineEditor) filterPaste(text string) string {
+	if e.PasteFilter != nil {
+		return e.PasteFilter(text)
+	}
+	return string(SanitizePaste([]byte(text)))
+}
+
+// delete deletes the characters in [from, to), clamped to the line.
+func (e *LineEditor) delete(from, to int) {
+	from, to = clampInt(from, 0, len(e.line)), clampInt(to, 0, len(e.line))
+	if from >= to {
+		return
+	}
+	e.line = append(e.line[:from], e.line[to:]...)
+	e.pos = from
+}
+
+func (e *LineEditor) move(pos int) {
+	e.pos = clampInt(pos, 0, len(e.line))
+}
+
+// wordStart returns the start of the word before the cursor.
+func (e *LineEditor) wordStart() int {
+	i := e.pos
+	for i > 0 && !isWordRune(e.line[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(e.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd returns the end of the word after the cursor.
+func (e *LineEditor) wordEnd() int {
+	i := e.pos
+	for i < len(e.line) && !isWordRune(e.line[i]) {
+		i++
+	}
+	for i < len(e.line) && isWordRune(e.line[i]) {
+		i++
+	}
+	return i
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// browse shows the line at index i of the history, where len(History) is the
+// line being edited.
+func (e *LineEditor) browse(i int) {
+	if i < 0 || i > len(e.History) || i == e.history {
+		return
+	}
+	if e.history == len(e.History) {
+		e.edited = string(e.line)
+	}
+	e.history = i
+	if i == len(e.History) {
+		e.line = []rune(e.edited)
+	} else {
+		e.line = []rune(e.History[i])
+	}
+	e.pos = len(e.line)
+}
+
+// redraw writes the prompt and line over the current row, with the cursor at
+// its position in the line. Lines wider than the terminal are scrolled to
+// keep the cursor visible.
+func (e *LineEditor) redraw() error {
+	promptWidth := StringWidth(e.Prompt)
+	start, end := 0, len(e.line)
+	if avail := e.Width - promptWidth - 1; e.Width > 0 && avail > 0 && e.lineWidth(0, end) > avail {
+		// Show the part of the line ending at the cursor or after it, as far
+		// as possible
+		start = e.pos
+		for start > 0 && e.lineWidth(start-1, e.pos) <= avail {
+			start--
+		}
+		end = e.pos
+		for end < len(e.line) && e.lineWidth(start, end+1) <= avail {
+			end++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\r" + e.Prompt)
+	b.WriteString(e.display(start, end))
+	b.WriteString(EraseRight)
+	b.WriteString(CursorPosX(promptWidth + e.lineWidth(start, e.pos)))
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+// display returns the characters of the line in [from, to) as displayed, with
+// tabs as spaces and other control characters in caret notation.
+func (e *LineEditor) display(from, to int) string {
+	var b strings.Builder
+	for _, r := range e.line[from:to] {
+		switch {
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 0x20:
+			b.WriteString("^" + string(r+0x40))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (e *LineEditor) lineWidth(from, to int) int {
+	return StringWidth(e.display(from, to))
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,170 @@
+package escapes
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LinkSpan is a hyperlink found in a string by ExtractLinks.
+type LinkSpan struct {
+	URL string
+	ID  string // Value of the id parameter, if any
+
+	// Start and End are the byte offsets in the string of the linked text,
+	// which may contain other escape sequences.
+	Start, End int
+
+	// Text is the linked text, with escape sequences removed.
+	Text string
+}
+
+// ExtractLinks returns the hyperlinks (OSC 8) in s, in order. A link ends with
+// the next hyperlink sequence, or at the end of s if it is never closed.
+// Links with no text are omitted.
+func ExtractLinks(s string) []LinkSpan {
+	var (
+		links []LinkSpan
+		open  *LinkSpan
+	)
+	closeLink := func(end int) {
+		if open != nil && end > open.Start {
+			open.End = end
+			open.Text = Strip(s[open.Start:end])
+			links = append(links, *open)
+		}
+		open = nil
+	}
+
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+
+		params, url, ok := parseLinkSequence(seq)
+		if !ok {
+			continue
+		}
+		closeLink(i - n)
+		if url != "" {
+			open = &LinkSpan{URL: url, ID: linkParam(params, "id"), Start: i}
+		}
+	}
+	closeLink(len(s))
+	return links
+}
+
+// parseLinkSequence returns the parameters and URL of a hyperlink sequence,
+// OSC 8 ; params ; URL ST. The URL is empty for the sequence closing a link.
+func parseLinkSequence(seq string) (params, url string, ok bool) {
+	const prefix = Osc + "8;"
+	if !strings.HasPrefix(seq, prefix) {
+		return "", "", false
+	}
+	body := strings.TrimPrefix(seq, prefix)
+	if strings.HasSuffix(body, Bel) {
+		body = strings.TrimSuffix(body, Bel)
+	} else {
+		body = strings.TrimSuffix(body, St)
+	}
+
+	sep := strings.IndexByte(body, ';')
+	if sep < 0 {
+		return "", "", false
+	}
+	return body[:sep], body[sep+1:], true
+}
+
+// linkParam returns the value of a hyperlink parameter, given the parameters
+// as key=value pairs separated by colons.
+func linkParam(params, key string) string {
+	for _, p := range strings.Split(params, ":") {
+		if strings.HasPrefix(p, key+"=") {
+			return p[len(key)+1:]
+		}
+	}
+	return ""
+}
+
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?|ftp|file)://[^\s<>"\x00-\x1f\x7f]+`)
+
+// Linkify returns s with the URLs in its text wrapped in hyperlinks, leaving
+// text that is already linked unchanged. Trailing punctuation, and closing
+// parentheses without a matching opening one, are not considered part of a
+// URL.
+func Linkify(s string) string {
+	var (
+		b      strings.Builder
+		linked bool
+		start  int
+	)
+	flush := func(end int) {
+		if linked {
+			b.WriteString(s[start:end])
+		} else {
+			b.WriteString(linkifyText(s[start:end]))
+		}
+	}
+
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		flush(i)
+		b.WriteString(s[i : i+n])
+		if _, url, ok := parseLinkSequence(s[i : i+n]); ok {
+			linked = url != ""
+		}
+		i += n
+		start = i
+	}
+	flush(len(s))
+	return b.String()
+}
+
+// linkifyText wraps the URLs in text, which contains no escape sequences.
+func linkifyText(text string) string {
+	matches := urlPattern.FindAllStringIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		url := trimURL(text[m[0]:m[1]])
+		b.WriteString(text[last:m[0]])
+		b.WriteString(Link(url, url))
+		last = m[0] + len(url)
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// trimURL removes the trailing characters of a URL that are more likely to be
+// punctuation of the surrounding text.
+func trimURL(url string) string {
+	for len(url) > 0 {
+		switch c := url[len(url)-1]; c {
+		case '.', ',', ':', ';', '!', '?', '\'', '*':
+			url = url[:len(url)-1]
+			continue
+		case ')', ']':
+			open := byte('(')
+			if c == ']' {
+				open = '['
+			}
+			if strings.Count(url, string(c)) > strings.Count(url, string(open)) {
+				url = url[:len(url)-1]
+				continue
+			}
+		}
+		return url
+	}
+	return url
+}
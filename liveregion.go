@@ -0,0 +1,170 @@
+package escapes
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LiveRegion shows a block of lines that is redrawn in place, such as
+// progress bars and spinners, below output that keeps scrolling as usual,
+// such as log lines. Output written to the region is inserted above the
+// block, which is then drawn again below it.
+//
+// Output is written a line at a time, so that an incomplete line is not
+// overwritten by the block; the rest is held until its newline is written, or
+// the region is closed. Writes and updates are serialized, so a LiveRegion may
+// be used from several goroutines, such as with a log.Logger. If interactive
+// sequences are disabled, output is written as it is, and the block is only
+// written when the region is closed.
+type LiveRegion struct {
+	mu      sync.Mutex
+	w       io.Writer
+	block   liveBlock
+	pending []byte // Incomplete line of output
+	err     error
+}
+
+// NewLiveRegion returns a live region writing to w, initially empty. The
+// cursor is expected to be at the start of a line.
+func NewLiveRegion(w io.Writer) *LiveRegion {
+	return &LiveRegion{w: w, block: liveBlock{w: w}}
+}
+
+// Set replaces the lines of the block. Lines should fit on a row.
+func (l *LiveRegion) Set(lines ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !Interactive() {
+		l.block.lines = lines
+		return l.err
+	}
+	return l.write(l.block.update(lines...))
+}
+
+// Write writes output above the block.
+func (l *LiveRegion) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !Interactive() {
+		if l.err == nil {
+			_, l.err = writeFull(l.w, p)
+		}
+		return len(p), l.err
+	}
+
+	l.pending = append(l.pending, p...)
+	i := bytes.LastIndexByte(l.pending, '\n')
+	if i < 0 {
+		return len(p), l.err
+	}
+	// Lines end with CRLF in case the terminal is in raw mode
+	lines := strings.ReplaceAll(string(l.pending[:i+1]), "\n", "\r\n")
+	l.pending = append(l.pending[:0], l.pending[i+1:]...)
+
+	// Replace the block with the output, then draw it again below
+	blockLines := l.block.lines
+	s := l.block.erase() + lines
+	if len(blockLines) > 0 {
+		s += l.block.update(blockLines...)
+	}
+	return len(p), l.write(s)
+}
+
+// WriteString is like Write, but writes the contents of string s.
+func (l *LiveRegion) WriteString(s string) (int, error) {
+	return l.Write([]byte(s))
+}
+
+// Close writes any incomplete line of output, and leaves the block on screen
+// below it, with the cursor on the next line.
+func (l *LiveRegion) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines := l.block.lines
+	var s string
+	if Interactive() {
+		s = l.block.erase()
+	}
+	if len(l.pending) > 0 {
+		s += string(l.pending) + "\r\n"
+		l.pending = nil
+	}
+	for _, line := range lines {
+		s += line + "\r\n"
+	}
+	return l.write(s)
+}
+
+// write writes s unless an error occurred before. It must be called with
+// l.mu held.
+func (l *LiveRegion) write(s string) error {
+	if l.err == nil && s != "" {
+		_, l.err = io.WriteString(l.w, s)
+	}
+	return l.err
+}
+
+// OverwriteLine replaces the line the cursor is on with text, such as a
+// status updated in place, for when a LiveRegion is more than needed. Only
+// the first line of text is written, and if w is a terminal, it is truncated
+// to fit, keeping its escape sequences so that styles and hyperlinks are
+// ended. The cursor is left at the end of the text. Nothing is written if
+// interactive sequences are disabled.
+func OverwriteLine(w io.Writer, text string) error {
+	if !Interactive() {
+		return nil
+	}
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	if f, ok := w.(*os.File); ok && IsTerminal(f) {
+		if size, err := GetConsoleSize(f.Fd()); err == nil && size.Cols > 1 {
+			// Leave the last column free, so that the cursor does not wrap
+			head, tail := splitAtWidth(text, size.Cols-1)
+			text = head.s + sequencesOnly(tail.s)
+		}
+	}
+	_, err := io.WriteString(w, "\r"+text+EraseRight)
+	return err
+}
+
+// RedrawInPlace replaces the last oldLineCount lines of output with
+// newContent, such as a report updated as it changes, without switching to
+// the alternate screen, so that the last content stays in the scrollback. The
+// cursor is expected at the start of the line following the old content, and
+// is left at the start of the line following the new content, which is ended
+// with a newline if it is not. It returns the number of lines of newContent,
+// to pass as oldLineCount to the next call.
+//
+// Lines are overwritten and erased one by one, within synchronized update
+// sequences, so that the content does not flicker. Lines should fit on a row,
+// since wrapped lines take more rows than are counted, and content taller
+// than the terminal cannot be replaced in full. If interactive sequences are
+// disabled, newContent is written after the old content.
+func RedrawInPlace(w io.Writer, oldLineCount int, newContent string) (int, error) {
+	// Lines ending with CRLF would be erased by the erase before the LF
+	newContent = strings.ReplaceAll(newContent, "\r\n", "\n")
+	if newContent != "" && !strings.HasSuffix(newContent, "\n") {
+		newContent += "\n"
+	}
+	n := strings.Count(newContent, "\n")
+	if !Interactive() {
+		_, err := io.WriteString(w, newContent)
+		return n, err
+	}
+
+	var b strings.Builder
+	b.WriteString(SyncUpdateBegin + "\r")
+	if oldLineCount > 0 {
+		b.WriteString(CursorMove(0, -oldLineCount))
+	}
+	// Lines end with CRLF in case the terminal is in raw mode
+	b.WriteString(strings.ReplaceAll(newContent, "\n", EraseRight+"\r\n"))
+	b.WriteString(EraseDown + SyncUpdateEnd)
+	_, err := io.WriteString(w, b.String())
+	return n, err
+}
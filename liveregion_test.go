@@ -0,0 +1,60 @@
+package escapes
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLiveRegion(t *testing.T) {
+	defer SetInteractive(Interactive())
+	SetInteractive(true)
+
+	var b bytes.Buffer
+	l := NewLiveRegion(&b)
+	step := func(name, want string) {
+		t.Helper()
+		if got := b.String(); got != want {
+			t.Errorf("%s: wrote %q, want %q", name, got, want)
+		}
+		b.Reset()
+	}
+
+	l.Set("a", "b")
+	step("Set", "\ra"+EraseRight+"\r\n\rb"+EraseRight)
+	l.Set("a", "c")
+	step("Set changed", "\rc"+EraseRight)
+	l.WriteString("log\npart")
+	step("Write", "\r"+CursorMove(0, -1)+EraseDown+"log\r\n"+"\ra"+EraseRight+"\r\n\rc"+EraseRight)
+	l.Close()
+	step("Close", "\r"+CursorMove(0, -1)+EraseDown+"part\r\na\r\nc\r\n")
+}
+
+func TestLiveRegionNotInteractive(t *testing.T) {
+	defer SetInteractive(Interactive())
+	SetInteractive(false)
+
+	var b bytes.Buffer
+	l := NewLiveRegion(&b)
+	l.Set("50%")
+	l.WriteString("log\n")
+	l.Set("100%")
+	l.Close()
+	if got, want := b.String(), "log\n100%\r\n"; got != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+}
+
+func TestRedrawInPlace(t *testing.T) {
+	defer SetInteractive(Interactive())
+	SetInteractive(true)
+
+	e := NewEmulator(10, 4)
+	n, _ := RedrawInPlace(e, 0, "one\ntwo\nsix")
+	n, _ = RedrawInPlace(e, n, "three\r\nfour\r\n")
+	if n != 2 {
+		t.Errorf("RedrawInPlace returned %d lines, want 2", n)
+	}
+	if got, want := e.String(), "three\nfour\n\n"; got != want {
+		t.Errorf("screen = %q, want %q", got, want)
+	}
+}
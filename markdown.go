@@ -0,0 +1,629 @@
+package escapes
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// markdownRuleWidth is the width of horizontal rules when not wrapping.
+const markdownRuleWidth = 40
+
+// Bullets of unordered lists, by nesting level
+var markdownBullets = []string{"•", "◦", "▪"}
+
+// RenderMarkdown renders Markdown as styled text for the terminal, such as
+// help texts and changelogs, with each line ending in a newline. Paragraphs
+// are wrapped to width columns, or not at all if width is 0.
+//
+// A practical subset of Markdown is supported: ATX and setext headings,
+// paragraphs, fenced code blocks, block quotes, ordered and unordered lists,
+// which may be nested, horizontal rules, emphasis, strong emphasis,
+// strikethrough, code spans, links, autolinks and backslash escapes. Other
+// syntax, such as tables and HTML, is left as text.
+//
+// Text uses t.Text, headings t.Accent, code t.Info, links t.Accent and are
+// made clickable with OSC 8 hyperlinks, and quotes, rules and list markers
+// t.Muted.
+func RenderMarkdown(src string, width int, t Theme) string {
+	m := markdown{theme: t, base: t.Text}
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	lines := m.blocks(strings.Split(src, "\n"), width, false)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// markdown is the state of RenderMarkdown.
+type markdown struct {
+	theme Theme
+	base  Style // Style of text in the current block
+	depth int   // Nesting level of lists
+}
+
+// blocks renders lines of Markdown. Blocks are separated by blank lines,
+// unless tight is set, in which case they are only separated where the source
+// has blank lines, as within list items.
+func (m *markdown) blocks(lines []string, width int, tight bool) []string {
+	var (
+		out []string
+		sep bool // Whether a blank line precedes the current block
+	)
+	add := func(block ...string) {
+		if len(out) > 0 && (sep || !tight) {
+			out = append(out, "")
+		}
+		out = append(out, block...)
+		sep = false
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		text := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(line) == "" {
+			sep = true
+			i++
+			continue
+		}
+
+		if fence := markdownFence(text); fence != "" {
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimLeft(lines[i], " "), fence) &&
+					strings.Trim(strings.TrimSpace(lines[i]), fence[:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, "  "+m.theme.Info.Render(lines[i]))
+			}
+			add(code...)
+			continue
+		}
+
+		if level, title, ok := markdownHeading(text); ok {
+			add(m.heading(level, title, width)...)
+			i++
+			continue
+		}
+
+		if markdownRule(text) {
+			w := width
+			if w <= 0 {
+				w = markdownRuleWidth
+			}
+			add(m.theme.Muted.Render(strings.Repeat("─", w)))
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(text, ">") {
+			var quote []string
+			for ; i < len(lines); i++ {
+				text := strings.TrimLeft(lines[i], " ")
+				if !strings.HasPrefix(text, ">") {
+					break
+				}
+				text = strings.TrimPrefix(text[1:], " ")
+				quote = append(quote, text)
+			}
+			add(m.quote(quote, width)...)
+			continue
+		}
+
+		if _, _, _, ok := markdownListItem(line); ok {
+			var list []string
+			list, i = m.list(lines, i, width)
+			add(list...)
+			continue
+		}
+
+		// A paragraph continues until a blank line or another block, unless
+		// it is underlined as a setext heading
+		para := []string{text}
+		level := 0
+		for i++; i < len(lines); i++ {
+			next := strings.TrimSpace(lines[i])
+			if next != "" && strings.Trim(next, "=") == "" {
+				level = 1
+			} else if next != "" && strings.Trim(next, "-") == "" {
+				level = 2
+			}
+			if level > 0 {
+				i++
+				break
+			}
+			if next == "" || markdownInterrupts(lines[i]) {
+				break
+			}
+			para = append(para, lines[i])
+		}
+		if level > 0 {
+			add(m.heading(level, strings.Join(para, " "), width)...)
+		} else {
+			add(m.paragraph(para, m.base, width)...)
+		}
+	}
+	return out
+}
+
+// heading renders a heading of the given level.
+func (m *markdown) heading(level int, title string, width int) []string {
+	style := overlayStyle(m.base, Style{Attrs: AttrBold})
+	switch level {
+	case 1:
+		style = overlayStyle(style, m.theme.Accent.With(AttrUnderline))
+	case 2:
+		style = overlayStyle(style, m.theme.Accent)
+	}
+	return m.paragraph([]string{title}, style, width)
+}
+
+// paragraph renders the lines of a paragraph as wrapped text. Lines ending in
+// two spaces or a backslash are kept as line breaks.
+func (m *markdown) paragraph(lines []string, style Style, width int) []string {
+	var text strings.Builder
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		last := i == len(lines)-1
+		hard := !last && (strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\"))
+		line = strings.TrimRight(line, " ")
+		if hard {
+			line = strings.TrimSuffix(line, "\\")
+		}
+		text.WriteString(line)
+		if !last {
+			if hard {
+				text.WriteByte('\n')
+			} else {
+				text.WriteByte(' ')
+			}
+		}
+	}
+	s := joinSpans(m.inline(text.String(), style, "", nil))
+	if width <= 0 {
+		width = math.MaxInt32
+	}
+	return wrapLinks(wrapLines(s, width))
+}
+
+// quote renders the contents of a block quote, behind a bar.
+func (m *markdown) quote(lines []string, width int) []string {
+	base := m.base
+	m.base = overlayStyle(base, m.theme.Muted)
+	body := m.blocks(lines, markdownInnerWidth(width, 2), false)
+	m.base = base
+
+	bar := m.theme.Muted.Render("│")
+	for i, line := range body {
+		body[i] = bar + " " + line
+	}
+	return body
+}
+
+// list renders the list starting at lines[i], returning its lines and the
+// index of the line following it.
+func (m *markdown) list(lines []string, i, width int) ([]string, int) {
+	_, first, _, _ := markdownListItem(lines[i])
+	ordered := first != ""
+	number, _ := strconv.Atoi(strings.TrimRight(first, ".)"))
+
+	type item struct {
+		marker string
+		body   []string
+	}
+	var (
+		items   []item
+		markerW int
+	)
+	for i < len(lines) {
+		col, marker, content, ok := markdownListItem(lines[i])
+		if !ok || (marker != "") != ordered {
+			break
+		}
+		if ordered {
+			marker = strconv.Itoa(number) + "."
+			number++
+		} else {
+			marker = markdownBullets[m.depth%len(markdownBullets)]
+		}
+		markerW = max(markerW, StringWidth(marker))
+
+		// The item continues with the lines indented past its marker, and
+		// with lazy continuations of its paragraphs
+		body := []string{content}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if strings.TrimSpace(line) == "" {
+				j := i
+				for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+					j++
+				}
+				if j == len(lines) || len(lines[j])-len(strings.TrimLeft(lines[j], " ")) < col {
+					break
+				}
+				body = append(body, "")
+				continue
+			}
+			if indent >= col {
+				body = append(body, line[col:])
+				continue
+			}
+			if strings.TrimSpace(lines[i-1]) == "" || markdownInterrupts(line) {
+				break
+			}
+			body = append(body, line)
+		}
+		items = append(items, item{marker, body})
+
+		// Blank lines between items are skipped
+		j := i
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+			j++
+		}
+		if j < len(lines) {
+			if _, _, _, ok := markdownListItem(lines[j]); ok {
+				i = j
+			}
+		}
+	}
+
+	m.depth++
+	defer func() { m.depth-- }()
+
+	var out []string
+	for _, it := range items {
+		pad := strings.Repeat(" ", markerW-StringWidth(it.marker))
+		if ordered {
+			it.marker = pad + it.marker
+		} else {
+			it.marker += pad
+		}
+		body := m.blocks(it.body, markdownInnerWidth(width, markerW+1), true)
+		if len(body) == 0 {
+			body = []string{""}
+		}
+		for j, line := range body {
+			switch {
+			case j == 0:
+				line = m.theme.Muted.Render(it.marker) + " " + line
+			case line != "":
+				line = strings.Repeat(" ", markerW+1) + line
+			}
+			out = append(out, line)
+		}
+	}
+	return out, i
+}
+
+// inline appends the spans of inline Markdown s, in the given style and
+// hyperlink.
+func (m *markdown) inline(s string, style Style, url string, spans []Span) []Span {
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			spans = append(spans, Span{Text: text.String(), Style: style, URL: url})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isMarkdownPunct(s[i+1]):
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			n := markdownRun(s, i)
+			if end := markdownCodeEnd(s, i+n, n); end >= 0 {
+				code := s[i+n : end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				flush()
+				spans = append(spans, Span{Text: code, Style: overlayStyle(style, m.theme.Info), URL: url})
+				i = end + n
+				continue
+			}
+			text.WriteString(s[i : i+n])
+			i += n
+			continue
+
+		case c == '*' || c == '_' || c == '~':
+			n := markdownRun(s, i)
+			var attrs Attr
+			switch {
+			case c == '~' && n == 2:
+				attrs = AttrStrikethrough
+			case c != '~' && n == 1:
+				attrs = AttrItalic
+			case c != '~' && n == 2:
+				attrs = AttrBold
+			case c != '~' && n == 3:
+				attrs = AttrBold | AttrItalic
+			}
+			if attrs != 0 && markdownOpens(s, i, n) {
+				if end := markdownEmphasisEnd(s, i+n, c, n); end >= 0 {
+					flush()
+					spans = m.inline(s[i+n:end], style.With(attrs), url, spans)
+					i = end + n
+					continue
+				}
+			}
+			text.WriteString(s[i : i+n])
+			i += n
+			continue
+
+		case c == '[' || (c == '!' && strings.HasPrefix(s[i+1:], "[")):
+			start := i
+			if c == '!' {
+				start++
+			}
+			if label, target, end, ok := markdownLink(s, start); ok {
+				flush()
+				spans = m.inline(label, overlayStyle(style, m.theme.Accent.With(AttrUnderline)), target, spans)
+				i = end
+				continue
+			}
+
+		case c == '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				target := s[i+1 : i+end]
+				if strings.Contains(target, "://") && !strings.ContainsAny(target, " <") {
+					flush()
+					spans = append(spans, Span{
+						Text:  target,
+						Style: overlayStyle(style, m.theme.Accent.With(AttrUnderline)),
+						URL:   target,
+					})
+					i += end + 1
+					continue
+				}
+			}
+		}
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+	return spans
+}
+
+// overlayStyle returns base with the colors set by s, and the attributes of
+// both.
+func overlayStyle(base, s Style) Style {
+	if !s.Fg.IsDefault() {
+		base.Fg = s.Fg
+	}
+	if !s.Bg.IsDefault() {
+		base.Bg = s.Bg
+	}
+	base.Attrs |= s.Attrs
+	return base
+}
+
+// wrapLinks closes hyperlinks that are open at the end of wrapped lines, and
+// opens them again on the next line, so that indentation added around the
+// lines is not part of the links.
+func wrapLinks(lines []string) []string {
+	var url string
+	for i, line := range lines {
+		if url != "" {
+			line = Osc + "8;;" + url + oscEnd() + line
+		}
+		for j := 0; j < len(line); {
+			n := sequenceLen(line[j:])
+			if n == 0 {
+				j++
+				continue
+			}
+			if _, u, ok := parseLinkSequence(line[j : j+n]); ok {
+				url = u
+			}
+			j += n
+		}
+		if url != "" {
+			line += Osc + "8;;" + oscEnd()
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// markdownInnerWidth returns the width left to nested blocks indented by n
+// columns, keeping 0 as no wrapping.
+func markdownInnerWidth(width, n int) int {
+	if width <= 0 {
+		return 0
+	}
+	return max(width-n, 1)
+}
+
+// markdownFence returns the fence starting a fenced code block, or "".
+func markdownFence(line string) string {
+	if n := markdownRun(line, 0); n >= 3 && (line[0] == '`' || line[0] == '~') {
+		return line[:n]
+	}
+	return ""
+}
+
+// markdownHeading parses an ATX heading, such as "## Title".
+func markdownHeading(line string) (level int, title string, ok bool) {
+	level = markdownRun(line, 0)
+	if level == 0 || level > 6 || line[0] != '#' {
+		return 0, "", false
+	}
+	if level < len(line) && line[level] != ' ' && line[level] != '\t' {
+		return 0, "", false
+	}
+	title = strings.TrimSpace(line[level:])
+	// An optional closing sequence of #s
+	if t := strings.TrimRight(title, "#"); t == "" || strings.HasSuffix(t, " ") {
+		title = strings.TrimSpace(t)
+	}
+	return level, title, true
+}
+
+// markdownRule reports whether line is a horizontal rule, such as "---" or
+// "* * *".
+func markdownRule(line string) bool {
+	s := strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+	return len(s) >= 3 && (strings.Trim(s, "-") == "" || strings.Trim(s, "*") == "" || strings.Trim(s, "_") == "")
+}
+
+// markdownListItem parses the first line of a list item, returning the column
+// its content starts at, its marker for ordered lists, such as "1.", and its
+// content.
+func markdownListItem(line string) (col int, marker, content string, ok bool) {
+	text := strings.TrimLeft(line, " ")
+	indent := len(line) - len(text)
+	if markdownRule(text) {
+		return 0, "", "", false
+	}
+
+	n := 0
+	switch {
+	case strings.HasPrefix(text, "- "), strings.HasPrefix(text, "* "), strings.HasPrefix(text, "+ "):
+		n = 1
+	default:
+		for n < len(text) && n < 9 && text[n] >= '0' && text[n] <= '9' {
+			n++
+		}
+		if n == 0 || n+1 >= len(text) || (text[n] != '.' && text[n] != ')') || text[n+1] != ' ' {
+			return 0, "", "", false
+		}
+		n++
+		marker = text[:n]
+	}
+	content = strings.TrimLeft(text[n:], " ")
+	return indent + n + 1, marker, content, true
+}
+
+// markdownInterrupts reports whether line starts a block that ends a
+// paragraph.
+func markdownInterrupts(line string) bool {
+	text := strings.TrimLeft(line, " ")
+	_, _, headed := markdownHeading(text)
+	_, _, _, listed := markdownListItem(line)
+	return headed || listed || markdownFence(text) != "" || markdownRule(text) || strings.HasPrefix(text, ">")
+}
+
+// markdownRun returns the length of the run of the byte at s[i].
+func markdownRun(s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// markdownCodeEnd returns the index of the run of n backticks closing a code
+// span, or -1.
+func markdownCodeEnd(s string, i, n int) int {
+	for i < len(s) {
+		j := strings.IndexByte(s[i:], '`')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		run := markdownRun(s, i)
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// markdownOpens reports whether the run of n delimiters at s[i] can open
+// emphasis: it must be followed by text, and underscores must not be within a
+// word.
+func markdownOpens(s string, i, n int) bool {
+	if i+n >= len(s) || s[i+n] == ' ' {
+		return false
+	}
+	if s[i] == '_' && i > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:i])
+		return !isWordRune(r)
+	}
+	return true
+}
+
+// markdownEmphasisEnd returns the index of the run of n delimiters c closing
+// emphasis, or -1. Code spans and escaped characters are skipped.
+func markdownEmphasisEnd(s string, i int, c byte, n int) int {
+	for i < len(s) {
+		switch s[i] {
+		case '\\':
+			i += 2
+			continue
+		case '`':
+			run := markdownRun(s, i)
+			if end := markdownCodeEnd(s, i+run, run); end >= 0 {
+				i = end + run
+				continue
+			}
+			i += run
+			continue
+		case c:
+			run := markdownRun(s, i)
+			closes := run == n && s[i-1] != ' '
+			if closes && c == '_' && i+run < len(s) {
+				r, _ := utf8.DecodeRuneInString(s[i+run:])
+				closes = !isWordRune(r)
+			}
+			if closes {
+				return i
+			}
+			i += run
+			continue
+		}
+		i++
+	}
+	return -1
+}
+
+// markdownLink parses a link of the form [label](target "title") at s[i],
+// returning the index following it.
+func markdownLink(s string, i int) (label, target string, end int, ok bool) {
+	depth := 0
+	j := i
+	for ; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+			continue
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if j >= len(s)-1 || s[j+1] != '(' {
+		return "", "", 0, false
+	}
+	paren := strings.IndexByte(s[j+2:], ')')
+	if paren < 0 {
+		return "", "", 0, false
+	}
+	target = strings.TrimSpace(s[j+2 : j+2+paren])
+	if k := strings.IndexByte(target, ' '); k >= 0 {
+		// Titles are not shown
+		target = target[:k]
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	return s[i+1 : j], target, j + 3 + paren, true
+}
+
+// isMarkdownPunct reports whether c is an ASCII punctuation character, which
+// can be escaped with a backslash.
+func isMarkdownPunct(c byte) bool {
+	return c < utf8.RuneSelf && (unicode.IsPunct(rune(c)) || unicode.IsSymbol(rune(c)))
+}
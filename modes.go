@@ -0,0 +1,29 @@
+package escapes
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SaveMode returns an escape sequence to save the current values of private
+// modes, such as 1000 for mouse reporting or 2004 for bracketed paste
+// (XTSAVE), so that they can be restored with RestoreMode instead of being
+// reset to values the user may not have had. xterm and most terminals based
+// on it support saving modes; others ignore it.
+func SaveMode(modes ...int) string {
+	return Esc + "?" + joinModes(modes) + "s"
+}
+
+// RestoreMode returns an escape sequence to restore the values of private
+// modes saved by SaveMode (XTRESTORE).
+func RestoreMode(modes ...int) string {
+	return Esc + "?" + joinModes(modes) + "r"
+}
+
+func joinModes(modes []int) string {
+	s := make([]string, len(modes))
+	for i, m := range modes {
+		s[i] = strconv.Itoa(m)
+	}
+	return strings.Join(s, ";")
+}
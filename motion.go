@@ -0,0 +1,81 @@
+package escapes
+
+import "strconv"
+
+// MoveTo returns the shortest escape sequence that moves the cursor from one
+// coordinate pair to another, where (0, 0) is the top-left corner. It chooses
+// between carriage return, backspaces, relative moves (CUF, CUB, CUU, CUD),
+// absolute column and row moves (CHA, VPA) and absolute positioning (CUP).
+//
+// The current position must be known exactly; in particular, the cursor must
+// not be in the pending-wrap state after writing to the last column.
+func MoveTo(fromX, fromY, toX, toY int) string {
+	if fromX == toX && fromY == toY {
+		return ""
+	}
+
+	move := moveX(fromX, toX) + moveY(fromY, toY)
+	cup := Esc + "H"
+	switch {
+	case toX == 0 && toY == 0:
+	case toX == 0:
+		cup = Esc + strconv.Itoa(toY+1) + "H"
+	default:
+		cup = Esc + strconv.Itoa(toY+1) + ";" + strconv.Itoa(toX+1) + "H"
+	}
+	return shortest(move, cup)
+}
+
+// moveX returns the shortest sequence to move horizontally within a row.
+func moveX(from, to int) string {
+	switch {
+	case from == to:
+		return ""
+	case to == 0:
+		return "\r"
+	}
+
+	cha := csiN(to+1, 'G')
+	if to > from {
+		return shortest(csiN(to-from, 'C'), cha, "\r"+csiN(to, 'C'))
+	}
+	var bs string
+	if n := from - to; n <= 4 {
+		for i := 0; i < n; i++ {
+			bs += "\b"
+		}
+	}
+	return shortest(csiN(from-to, 'D'), cha, bs)
+}
+
+// moveY returns the shortest sequence to move vertically within a column.
+func moveY(from, to int) string {
+	switch {
+	case from == to:
+		return ""
+	case to > from:
+		return shortest(csiN(to-from, 'B'), csiN(to+1, 'd'))
+	default:
+		return shortest(csiN(from-to, 'A'), csiN(to+1, 'd'))
+	}
+}
+
+// csiN returns a control sequence with a single numeric parameter, which is
+// omitted if it equals the default of 1.
+func csiN(n int, final byte) string {
+	if n == 1 {
+		return Esc + string(final)
+	}
+	return Esc + strconv.Itoa(n) + string(final)
+}
+
+// shortest returns the shortest non-empty string among candidates.
+func shortest(candidates ...string) string {
+	var best string
+	for _, c := range candidates {
+		if c != "" && (best == "" || len(c) < len(best)) {
+			best = c
+		}
+	}
+	return best
+}
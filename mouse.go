@@ -0,0 +1,98 @@
+package escapes
+
+// MouseButton is a mouse button, or wheel direction.
+type MouseButton int
+
+// Mouse buttons
+const (
+	MouseNone MouseButton = iota
+	MouseLeft
+	MouseMiddle
+	MouseRight
+	MouseWheelUp
+	MouseWheelDown
+	MouseWheelLeft
+	MouseWheelRight
+	MouseBackward
+	MouseForward
+)
+
+// MouseAction is the kind of a mouse event.
+type MouseAction int
+
+// Mouse actions
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseMotion
+)
+
+// MouseEvent is a mouse event, reported by the terminal once mouse reporting
+// is enabled with MouseNormalEnable or similar. Wheel events are presses of
+// the wheel buttons; with AlternateScrollEnable and no mouse reporting, the
+// wheel sends arrow keys instead.
+type MouseEvent struct {
+	// X and Y are the cell the event happened in, where (0, 0) is the
+	// top-left corner.
+	X, Y int
+
+	// PixelX and PixelY are the position of the event in pixels from the
+	// top-left corner, when reported with MouseSGRPixelsEnable; otherwise
+	// they are those of the top-left corner of the cell, using CellSize.
+	PixelX, PixelY int
+
+	Button MouseButton
+	Action MouseAction
+
+	// Mod are the modifiers held, among Shift, Alt and Ctrl. Terminals
+	// often reserve some of them for their own use, such as selecting text.
+	Mod Modifier
+}
+
+// mouseEvent decodes the button byte and coordinates of a mouse report, where
+// the coordinates are 1-based cells, or pixels if pixels is true.
+func mouseEvent(cb, x, y int, release, pixels bool) MouseEvent {
+	ev := MouseEvent{}
+	cell := CellSize()
+	if pixels {
+		ev.PixelX, ev.PixelY = x, y
+		if cell.Width > 0 && cell.Height > 0 {
+			ev.X, ev.Y = x/cell.Width, y/cell.Height
+		}
+	} else {
+		ev.X, ev.Y = x-1, y-1
+		ev.PixelX, ev.PixelY = ev.X*cell.Width, ev.Y*cell.Height
+	}
+
+	if cb&4 != 0 {
+		ev.Mod |= ModShift
+	}
+	if cb&8 != 0 {
+		ev.Mod |= ModAlt
+	}
+	if cb&16 != 0 {
+		ev.Mod |= ModCtrl
+	}
+
+	button := cb & 3
+	switch {
+	case cb&128 != 0:
+		ev.Button = MouseBackward + MouseButton(button)
+	case cb&64 != 0:
+		ev.Button = MouseWheelUp + MouseButton(button)
+	case button == 3:
+		// Legacy reports do not tell which button was released
+		ev.Button = MouseNone
+		release = cb&32 == 0
+	default:
+		ev.Button = MouseLeft + MouseButton(button)
+	}
+
+	switch {
+	case cb&32 != 0:
+		ev.Action = MouseMotion
+	case release:
+		ev.Action = MouseRelease
+	}
+	return ev
+}
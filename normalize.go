@@ -0,0 +1,131 @@
+package escapes
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// NewNormalizeReader returns a reader that rewrites the escape sequences in
+// the output read from r to a canonical form, so that equivalent sequences
+// compare equal:
+//
+//   - Default parameters are omitted, such as in CSI 1 A or CSI 1 ; 1 H.
+//   - SGR parameters lose their leading zeros, a lone reset is written as
+//     CSI m, and colors using colons are written with semicolons.
+//   - CSI f is written as CSI H.
+//   - OSC sequences are terminated by BEL rather than ST.
+func NewNormalizeReader(r io.Reader) io.Reader {
+	return newTransformReader(r, normalize)
+}
+
+func normalize(s string) string {
+	if strings.IndexByte(s, AsciiEscape) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			j := i + 1
+			for j < len(s) && s[j] != AsciiEscape {
+				j++
+			}
+			b.WriteString(s[i:j])
+			i = j
+			continue
+		}
+		b.WriteString(normalizeSequence(s[i : i+n]))
+		i += n
+	}
+	return b.String()
+}
+
+// csiDefaultOne are the final bytes of CSI sequences whose only parameter
+// defaults to 1.
+const csiDefaultOne = "@ABCDEFGIPSTXZLMdeab`"
+
+func normalizeSequence(seq string) string {
+	if strings.HasPrefix(seq, Osc) && strings.HasSuffix(seq, St) {
+		return strings.TrimSuffix(seq, St) + Bel
+	}
+	if len(seq) < 3 || seq[:2] != Esc {
+		return seq
+	}
+	params, final := seq[2:len(seq)-1], seq[len(seq)-1]
+	for i := 0; i < len(params); i++ {
+		if c := params[i]; (c < '0' || c > '9') && c != ';' && c != ':' {
+			// Private or intermediate bytes, left as is
+			return seq
+		}
+	}
+
+	switch {
+	case final == 'm':
+		return Esc + normalizeSGR(params) + "m"
+	case final == 'H' || final == 'f':
+		fields := strings.Split(params, ";")
+		for len(fields) > 0 && trimZeros(fields[len(fields)-1]) <= "1" {
+			fields = fields[:len(fields)-1]
+		}
+		for i := range fields {
+			if fields[i] = trimZeros(fields[i]); fields[i] == "0" {
+				fields[i] = "1"
+			}
+		}
+		return Esc + strings.Join(fields, ";") + "H"
+	case final == 'J' || final == 'K':
+		if p := trimZeros(params); p != "0" && p != "" {
+			return Esc + p + string(final)
+		}
+		return Esc + string(final)
+	case strings.IndexByte(csiDefaultOne, final) >= 0 && !strings.ContainsAny(params, ";:"):
+		if p := trimZeros(params); p != "0" && p != "1" && p != "" {
+			return Esc + p + string(final)
+		}
+		return Esc + string(final)
+	}
+	return seq
+}
+
+// normalizeSGR returns the canonical form of the parameters of an SGR
+// sequence.
+func normalizeSGR(params string) string {
+	var out []string
+	fields := strings.Split(params, ";")
+	for i := 0; i < len(fields); i++ {
+		sub := strings.Split(fields[i], ":")
+		p := trimZeros(sub[0])
+		if (p == "38" || p == "48" || p == "58") && len(sub) > 1 {
+			if c, ok := parseSGRColor(sub[1:], true); ok {
+				out = append(out, p+strings.TrimPrefix(c.params(false), "38"))
+				continue
+			}
+		}
+		if len(sub) > 1 {
+			for j := range sub {
+				sub[j] = trimZeros(sub[j])
+			}
+			out = append(out, strings.Join(sub, ":"))
+			continue
+		}
+		out = append(out, p)
+	}
+	if len(out) == 1 && out[0] == "0" {
+		return ""
+	}
+	return strings.Join(out, ";")
+}
+
+// trimZeros removes the leading zeros of a number, and an empty number
+// becomes 0.
+func trimZeros(p string) string {
+	if n, err := strconv.Atoi(p); err == nil && n >= 0 {
+		return strconv.Itoa(n)
+	}
+	if p == "" {
+		return "0"
+	}
+	return p
+}
@@ -0,0 +1,227 @@
+package escapes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// NotificationProtocol is a protocol of escape sequences for desktop
+// notifications.
+type NotificationProtocol int
+
+// Notification protocols
+const (
+	// NotificationOSC9 shows a message (OSC 9), as introduced by iTerm2 and
+	// supported by WezTerm, Ghostty and others. Titles are joined to bodies.
+	NotificationOSC9 NotificationProtocol = iota
+
+	// NotificationOSC99 is the notification protocol of kitty (OSC 99), which
+	// supports titles, urgency, actions, and updating and closing
+	// notifications.
+	NotificationOSC99
+
+	// NotificationOSC777 shows a title and a body (OSC 777 ; notify), as
+	// introduced by rxvt-unicode and supported by foot, Ghostty and some
+	// VTE-based terminals, some of which only support this protocol.
+	NotificationOSC777
+)
+
+// Urgency is the urgency of a notification.
+type Urgency int
+
+// Urgencies of notifications
+const (
+	UrgencyNormal Urgency = iota
+	UrgencyLow
+	UrgencyCritical
+)
+
+// Actions taken when a notification is activated, such as by clicking it
+const (
+	NotificationFocus  = "focus"  // Focus the window that sent the notification
+	NotificationReport = "report" // Report the activation to the program
+)
+
+// notificationChunkSize is the size of the text of each sequence of an OSC 99
+// notification, before it is encoded.
+const notificationChunkSize = 2048
+
+var notificationID int32
+
+// Notification is a desktop notification.
+type Notification struct {
+	// ID identifies the notification, to update or close it. It is made of
+	// letters, digits, and the characters - _ + and .; a notification whose
+	// text is sent in several sequences is given one if it is empty.
+	ID string
+
+	Title string
+	Body  string
+
+	Urgency Urgency
+
+	// Actions are taken when the notification is activated, such as
+	// NotificationFocus and NotificationReport. The terminal focuses the
+	// window by default.
+	Actions []string
+
+	// ReportClose requests the terminal to report when the notification is
+	// closed.
+	ReportClose bool
+}
+
+// Sequence returns the escape sequences that show the notification with
+// protocol p. Only the title and body are shown with NotificationOSC9 and
+// NotificationOSC777.
+//
+// OSC 777 separates the title and body with semicolons, and has no way of
+// escaping them, so semicolons in the title and body are replaced with
+// fullwidth semicolons (U+FF1B), which look the same.
+func (n Notification) Sequence(p NotificationProtocol) string {
+	switch p {
+	case NotificationOSC777:
+		escape := strings.NewReplacer(";", "\uFF1B")
+		return Osc + "777;notify;" + escape.Replace(stripControls(n.Title)) + ";" +
+			escape.Replace(stripControls(n.Body)) + oscEnd()
+	case NotificationOSC9:
+		text := n.Body
+		if n.Title != "" && n.Body != "" {
+			text = n.Title + ": " + n.Body
+		} else if n.Title != "" {
+			text = n.Title
+		}
+		return Osc + "9;" + stripControls(text) + oscEnd()
+	}
+
+	var meta []string
+	switch n.Urgency {
+	case UrgencyLow:
+		meta = append(meta, "u=0")
+	case UrgencyCritical:
+		meta = append(meta, "u=2")
+	}
+	if len(n.Actions) > 0 {
+		meta = append(meta, "a="+strings.Join(n.Actions, ","))
+	}
+	if n.ReportClose {
+		meta = append(meta, "c=1")
+	}
+
+	// Texts longer than a chunk are sent in several sequences, each but the
+	// last marked as incomplete, and joined by the ID of the notification
+	type part struct{ kind, text string }
+	var parts []part
+	for _, p := range []part{{"title", n.Title}, {"body", n.Body}} {
+		for _, chunk := range chunkString(p.text, notificationChunkSize) {
+			parts = append(parts, part{p.kind, chunk})
+		}
+	}
+	if len(parts) == 0 {
+		parts = []part{{"title", ""}}
+	}
+	id := n.ID
+	if id == "" && len(parts) > 1 {
+		id = "n" + strconv.Itoa(int(atomic.AddInt32(&notificationID, 1)))
+	}
+
+	var b strings.Builder
+	for i, p := range parts {
+		var keys []string
+		if id != "" {
+			keys = append(keys, "i="+id)
+		}
+		if i < len(parts)-1 {
+			keys = append(keys, "d=0")
+		}
+		keys = append(keys, "p="+p.kind, "e=1")
+		if i == 0 {
+			keys = append(keys, meta...)
+		}
+		b.WriteString(Osc + "99;" + strings.Join(keys, ":") + ";" +
+			base64.StdEncoding.EncodeToString([]byte(p.text)) + oscEnd())
+	}
+	return b.String()
+}
+
+// Notify returns the escape sequences that show a notification with the
+// protocol the terminal supports, guessed by DetectNotificationProtocol.
+func Notify(n Notification) string {
+	return n.Sequence(DetectNotificationProtocol())
+}
+
+// CloseNotification returns an escape sequence to close the notification with
+// the given ID (OSC 99).
+func CloseNotification(id string) string {
+	return Osc + "99;i=" + id + ":p=close;" + oscEnd()
+}
+
+// DetectNotificationProtocol guesses the notification protocol of the
+// terminal from the environment: NotificationOSC99 in kitty,
+// NotificationOSC777 in rxvt-unicode, foot and VTE-based terminals, and
+// NotificationOSC9 otherwise. QueryNotificationProtocol asks the terminal
+// instead.
+func DetectNotificationProtocol() NotificationProtocol {
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty":
+		return NotificationOSC99
+	case os.Getenv("VTE_VERSION") != "" || strings.HasPrefix(term, "rxvt") || strings.HasPrefix(term, "foot"):
+		return NotificationOSC777
+	}
+	return NotificationOSC9
+}
+
+// QueryNotificationProtocol asks the terminal whether it supports OSC 99,
+// and returns NotificationOSC99 if it does, or the protocol guessed by
+// DetectNotificationProtocol otherwise. See Query for the requirements on w
+// and r.
+func QueryNotificationProtocol(w io.Writer, r io.Reader, timeout time.Duration) (NotificationProtocol, error) {
+	// The device status report is answered by every terminal, after the
+	// reply to OSC 99 if any
+	reply, err := Query(w, r, Osc+"99;i=query:p=?;"+oscEnd()+Esc+"5n", func(b []byte) bool {
+		return bytes.Contains(b, []byte(Esc+"0n"))
+	}, timeout)
+	if bytes.Contains(reply, []byte(Osc+"99;")) {
+		return NotificationOSC99, nil
+	}
+	if err == ErrQueryTimeout && len(reply) > 0 {
+		err = nil
+	}
+	return DetectNotificationProtocol(), err
+}
+
+// chunkString splits s into chunks of at most size bytes, without splitting
+// runes.
+func chunkString(s string, size int) []string {
+	var chunks []string
+	for len(s) > size {
+		n := size
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		chunks = append(chunks, s[:n])
+		s = s[n:]
+	}
+	if s != "" {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
+
+// stripControls removes the control characters from s, so that it can be
+// used in an escape sequence.
+func stripControls(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || (r >= 0x7F && r < 0xA0) {
+			return -1
+		}
+		return r
+	}, s)
+}
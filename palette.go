@@ -0,0 +1,110 @@
+package escapes
+
+import (
+	"math"
+	"sync"
+)
+
+// paletteLab holds the CIELAB values of the 256 color palette, computed once.
+var (
+	paletteLabOnce sync.Once
+	paletteLab     [256][3]float64
+)
+
+// rgbToLab converts an sRGB color to CIELAB, under the D65 illuminant.
+func rgbToLab(r, g, b uint8) [3]float64 {
+	lr, lg, lb := srgbToLinear(r), srgbToLinear(g), srgbToLinear(b)
+	x := (0.4124*lr + 0.3576*lg + 0.1805*lb) / 0.95047
+	y := 0.2126*lr + 0.7152*lg + 0.0722*lb
+	z := (0.0193*lr + 0.1192*lg + 0.9505*lb) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// nearestPalette returns the index in [from, to) of the palette color closest
+// to the given RGB value, as perceived: the distance is measured in CIELAB.
+func nearestPalette(r, g, b uint8, from, to int) int {
+	paletteLabOnce.Do(func() {
+		for i := range paletteLab {
+			p := paletteRGB(i)
+			paletteLab[i] = rgbToLab(p[0], p[1], p[2])
+		}
+	})
+	lab := rgbToLab(r, g, b)
+	best, bestDist := from, -1.0
+	for i := from; i < to; i++ {
+		p := &paletteLab[i]
+		dl, da, db := p[0]-lab[0], p[1]-lab[1], p[2]-lab[2]
+		if d := dl*dl + da*da + db*db; bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// paletteTableBits is the number of high bits of each channel that index a
+// paletteTable.
+const paletteTableBits = 5
+
+// paletteTable maps RGB values, reduced to their high bits, to the nearest
+// color of a range of the palette, so that whole images are converted with a
+// lookup per pixel. It is filled on first use.
+type paletteTable struct {
+	from, to int
+	once     sync.Once
+	table    [1 << (3 * paletteTableBits)]uint8
+}
+
+// Tables of the 256 color palette, without the basic colors whose values
+// depend on the terminal's theme, and of the 16 basic colors
+var (
+	ansi256Table = &paletteTable{from: 16, to: 256}
+	ansiTable    = &paletteTable{from: 0, to: 16}
+)
+
+// nearest returns the index of the palette color closest to the given RGB
+// value, whose channels are clamped to [0, 255].
+func (t *paletteTable) nearest(c [3]float64) int {
+	t.once.Do(t.fill)
+	const shift = 8 - paletteTableBits
+	i := 0
+	for _, v := range c {
+		i = i<<paletteTableBits | int(clampChannel(v))>>shift
+	}
+	return int(t.table[i])
+}
+
+// fill computes the table from the center of the range of RGB values of
+// each of its entries.
+func (t *paletteTable) fill() {
+	const (
+		shift = 8 - paletteTableBits
+		size  = 1 << paletteTableBits
+		half  = 1 << (shift - 1)
+	)
+	for r := 0; r < size; r++ {
+		for g := 0; g < size; g++ {
+			for b := 0; b < size; b++ {
+				t.table[(r*size+g)*size+b] = uint8(nearestPalette(
+					uint8(r<<shift|half), uint8(g<<shift|half), uint8(b<<shift|half), t.from, t.to))
+			}
+		}
+	}
+}
+
+// clampChannel rounds v to the nearest value of a color channel.
+func clampChannel(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
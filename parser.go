@@ -0,0 +1,106 @@
+package escapes
+
+import (
+	"io"
+	"strings"
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind int
+
+// Kinds of tokens
+const (
+	TokenText   TokenKind = iota // Text, including control characters
+	TokenCSI                     // Control sequence, ESC [
+	TokenOSC                     // Operating system command, ESC ]
+	TokenDCS                     // Device control string, ESC P
+	TokenAPC                     // Application program command, ESC _
+	TokenPM                      // Privacy message, ESC ^
+	TokenSOS                     // Start of string, ESC X
+	TokenEscape                  // Any other escape sequence, such as ESC 7
+)
+
+// Token is a piece of output: either text, or a single escape sequence.
+type Token struct {
+	Kind  TokenKind
+	Value string
+}
+
+// Tokenize splits s into text and escape sequences. Concatenating the values
+// of the tokens yields s.
+func Tokenize(s string) []Token {
+	var tokens []Token
+	for i := 0; i < len(s); {
+		t := nextToken(s[i:])
+		tokens = append(tokens, t)
+		i += len(t.Value)
+	}
+	return tokens
+}
+
+// nextToken returns the token at the start of s, which must not be empty.
+func nextToken(s string) Token {
+	if n := sequenceLen(s); n > 0 {
+		return Token{Kind: sequenceKind(s[:n]), Value: s[:n]}
+	}
+	n := strings.IndexByte(s[1:], AsciiEscape) + 1
+	if n == 0 {
+		n = len(s)
+	}
+	return Token{Kind: TokenText, Value: s[:n]}
+}
+
+func sequenceKind(seq string) TokenKind {
+	if len(seq) < 2 {
+		return TokenEscape
+	}
+	switch seq[1] {
+	case '[':
+		return TokenCSI
+	case ']':
+		return TokenOSC
+	case 'P':
+		return TokenDCS
+	case '_':
+		return TokenAPC
+	case '^':
+		return TokenPM
+	case 'X':
+		return TokenSOS
+	default:
+		return TokenEscape
+	}
+}
+
+// NewSequenceWriter returns a writer that passes text through unchanged, but
+// replaces every escape sequence with the result of fn, which may return the
+// sequence itself to keep it, or an empty string to drop it. This allows
+// proxying the output of other programs, such as to rewrite hyperlinks or
+// ignore title changes. fn is never called with text tokens, and sequences are
+// always complete, even if split across writes; the writer must be flushed
+// with Flush once the output is complete.
+func NewSequenceWriter(w io.Writer, fn func(t Token) string) *TransformWriter {
+	return newTransformWriter(w, sequenceTransform(fn))
+}
+
+// NewSequenceReader is like NewSequenceWriter, but transforms the output read
+// from r.
+func NewSequenceReader(r io.Reader, fn func(t Token) string) io.Reader {
+	return newTransformReader(r, sequenceTransform(fn))
+}
+
+func sequenceTransform(fn func(t Token) string) func(s string) string {
+	return func(s string) string {
+		var b strings.Builder
+		for i := 0; i < len(s); {
+			t := nextToken(s[i:])
+			i += len(t.Value)
+			if t.Kind == TokenText {
+				b.WriteString(t.Value)
+			} else {
+				b.WriteString(fn(t))
+			}
+		}
+		return b.String()
+	}
+}
@@ -0,0 +1,84 @@
+package escapes
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Markers surrounding pasted text while bracketed paste is enabled
+const (
+	BracketedPasteStart = Esc + "200~"
+	BracketedPasteEnd   = Esc + "201~"
+)
+
+// PasteEvent is text pasted while bracketed paste is enabled, decoded by an
+// InputDecoder without the markers around it. The text is as the terminal
+// sent it, so it should be reviewed, such as with SanitizePaste, before it is
+// used. A paste whose end does not arrive within EscTimeout, as may happen
+// with large pastes over slow connections, is split into several events.
+type PasteEvent string
+
+// decodePaste decodes pasted text at the start of b, up to the end marker of
+// the paste. If final is true and the end marker is missing, the text received
+// so far is decoded, and the paste continues with the next input.
+func (d *InputDecoder) decodePaste(b []byte, final bool) (Event, int) {
+	end := []byte(BracketedPasteEnd)
+	if i := bytes.Index(b, end); i >= 0 {
+		d.pasting = false
+		return PasteEvent(b[:i]), i + len(end)
+	}
+	if !final {
+		return nil, 0
+	}
+
+	// Keep what may be the start of the end marker for the next input
+	n := len(b)
+	for k := len(end) - 1; k > 0; k-- {
+		if bytes.HasSuffix(b, end[:k]) && k < n {
+			n -= k
+			break
+		}
+	}
+	return PasteEvent(b[:n]), n
+}
+
+// SanitizePaste returns pasted data without what could make it act as more
+// than text: escape sequences, including bracketed paste markers embedded to
+// end the paste early and have the rest interpreted as typed keys, and
+// control characters other than newline and tab. Carriage returns, which
+// terminals send for line breaks, are converted to newlines.
+func SanitizePaste(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	s := string(b) // Sliced for sequenceEnd without copying b again
+	for i := 0; i < len(b); {
+		if n, _ := sequenceEnd(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+
+		c := b[i]
+		switch {
+		case c == '\r':
+			if i+1 < len(b) && b[i+1] == '\n' {
+				i++
+			}
+			out = append(out, '\n')
+			i++
+			continue
+		case c == '\n' || c == '\t':
+			out = append(out, c)
+			i++
+			continue
+		case c < 0x20 || c == AsciiDelete:
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b[i:])
+		if !(r >= 0x80 && r < 0xA0) && !(r == utf8.RuneError && size == 1 && c >= 0x80 && c < 0xA0) {
+			out = append(out, b[i:i+size]...)
+		}
+		i += size
+	}
+	return out
+}
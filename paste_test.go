@@ -0,0 +1,33 @@
+package escapes
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSanitizePaste(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"hello", "hello"},
+		{"a\r\nb\rc\n\td", "a\nb\nc\n\td"},
+		{"rm -rf ~\x1b[201~\r", "rm -rf ~\n"},
+		{"a\x1b]52;c;?\x07b", "ab"},
+		{"a\x03\x7fb\u0085c", "abc"},
+		{"日本", "日本"},
+	}
+	for _, tt := range tests {
+		if got := string(SanitizePaste([]byte(tt.in))); got != tt.want {
+			t.Errorf("SanitizePaste(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkSanitizePaste(b *testing.B) {
+	paste := bytes.Repeat([]byte("some pasted \x1b[1mtext\x1b[0m\r\n"), 4096)
+	b.SetBytes(int64(len(paste)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		SanitizePaste(paste)
+	}
+}
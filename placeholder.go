@@ -0,0 +1,105 @@
+package escapes
+
+import "strings"
+
+// KittyPlaceholder is the character of the cells showing a virtual placement
+// of an image with the kitty graphics protocol (see KittyPlacement.Virtual).
+// The foreground color of a cell selects the image, and combining characters
+// the part of the image the cell shows.
+const KittyPlaceholder rune = 0x10EEEE
+
+// SetKittyImage fills the area of cols by rows cells whose top-left corner is
+// (x, y) with Unicode placeholders showing the virtual placement of the image
+// with the given id, so that the image is drawn, moved and scrolled along
+// with the rest of the screen. Cells out of bounds are ignored.
+//
+// The image id is encoded in the foreground color: ids below 256 need the
+// 256-color profile, and others true colors. At most 297 rows and columns of
+// an image can be shown.
+func (s *Screen) SetKittyImage(x, y, id, cols, rows int) {
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			if s.inBounds(x+col, y+row) {
+				s.SetCell(x+col, y+row, kittyPlaceholderCell(id, row, col))
+			}
+		}
+	}
+}
+
+// KittyPlaceholders returns the Unicode placeholders that show the virtual
+// placement of the image with the given id, as cols by rows cells, as lines
+// separated by newlines and ending with the default style. See
+// Screen.SetKittyImage.
+func KittyPlaceholders(id, cols, rows int) string {
+	var b strings.Builder
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			b.WriteByte('\n')
+		}
+		style := kittyPlaceholderCell(id, row, 0).Style
+		b.WriteString(style.Sequence())
+		for col := 0; col < cols; col++ {
+			b.WriteRune(KittyPlaceholder)
+			b.WriteString(kittyPlaceholderCell(id, row, col).Marks)
+		}
+		b.WriteString(Transition(style, Style{}))
+	}
+	return b.String()
+}
+
+// kittyPlaceholderCell returns the placeholder cell showing the given row and
+// column of the image with the given id. The row, the column and the most
+// significant byte of the id, if any, are encoded as diacritics.
+func kittyPlaceholderCell(id, row, col int) Cell {
+	fg := IndexedColor(id)
+	if id >= 256 {
+		fg = RGB(uint8(id>>16), uint8(id>>8), uint8(id))
+	}
+	marks := string(kittyDiacritic(row)) + string(kittyDiacritic(col))
+	if high := id >> 24 & 0xFF; high != 0 {
+		marks += string(kittyDiacritic(high))
+	}
+	return Cell{Rune: KittyPlaceholder, Marks: marks, Style: Style{Fg: fg}}
+}
+
+// kittyDiacritic returns the combining character encoding the number n in
+// Unicode placeholders, or the last one if n is too large.
+func kittyDiacritic(n int) rune {
+	var last rune
+	for _, r := range kittyDiacritics {
+		if size := int(r.hi-r.lo) + 1; n >= size {
+			n -= size
+			last = r.hi
+			continue
+		}
+		return r.lo + rune(max(n, 0))
+	}
+	return last
+}
+
+// kittyDiacritics are the combining characters encoding the numbers 0 to 296
+// in Unicode placeholders, in order.
+var kittyDiacritics = []runeRange{
+	{0x0305, 0x0305}, {0x030D, 0x030E}, {0x0310, 0x0310}, {0x0312, 0x0312},
+	{0x033D, 0x033F}, {0x0346, 0x0346}, {0x034A, 0x034C}, {0x0350, 0x0352},
+	{0x0357, 0x0357}, {0x035B, 0x035B}, {0x0363, 0x036F}, {0x0483, 0x0487},
+	{0x0592, 0x0595}, {0x0597, 0x0599}, {0x059C, 0x05A1}, {0x05A8, 0x05A9},
+	{0x05AB, 0x05AC}, {0x05AF, 0x05AF}, {0x05C4, 0x05C4}, {0x0610, 0x0617},
+	{0x0657, 0x065B}, {0x065D, 0x065E}, {0x06D6, 0x06DC}, {0x06DF, 0x06E2},
+	{0x06E4, 0x06E4}, {0x06E7, 0x06E8}, {0x06EB, 0x06EC}, {0x0730, 0x0730},
+	{0x0732, 0x0733}, {0x0735, 0x0736}, {0x073A, 0x073A}, {0x073D, 0x073D},
+	{0x073F, 0x0741}, {0x0743, 0x0743}, {0x0745, 0x0745}, {0x0747, 0x0747},
+	{0x0749, 0x074A}, {0x07EB, 0x07F1}, {0x07F3, 0x07F3}, {0x0816, 0x0819},
+	{0x081B, 0x0823}, {0x0825, 0x0827}, {0x0829, 0x082D}, {0x0951, 0x0951},
+	{0x0953, 0x0954}, {0x0F82, 0x0F83}, {0x0F86, 0x0F87}, {0x135D, 0x135F},
+	{0x17DD, 0x17DD}, {0x193A, 0x193A}, {0x1A17, 0x1A17}, {0x1A75, 0x1A7C},
+	{0x1B6B, 0x1B6B}, {0x1B6D, 0x1B73}, {0x1CD0, 0x1CD2}, {0x1CDA, 0x1CDB},
+	{0x1CE0, 0x1CE0}, {0x1DC0, 0x1DC1}, {0x1DC3, 0x1DC9}, {0x1DCB, 0x1DCC},
+	{0x1DD1, 0x1DE6}, {0x1DFE, 0x1DFE}, {0x20D0, 0x20D1}, {0x20D4, 0x20D7},
+	{0x20DB, 0x20DC}, {0x20E1, 0x20E1}, {0x20E7, 0x20E7}, {0x20E9, 0x20E9},
+	{0x20F0, 0x20F0}, {0x2CEF, 0x2CF1}, {0x2DE0, 0x2DFF}, {0xA66F, 0xA66F},
+	{0xA67C, 0xA67D}, {0xA6F0, 0xA6F1}, {0xA8E0, 0xA8F1}, {0xAAB0, 0xAAB0},
+	{0xAAB2, 0xAAB3}, {0xAAB7, 0xAAB8}, {0xAABE, 0xAABF}, {0xAAC1, 0xAAC1},
+	{0xFE20, 0xFE26}, {0x10A0F, 0x10A0F}, {0x10A38, 0x10A38}, {0x1D185, 0x1D189},
+	{0x1D1AA, 0x1D1AD}, {0x1D242, 0x1D244},
+}
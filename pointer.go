@@ -0,0 +1,29 @@
+package escapes
+
+// Common mouse pointer shapes, named after the CSS cursor values. Terminals may
+// also accept X11 cursor font names, such as "hand2" or "xterm".
+const (
+	PointerDefault    = "default"
+	PointerText       = "text"
+	PointerHand       = "pointer"
+	PointerCrosshair  = "crosshair"
+	PointerMove       = "move"
+	PointerWait       = "wait"
+	PointerHelp       = "help"
+	PointerNotAllowed = "not-allowed"
+	PointerColResize  = "col-resize"
+	PointerRowResize  = "row-resize"
+)
+
+// SetPointerShape returns an escape sequence to change the shape of the mouse
+// pointer while it is over the terminal, such as PointerHand over a link.
+// Supported by xterm, kitty, foot and WezTerm.
+func SetPointerShape(name string) string {
+	return Osc + "22;" + name + oscEnd()
+}
+
+// ResetPointerShape returns an escape sequence to restore the default shape
+// of the mouse pointer.
+func ResetPointerShape() string {
+	return SetPointerShape("")
+}
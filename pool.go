@@ -0,0 +1,38 @@
+package escapes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"sync"
+)
+
+// maxPooledBuffer is the capacity above which buffers are not returned to the
+// pool, so that a single large image does not keep its memory in use.
+const maxPooledBuffer = 1 << 20
+
+// bufferPool holds the buffers in which frames, styled text and images are
+// built, so that rendering repeatedly produces little garbage.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns b to the pool. It must not be used afterwards, nor the
+// slices returned by its Bytes method.
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// writeBase64 writes data to b encoded in standard base64.
+func writeBase64(b *bytes.Buffer, data []byte) {
+	b.Grow(base64.StdEncoding.EncodedLen(len(data)))
+	b.Write(base64.StdEncoding.AppendEncode(b.AvailableBuffer(), data))
+}
@@ -0,0 +1,228 @@
+package escapes
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ModeStatus is the status of a terminal mode, as reported by DECRQM.
+type ModeStatus int
+
+// Mode statuses, as defined by DECRPM
+const (
+	ModeNotRecognized ModeStatus = iota
+	ModeSet
+	ModeReset
+	ModePermanentlySet
+	ModePermanentlyReset
+)
+
+// ProbeModes are the private modes whose status Probe requests.
+var ProbeModes = []int{1004, 1006, 1016, 2004, 2026}
+
+// Capabilities describes the features a terminal reported supporting.
+type Capabilities struct {
+	// Responded reports whether the terminal replied at all. If it is false,
+	// the output is most likely not a terminal.
+	Responded bool
+
+	// DeviceAttributes are the parameters of the primary device attributes
+	// (DA1) reply. The first one is the conformance level.
+	DeviceAttributes []int
+
+	// Version is the name and version of the terminal, as reported by
+	// XTVERSION, such as "kitty(0.31.0)".
+	Version string
+
+	// Modes are the statuses of the modes that were requested.
+	Modes map[int]ModeStatus
+
+	// Background is the background color of the terminal, or nil if the
+	// terminal did not report it.
+	Background color.Color
+}
+
+// SupportsMode reports whether the terminal recognizes a private mode.
+func (c *Capabilities) SupportsMode(mode int) bool {
+	s := c.Modes[mode]
+	return s == ModeSet || s == ModeReset || s == ModePermanentlySet
+}
+
+// SupportsSixel reports whether the terminal advertised sixel graphics, which
+// is feature 4 of the primary device attributes.
+func (c *Capabilities) SupportsSixel() bool {
+	return c.hasDeviceAttribute(4)
+}
+
+func (c *Capabilities) hasDeviceAttribute(feature int) bool {
+	if len(c.DeviceAttributes) < 2 {
+		return false
+	}
+	for _, a := range c.DeviceAttributes[1:] {
+		if a == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Probe sends a batch of queries to the terminal and collects the replies in a
+// single round trip: primary device attributes, XTVERSION, the status of each
+// mode in ProbeModes, and the background color. The batch ends with a device
+// status report, which every terminal answers after the other replies, so
+// that Probe does not need to wait for the timeout when queries go
+// unanswered. Only the replies received before the timeout are used; a
+// terminal that does not reply at all yields an empty Capabilities and
+// ErrQueryTimeout.
+//
+// Like Query, Probe requires the terminal to be in raw mode.
+func Probe(w io.Writer, r io.Reader, timeout time.Duration) (*Capabilities, error) {
+	var req strings.Builder
+	req.WriteString(Esc + "c")
+	req.WriteString(Esc + ">0q")
+	for _, mode := range ProbeModes {
+		req.WriteString(Esc + "?" + strconv.Itoa(mode) + "$p")
+	}
+	req.WriteString(Osc + "11;?" + oscEnd())
+	req.WriteString(Esc + "5n")
+
+	reply, err := Query(w, r, req.String(), func(b []byte) bool {
+		return bytes.Contains(b, []byte(Esc+"0n"))
+	}, timeout)
+
+	caps := parseCapabilities(string(reply))
+	if err == ErrQueryTimeout && caps.Responded {
+		err = nil
+	}
+	return caps, err
+}
+
+// parseCapabilities collects the replies to the queries sent by Probe.
+func parseCapabilities(reply string) *Capabilities {
+	caps := &Capabilities{Modes: make(map[int]ModeStatus)}
+	for i := 0; i < len(reply); {
+		n := sequenceLen(reply[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := reply[i : i+n]
+		i += n
+
+		switch {
+		case strings.HasPrefix(seq, Esc):
+			prefix, params, final := parseCSI(seq)
+			switch {
+			case prefix == "?" && final == "c":
+				caps.DeviceAttributes = params
+				caps.Responded = true
+			case prefix == "?" && final == "$y" && len(params) == 2:
+				caps.Modes[params[0]] = ModeStatus(params[1])
+				caps.Responded = true
+			case prefix == "" && final == "n":
+				caps.Responded = true
+			}
+		case strings.HasPrefix(seq, Dcs+">|"):
+			caps.Version = strings.TrimSuffix(seq[4:], St)
+			caps.Responded = true
+		case strings.HasPrefix(seq, Osc+"11;"):
+			body := strings.TrimSuffix(strings.TrimSuffix(seq[5:], Bel), St)
+			if c, err := ParseXColor(body); err == nil {
+				caps.Background = c
+			}
+			caps.Responded = true
+		}
+	}
+	return caps
+}
+
+// parseCSI splits a control sequence into its private prefix (such as "?" or
+// ">"), its numeric parameters, and its intermediate and final bytes. Missing
+// parameters are 0.
+func parseCSI(seq string) (prefix string, params []int, final string) {
+	body := strings.TrimPrefix(seq, Esc)
+	i := 0
+	for i < len(body) && body[i] >= '<' && body[i] <= '?' {
+		i++
+	}
+	prefix, body = body[:i], body[i:]
+
+	i = 0
+	for i < len(body) && ((body[i] >= '0' && body[i] <= '9') || body[i] == ';' || body[i] == ':') {
+		i++
+	}
+	if i > 0 {
+		for _, p := range strings.Split(body[:i], ";") {
+			n, _ := strconv.Atoi(p)
+			params = append(params, n)
+		}
+	}
+	return prefix, params, body[i:]
+}
+
+// ErrInvalidColor is returned when a color cannot be parsed.
+var ErrInvalidColor = errors.New("escapes: invalid color")
+
+// ParseXColor parses a color in one of the formats of XParseColor, used by
+// the replies to color queries such as OSC 10, 11 and 4:
+//
+//   - rgb:R/G/B, where each component has 1 to 4 hex digits and is scaled to
+//     the full range, such as rgb:1e1e/2e2e/3e3e.
+//   - rgba:R/G/B/A, as replied by some terminals such as urxvt, in the same
+//     way.
+//   - #RGB, #RRGGBB, #RRRGGGBBB or #RRRRGGGGBBBB, where the digits are the
+//     most significant bits of each component, so #f00 is #f00000.
+func ParseXColor(s string) (color.Color, error) {
+	var (
+		parts []string
+		hash  bool
+	)
+	switch {
+	case strings.HasPrefix(s, "rgb:"):
+		parts = strings.Split(s[4:], "/")
+		if len(parts) != 3 {
+			return nil, ErrInvalidColor
+		}
+	case strings.HasPrefix(s, "rgba:"):
+		parts = strings.Split(s[5:], "/")
+		if len(parts) != 4 {
+			return nil, ErrInvalidColor
+		}
+	case strings.HasPrefix(s, "#") && len(s) > 1 && (len(s)-1)%3 == 0 && len(s)-1 <= 12:
+		n := (len(s) - 1) / 3
+		parts = []string{s[1 : 1+n], s[1+n : 1+2*n], s[1+2*n:]}
+		hash = true
+	}
+	if parts == nil {
+		return nil, ErrInvalidColor
+	}
+
+	c := [4]uint8{3: 0xFF}
+	for i, p := range parts {
+		if len(p) < 1 || len(p) > 4 {
+			return nil, ErrInvalidColor
+		}
+		v, err := strconv.ParseUint(p, 16, 16)
+		if err != nil {
+			return nil, ErrInvalidColor
+		}
+		if hash {
+			// The digits are the most significant bits
+			c[i] = uint8(v << (16 - 4*uint(len(p))) >> 8)
+			continue
+		}
+		// Scale the component to 8 bits
+		max := uint64(1)<<(4*uint(len(p))) - 1
+		c[i] = uint8((v*255 + max/2) / max)
+	}
+	for i := 0; i < 3; i++ {
+		// color.RGBA is alpha-premultiplied
+		c[i] = uint8(uint(c[i]) * uint(c[3]) / 0xFF)
+	}
+	return color.RGBA{R: c[0], G: c[1], B: c[2], A: c[3]}, nil
+}
@@ -0,0 +1,87 @@
+package escapes
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestParseCSI(t *testing.T) {
+	tests := []struct {
+		seq    string
+		prefix string
+		params []int
+		final  string
+	}{
+		{"\x1b[?62;4;22c", "?", []int{62, 4, 22}, "c"},
+		{"\x1b[?2004;1$y", "?", []int{2004, 1}, "$y"},
+		{"\x1b[;5H", "", []int{0, 5}, "H"},
+		{"\x1b[0n", "", []int{0}, "n"},
+		{"\x1b[>c", ">", nil, "c"},
+	}
+	for _, tt := range tests {
+		prefix, params, final := parseCSI(tt.seq)
+		if prefix != tt.prefix || !reflect.DeepEqual(params, tt.params) || final != tt.final {
+			t.Errorf("parseCSI(%q) = %q, %v, %q, want %q, %v, %q", tt.seq, prefix, params, final, tt.prefix, tt.params, tt.final)
+		}
+	}
+}
+
+func TestParseCapabilities(t *testing.T) {
+	caps := parseCapabilities("\x1b[?62;4;22c\x1bP>|xterm(390)\x1b\\\x1b[?2004;2$y\x1b]11;rgb:1e1e/2e2e/3e3e\x1b\\\x1b[0n")
+	want := &Capabilities{
+		Responded:        true,
+		DeviceAttributes: []int{62, 4, 22},
+		Version:          "xterm(390)",
+		Modes:            map[int]ModeStatus{2004: 2},
+		Background:       color.RGBA{0x1E, 0x2E, 0x3E, 0xFF},
+	}
+	if !reflect.DeepEqual(caps, want) {
+		t.Errorf("parseCapabilities = %+v, want %+v", caps, want)
+	}
+	if !caps.hasDeviceAttribute(4) || caps.hasDeviceAttribute(62) {
+		t.Errorf("hasDeviceAttribute: want 4 but not the level 62")
+	}
+
+	if caps := parseCapabilities(""); caps.Responded {
+		t.Errorf("parseCapabilities(\"\").Responded = true")
+	}
+}
+
+func TestParseXColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.Color
+	}{
+		{"rgb:ffff/0000/8080", color.RGBA{0xFF, 0x00, 0x80, 0xFF}},
+		{"rgb:f/0/8", color.RGBA{0xFF, 0x00, 0x88, 0xFF}},
+		{"rgb:1e/2e/3e", color.RGBA{0x1E, 0x2E, 0x3E, 0xFF}},
+		{"rgba:ffff/ffff/ffff/8080", color.RGBA{0x80, 0x80, 0x80, 0x80}},
+		{"#f00", color.RGBA{0xF0, 0x00, 0x00, 0xFF}},
+		{"#1e2e3e", color.RGBA{0x1E, 0x2E, 0x3E, 0xFF}},
+		{"#1e1f2e2f3e3f", color.RGBA{0x1E, 0x2E, 0x3E, 0xFF}},
+	}
+	for _, tt := range tests {
+		got, err := ParseXColor(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseXColor(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"rgb:ff/00",
+		"rgb:ff/00/80/80",
+		"rgba:ff/00/80",
+		"rgb:ff/00/8g",
+		"rgb:fffff/0/0",
+		"rgb:/0/0",
+		"#ff",
+		"#ff00ff00ff00ff",
+		"red",
+	} {
+		if got, err := ParseXColor(in); err != ErrInvalidColor {
+			t.Errorf("ParseXColor(%q) = %v, %v, want %v", in, got, err, ErrInvalidColor)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package escapes
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Prompt asks questions interactively, drawing in place below the cursor and
+// reading keys from an InputDecoder. Answered questions are left on screen as
+// a single line with the answer. The terminal must be in raw mode, and options
+// should fit on a line.
+type Prompt struct {
+	// Question is the style of questions.
+	Question Style
+
+	// Answer is the style of answers once given.
+	Answer Style
+
+	// Highlight is the style of the option under the cursor in lists.
+	Highlight Style
+
+	// Cursor marks the option under the cursor in lists, and is replaced by
+	// spaces for the others.
+	Cursor string
+
+	// PageSize is the number of options of lists shown at once. Longer lists
+	// are scrolled as the cursor moves.
+	PageSize int
+
+	w io.Writer
+	d *InputDecoder
+}
+
+// NewPrompt returns a prompt writing to w and reading input from d, with the
+// default styles.
+func NewPrompt(w io.Writer, d *InputDecoder) *Prompt {
+	return &Prompt{
+		Question:  Style{Attrs: AttrBold},
+		Answer:    Style{Fg: ANSIColor(6)},
+		Highlight: Style{Fg: ANSIColor(6), Attrs: AttrBold},
+		Cursor:    "> ",
+		PageSize:  10,
+		w:         w,
+		d:         d,
+	}
+}
+
+// Confirm asks a yes or no question, answered with y or n, or Enter for the
+// default answer def.
+func (p *Prompt) Confirm(question string, def bool) (bool, error) {
+	hint := " [y/N] "
+	if def {
+		hint = " [Y/n] "
+	}
+	block := liveBlock{w: p.w}
+	if err := block.draw(p.Question.Render(question) + hint); err != nil {
+		return false, err
+	}
+
+	for {
+		k, err := p.readKey()
+		if err != nil {
+			block.draw("")
+			return false, err
+		}
+		answer := def
+		switch {
+		case k.Key == KeyEnter:
+		case k.Key == KeyRune && unicode.ToLower(k.Rune) == 'y':
+			answer = true
+		case k.Key == KeyRune && unicode.ToLower(k.Rune) == 'n':
+			answer = false
+		default:
+			continue
+		}
+
+		text := "no"
+		if answer {
+			text = "yes"
+		}
+		return answer, p.answer(&block, question, text)
+	}
+}
+
+// Select asks to choose one of options, moving the cursor with the arrow keys
+// (or Ctrl+P and Ctrl+N, or k and j) and confirming with Enter, and returns
+// its index. The cursor starts at index def.
+func (p *Prompt) Select(question string, options []string, def int) (int, error) {
+	cursor := clampInt(def, 0, len(options)-1)
+	block := liveBlock{w: p.w}
+	for {
+		lines := []string{p.Question.Render(question)}
+		start, end := p.page(cursor, len(options))
+		for i := start; i < end; i++ {
+			lines = append(lines, p.option(options[i], i == cursor))
+		}
+		if err := block.draw(lines...); err != nil {
+			return -1, err
+		}
+
+		k, err := p.readKey()
+		if err != nil {
+			block.draw("")
+			return -1, err
+		}
+		switch k.String() {
+		case "up", "ctrl+p", "k":
+			if cursor > 0 {
+				cursor--
+			}
+		case "down", "ctrl+n", "j":
+			if cursor < len(options)-1 {
+				cursor++
+			}
+		case "pgup":
+			cursor = max(cursor-p.pageSize(), 0)
+		case "pgdown":
+			cursor = min(cursor+p.pageSize(), len(options)-1)
+		case "home":
+			cursor = 0
+		case "end":
+			cursor = len(options) - 1
+		case "enter":
+			if len(options) > 0 {
+				return cursor, p.answer(&block, question, options[cursor])
+			}
+		}
+	}
+}
+
+// MultiSelect asks to choose any number of options, moving the cursor with the
+// arrow keys, toggling the option under it with Space and confirming with
+// Enter, and returns the indices of the chosen options, in order. Typing
+// filters the options to those containing the text typed, ignoring case, and
+// Ctrl+A toggles all of those shown. The options at the indices in def are
+// initially chosen.
+func (p *Prompt) MultiSelect(question string, options []string, def []int) ([]int, error) {
+	chosen := make([]bool, len(options))
+	for _, i := range def {
+		if i >= 0 && i < len(options) {
+			chosen[i] = true
+		}
+	}
+
+	var (
+		filter  []rune
+		cursor  int
+		block   = liveBlock{w: p.w}
+		visible []int // Indices of the options matching the filter
+	)
+	for {
+		visible = visible[:0]
+		for i, option := range options {
+			if strings.Contains(strings.ToLower(Strip(option)), strings.ToLower(string(filter))) {
+				visible = append(visible, i)
+			}
+		}
+		cursor = clampInt(cursor, 0, max(len(visible)-1, 0))
+
+		header := p.Question.Render(question)
+		if len(filter) > 0 {
+			header += " " + p.Answer.Render(string(filter))
+		}
+		lines := []string{header}
+		start, end := p.page(cursor, len(visible))
+		for j := start; j < end; j++ {
+			box := "[ ] "
+			if chosen[visible[j]] {
+				box = "[x] "
+			}
+			lines = append(lines, p.option(box+options[visible[j]], j == cursor))
+		}
+		if len(visible) > end-start {
+			lines = append(lines, strings.Repeat(" ", StringWidth(p.Cursor))+
+				"("+strconv.Itoa(cursor+1)+"/"+strconv.Itoa(len(visible))+")")
+		}
+		if err := block.draw(lines...); err != nil {
+			return nil, err
+		}
+
+		k, err := p.readKey()
+		if err != nil {
+			block.draw("")
+			return nil, err
+		}
+		switch k.String() {
+		case "up", "ctrl+p":
+			cursor--
+		case "down", "ctrl+n":
+			cursor++
+		case "pgup":
+			cursor -= p.pageSize()
+		case "pgdown":
+			cursor += p.pageSize()
+		case "home":
+			cursor = 0
+		case "end":
+			cursor = len(visible) - 1
+		case "space":
+			if len(visible) > 0 {
+				chosen[visible[cursor]] = !chosen[visible[cursor]]
+			}
+		case "ctrl+a":
+			all := true
+			for _, i := range visible {
+				all = all && chosen[i]
+			}
+			for _, i := range visible {
+				chosen[i] = !all
+			}
+		case "backspace":
+			if len(filter) > 0 {
+				filter = filter[:len(filter)-1]
+			}
+		case "enter":
+			var (
+				result []int
+				names  []string
+			)
+			for i, c := range chosen {
+				if c {
+					result = append(result, i)
+					names = append(names, options[i])
+				}
+			}
+			return result, p.answer(&block, question, strings.Join(names, ", "))
+		default:
+			if k.Key == KeyRune && k.Mod == 0 && unicode.IsPrint(k.Rune) {
+				filter = append(filter, k.Rune)
+				cursor = 0
+			}
+		}
+	}
+}
+
+// page returns the range of the options of a list of n shown with the cursor
+// at index cursor, keeping the cursor near the middle.
+func (p *Prompt) page(cursor, n int) (start, end int) {
+	size := p.pageSize()
+	if n <= size {
+		return 0, n
+	}
+	start = clampInt(cursor-size/2, 0, n-size)
+	return start, start + size
+}
+
+func (p *Prompt) pageSize() int {
+	if p.PageSize <= 0 {
+		return 10
+	}
+	return p.PageSize
+}
+
+// Input asks for a line of text, edited with a LineEditor. If the answer is
+// empty, def is returned instead.
+func (p *Prompt) Input(question, def string) (string, error) {
+	e := NewLineEditor(p.w, p.d)
+	e.Prompt = p.Question.Render(question) + " "
+	if def != "" {
+		e.Prompt = p.Question.Render(question) + " (" + def + ") "
+	}
+	line, err := e.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	if line == "" {
+		line = def
+	}
+
+	// Replace the line left by the editor with the answer
+	block := liveBlock{w: p.w}
+	if _, err := io.WriteString(p.w, CursorUp); err != nil {
+		return "", err
+	}
+	return line, p.answer(&block, question, line)
+}
+
+// option returns the line of an option in a list.
+func (p *Prompt) option(text string, selected bool) string {
+	if selected {
+		return p.Highlight.Render(p.Cursor + text)
+	}
+	return strings.Repeat(" ", StringWidth(p.Cursor)) + text
+}
+
+// answer replaces the block with the question and its answer.
+func (p *Prompt) answer(block *liveBlock, question, answer string) error {
+	if err := block.draw(p.Question.Render(question) + " " + p.Answer.Render(answer)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(p.w, "\r\n")
+	return err
+}
+
+// readKey returns the next key pressed, or ErrInterrupted for Ctrl+C.
+func (p *Prompt) readKey() (KeyEvent, error) {
+	for {
+		ev, err := p.d.ReadEvent()
+		if err != nil {
+			return KeyEvent{}, err
+		}
+		k, ok := ev.(KeyEvent)
+		if !ok || k.Action == KeyRelease {
+			continue
+		}
+		if k.String() == "ctrl+c" {
+			return k, ErrInterrupted
+		}
+		return k, nil
+	}
+}
+
+// liveBlock is a block of lines redrawn in place, starting at the row the
+// cursor is on when first drawn. Only the lines that changed are rewritten,
+// and the cursor is left at the end of the last line. Lines must fit in the
+// terminal.
+type liveBlock struct {
+	w     io.Writer
+	lines []string
+}
+
+// draw replaces the lines of the block, erasing those no longer needed.
+func (b *liveBlock) draw(lines ...string) error {
+	s := b.update(lines...)
+	if s == "" {
+		return nil
+	}
+	_, err := io.WriteString(b.w, s)
+	return err
+}
+
+// update replaces the lines of the block, and returns the sequences that
+// redraw it.
+func (b *liveBlock) update(lines ...string) string {
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+
+	var s strings.Builder
+	row := 0 // Row of the cursor in the block
+	if len(b.lines) > 0 {
+		row = len(b.lines) - 1
+	}
+	written := false
+	for i, line := range lines {
+		if i < len(b.lines) && b.lines[i] == line {
+			continue
+		}
+		if i < len(b.lines) || i == 0 {
+			s.WriteString(CursorMove(0, i-row))
+		} else {
+			// Add a line below the block
+			s.WriteString(CursorMove(0, i-1-row) + "\r\n")
+		}
+		s.WriteString("\r" + line + EraseRight)
+		row, written = i, true
+	}
+	if len(lines) < len(b.lines) {
+		s.WriteString(CursorMove(0, len(lines)-row) + "\r" + EraseDown)
+		row, written = len(lines), true
+	}
+	if last := len(lines) - 1; row != last {
+		s.WriteString(CursorMove(0, last-row) + CursorPosX(StringWidth(lines[last])))
+	}
+
+	b.lines = lines
+	if !written {
+		return ""
+	}
+	return s.String()
+}
+
+// erase returns the sequences that erase the block, leaving the cursor where
+// it started, and forgets its lines so that it is drawn anew from there.
+func (b *liveBlock) erase() string {
+	if len(b.lines) == 0 {
+		return ""
+	}
+	s := "\r" + CursorMove(0, 1-len(b.lines)) + EraseDown
+	b.lines = nil
+	return s
+}
@@ -0,0 +1,98 @@
+package escapes
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrQueryTimeout is returned when a terminal does not reply to a query in
+// time, as is the case when the output is not a terminal, or when the terminal
+// does not support the query.
+var ErrQueryTimeout = errors.New("escapes: timed out waiting for the terminal to reply")
+
+// DefaultQueryTimeout is a reasonable timeout for queries to local terminals
+// and most remote sessions.
+const DefaultQueryTimeout = 200 * time.Millisecond
+
+// Query writes a request to the terminal through w, then reads the reply from
+// r until match reports that the bytes read so far contain a complete reply.
+// The bytes read are returned. If no complete reply is read within timeout,
+// ErrQueryTimeout is returned along with the bytes read so far.
+//
+// The terminal should be in raw mode, so that the reply is not echoed and is
+// available without waiting for a newline. If r supports read deadlines, such
+// as a non-blocking *os.File, they are used to stop reading; otherwise, a read
+// may still be pending after the timeout, and consume the next input.
+//
+// If interactive sequences are disabled, ErrNotInteractive is returned without
+// sending the request.
+func Query(w io.Writer, r io.Reader, request string, match func([]byte) bool, timeout time.Duration) ([]byte, error) {
+	if !Interactive() {
+		return nil, ErrNotInteractive
+	}
+	if _, err := io.WriteString(w, request); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := r.(interface{ SetReadDeadline(time.Time) error }); ok && d.SetReadDeadline(deadline) == nil {
+		defer d.SetReadDeadline(time.Time{})
+		return readUntil(r, match, nil)
+	}
+	return readUntil(r, match, time.NewTimer(timeout))
+}
+
+type readResult struct {
+	p   []byte
+	err error
+}
+
+// readUntil reads from r until match returns true. If timer is not nil, reads
+// are done in a separate goroutine, and abandoned when the timer fires;
+// otherwise, r is expected to return an error after its deadline.
+func readUntil(r io.Reader, match func([]byte) bool, timer *time.Timer) ([]byte, error) {
+	if timer != nil {
+		defer timer.Stop()
+	}
+
+	var buf []byte
+	p := make([]byte, 256)
+	for {
+		var (
+			n   int
+			err error
+		)
+		if timer == nil {
+			n, err = r.Read(p)
+			if isTimeout(err) {
+				return buf, ErrQueryTimeout
+			}
+		} else {
+			ch := make(chan readResult, 1)
+			go func(p []byte) {
+				n, err := r.Read(p)
+				ch <- readResult{p[:n], err}
+			}(make([]byte, len(p)))
+			select {
+			case res := <-ch:
+				n, err = copy(p, res.p), res.err
+			case <-timer.C:
+				return buf, ErrQueryTimeout
+			}
+		}
+
+		buf = append(buf, p[:n]...)
+		if match(buf) {
+			return buf, nil
+		}
+		if err != nil {
+			return buf, err
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	t, ok := err.(interface{ Timeout() bool })
+	return ok && t.Timeout()
+}
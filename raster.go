@@ -0,0 +1,242 @@
+package escapes
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Dither is a dithering method used when reducing the colors of an image.
+type Dither int
+
+// Dithering methods
+const (
+	// DitherNone maps every pixel to the closest color, which produces bands
+	// in gradients.
+	DitherNone Dither = iota
+
+	// DitherFloydSteinberg diffuses the error of every pixel to its
+	// neighbors, which suits photos best.
+	DitherFloydSteinberg
+
+	// DitherOrdered offsets every pixel by a fixed pattern, which is faster
+	// and keeps animations from flickering.
+	DitherOrdered
+)
+
+// RasterOptions controls how images are converted by Sixel and HalfBlocks.
+type RasterOptions struct {
+	// Width is the width of the output in pixels for Sixel, or in columns for
+	// HalfBlocks. The height is scaled to preserve the aspect ratio. If it is
+	// 0, the image is not scaled.
+	Width int
+
+	// Colors is the size of the palette the image is reduced to. For Sixel,
+	// it defaults to and may not exceed 256. For HalfBlocks, up to 16 uses the
+	// basic colors, up to 256 the 256-color palette, and 0 the palette of
+	// CurrentProfile.
+	Colors int
+
+	// Dither is the dithering method used to reduce the colors.
+	Dither Dither
+}
+
+// raster is an image scaled for output, as non-premultiplied RGBA pixels.
+type raster struct {
+	width, height int
+	pix           []color.NRGBA
+}
+
+func (r *raster) at(x, y int) color.NRGBA {
+	return r.pix[y*r.width+x]
+}
+
+// newRaster scales img to the given width, preserving its aspect ratio, with
+// the height multiplied by yScale. Pixels are averaged when downscaling.
+func newRaster(img image.Image, width int, yScale float64) *raster {
+	b := img.Bounds()
+	if b.Empty() {
+		return &raster{}
+	}
+	if width <= 0 {
+		width = b.Dx()
+	}
+	height := int(float64(b.Dy())*float64(width)/float64(b.Dx())*yScale + 0.5)
+	if height < 1 {
+		height = 1
+	}
+
+	r := &raster{width: width, height: height, pix: make([]color.NRGBA, width*height)}
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := b.Min.Y + (y+1)*b.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := b.Min.X + (x+1)*b.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			// The sums overflow 32 bits beyond 65537 pixels per cell
+			var sr, sg, sb, sa, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					sr, sg, sb, sa, n = sr+uint64(cr), sg+uint64(cg), sb+uint64(cb), sa+uint64(ca), n+1
+				}
+			}
+			c := color.RGBA64{uint16(sr / n), uint16(sg / n), uint16(sb / n), uint16(sa / n)}
+			r.pix[y*width+x] = color.NRGBAModel.Convert(c).(color.NRGBA)
+		}
+	}
+	return r
+}
+
+// bayer4 is the threshold map of 4x4 ordered dithering.
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// quantize maps the pixels of r to the closest colors of palette, using the
+// given dithering method. Pixels that are mostly transparent are mapped to -1.
+// The closest color is found by nearest if not nil, or by nearestRGB.
+func quantize(r *raster, palette [][3]uint8, nearest func(c [3]float64) int, dither Dither) []int {
+	out := make([]int, len(r.pix))
+	if len(palette) == 0 {
+		return out
+	}
+
+	// Ordered dithering spreads pixels over the distance between palette
+	// colors, estimated from the palette's size
+	spread := 256 / math.Cbrt(float64(len(palette)))
+
+	// Floyd-Steinberg error of the current and next rows
+	cur := make([][3]float64, r.width+2)
+	next := make([][3]float64, r.width+2)
+
+	for y := 0; y < r.height; y++ {
+		for x := 0; x < r.width; x++ {
+			p := r.at(x, y)
+			if p.A < 128 {
+				out[y*r.width+x] = -1
+				continue
+			}
+
+			c := [3]float64{float64(p.R), float64(p.G), float64(p.B)}
+			switch dither {
+			case DitherFloydSteinberg:
+				for i := range c {
+					c[i] += cur[x+1][i]
+				}
+			case DitherOrdered:
+				d := (bayer4[y%4][x%4]/16 - 0.5) * spread
+				for i := range c {
+					c[i] += d
+				}
+			}
+
+			var best int
+			if nearest != nil {
+				best = nearest(c)
+			} else {
+				best = nearestRGB(palette, c)
+			}
+			out[y*r.width+x] = best
+			if dither != DitherFloydSteinberg {
+				continue
+			}
+			for i := range c {
+				e := c[i] - float64(palette[best][i])
+				cur[x+2][i] += e * 7 / 16
+				next[x][i] += e * 3 / 16
+				next[x+1][i] += e * 5 / 16
+				next[x+2][i] += e * 1 / 16
+			}
+		}
+		cur, next = next, cur
+		for i := range next {
+			next[i] = [3]float64{}
+		}
+	}
+	return out
+}
+
+// nearestRGB returns the index of the palette color closest to c.
+func nearestRGB(palette [][3]uint8, c [3]float64) int {
+	best, bestDist := 0, -1.0
+	for i, p := range palette {
+		dr, dg, db := c[0]-float64(p[0]), c[1]-float64(p[1]), c[2]-float64(p[2])
+		if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// medianCut returns a palette of at most n colors representing the opaque
+// pixels of r, by repeatedly splitting the group of pixels with the widest
+// range of a channel at its median.
+func medianCut(r *raster, n int) [][3]uint8 {
+	var pixels [][3]uint8
+	for _, p := range r.pix {
+		if p.A >= 128 {
+			pixels = append(pixels, [3]uint8{p.R, p.G, p.B})
+		}
+	}
+	if len(pixels) == 0 {
+		return nil
+	}
+
+	boxes := [][][3]uint8{pixels}
+	for len(boxes) < n {
+		// Split the box with the widest range
+		split, channel, widest := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				lo, hi := box[0][c], box[0][c]
+				for _, p := range box {
+					if p[c] < lo {
+						lo = p[c]
+					}
+					if p[c] > hi {
+						hi = p[c]
+					}
+				}
+				if int(hi-lo) > widest {
+					split, channel, widest = i, c, int(hi-lo)
+				}
+			}
+		}
+		if split < 0 {
+			break
+		}
+
+		box := boxes[split]
+		sort.Slice(box, func(i, j int) bool { return box[i][channel] < box[j][channel] })
+		mid := len(box) / 2
+		boxes[split] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	palette := make([][3]uint8, len(boxes))
+	for i, box := range boxes {
+		var sum [3]int
+		for _, p := range box {
+			sum[0], sum[1], sum[2] = sum[0]+int(p[0]), sum[1]+int(p[1]), sum[2]+int(p[2])
+		}
+		for c := range sum {
+			palette[i][c] = uint8(sum[c] / len(box))
+		}
+	}
+	return palette
+}
@@ -0,0 +1,37 @@
+package escapes
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestNewRaster(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{0xFF, 0, 0, 0xFF})
+	img.Set(1, 0, color.NRGBA{0, 0, 0xFF, 0xFF})
+	img.Set(2, 0, color.NRGBA{0, 0xFF, 0, 0xFF})
+	img.Set(3, 0, color.NRGBA{0, 0xFF, 0, 0xFF})
+	r := newRaster(img, 2, 0.5)
+	if r.width != 2 || r.height != 1 {
+		t.Fatalf("newRaster size = %dx%d, want 2x1", r.width, r.height)
+	}
+	// The bottom row is transparent
+	want := []color.NRGBA{{0x7F, 0, 0x7F, 0x7F}, {0, 0xFF, 0, 0x7F}}
+	for x, w := range want {
+		if got := r.at(x, 0); got != w {
+			t.Errorf("newRaster pixel %d = %v, want %v", x, got, w)
+		}
+	}
+}
+
+func TestNewRasterLargeCell(t *testing.T) {
+	// Averaging that many white pixels overflows 32-bit sums
+	img := image.NewRGBA(image.Rect(0, 0, 400, 400))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	r := newRaster(img, 1, 1)
+	if got, want := r.at(0, 0), (color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}); got != want {
+		t.Errorf("newRaster pixel = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package escapes
+
+import "strings"
+
+// Reflow rewraps text that was wrapped to oldWidth, such as by Wrap, to
+// newWidth, preserving its styles. Line breaks that wrapping would have
+// produced at oldWidth are removed first, and other line breaks are kept, so
+// that paragraphs and blank lines survive. A line exactly oldWidth columns
+// wide without whitespace is taken to be part of a word that was split.
+//
+// The style resets and reapplied styles Wrap adds around line breaks are
+// removed as well, so that text can be reflowed repeatedly without growing.
+func Reflow(s string, oldWidth, newWidth int) string {
+	lines := strings.Split(s, "\n")
+
+	var (
+		b      strings.Builder
+		active []string // Styles active at the start of the current line
+	)
+	cur := lines[0]
+	for i := 1; i < len(lines); i++ {
+		next := lines[i]
+
+		// Undo the reset and reapplied styles around the line break
+		body := strings.TrimSuffix(cur, ColorReset)
+		end := trackSGR(append([]string(nil), active...), body)
+		prefix := strings.Join(end, "")
+		if len(end) > 0 && body != cur && strings.HasPrefix(next, prefix) {
+			cur, next = body, next[len(prefix):]
+		} else {
+			end = trackSGR(end, cur[len(body):])
+		}
+
+		b.WriteString(cur)
+		switch soft, split := softBreak(Strip(cur), Strip(next), oldWidth); {
+		case split:
+		case soft:
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('\n')
+		}
+		cur, active = next, end
+	}
+	b.WriteString(cur)
+	return Wrap(b.String(), newWidth)
+}
+
+// softBreak reports whether the break between two lines of visible text was
+// likely produced by wrapping at width, and whether it split a word.
+func softBreak(line, next string, width int) (soft, split bool) {
+	if strings.TrimSpace(line) == "" || next == "" || next[0] == ' ' || next[0] == '\t' {
+		return false, false
+	}
+	lineW := StringWidth(line)
+	if lineW == width && !strings.ContainsAny(line, " \t") {
+		return true, true
+	}
+	word := next
+	if i := strings.IndexAny(next, " \t"); i >= 0 {
+		word = next[:i]
+	}
+	return lineW+1+StringWidth(word) > width, false
+}
@@ -0,0 +1,213 @@
+package escapes
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Renderer draws frames to a terminal at a limited frame rate. Frames submitted
+// faster than that are coalesced, so only the latest one is drawn on each tick.
+// Every frame is written in a single call, wrapped in synchronized update
+// sequences so that terminals supporting them never show a partial frame.
+//
+// Frames are drawn from the top-left corner of the terminal, so a Renderer is
+// usually combined with the alternate screen buffer, unless it is returned by
+// NewInlineRenderer. If interactive sequences are disabled, only the last
+// frame is written, as plain lines, on Close.
+type Renderer struct {
+	w        io.Writer
+	interval time.Duration
+	live     *LiveRegion // Region frames are drawn in, for inline renderers
+
+	mu      sync.Mutex
+	pending string
+	screen  *Screen // Pending Screen frame, if any
+	damage  []Rect  // Regions of the pending Screen frame that changed
+	partial bool    // Whether only the damage needs to be drawn
+	dirty   bool
+	last    *Screen // Last Screen drawn, to compute the next diff from
+	err     error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRenderer returns a Renderer that writes to w at most fps times per second,
+// defaulting to 60. It must be closed with Close.
+func NewRenderer(w io.Writer, fps int) *Renderer {
+	if fps <= 0 {
+		fps = 60
+	}
+	r := &Renderer{
+		w:        w,
+		interval: time.Second / time.Duration(fps),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+// NewInlineRenderer returns a Renderer for output that is mostly appended,
+// such as that of build tools, which does not take over the screen: frames are
+// drawn in place below the output, as with a LiveRegion, and lines written to
+// the renderer are added to the output above them, where they stay in the
+// scrollback of the terminal. Lines of frames should fit on a row, and Screen
+// frames are drawn in full. The cursor is expected to be at the start of a
+// line. It must be closed with Close, which leaves the last frame below the
+// output.
+func NewInlineRenderer(w io.Writer, fps int) *Renderer {
+	r := NewRenderer(w, fps)
+	r.live = NewLiveRegion(w)
+	return r
+}
+
+// Write writes finished lines of output above the frame of a renderer
+// returned by NewInlineRenderer; see LiveRegion.Write. Other renderers return
+// ErrNotSupported.
+func (r *Renderer) Write(p []byte) (int, error) {
+	if r.live == nil {
+		return 0, ErrNotSupported
+	}
+	return r.live.Write(p)
+}
+
+// WriteString is like Write, but writes the contents of string s.
+func (r *Renderer) WriteString(s string) (int, error) {
+	return r.Write([]byte(s))
+}
+
+// Render submits a frame of text, replacing any frame that was not drawn yet.
+// Text frames are drawn in full, erasing the rest of each line and the rest of
+// the screen below them.
+func (r *Renderer) Render(frame string) {
+	// Lines ending with CRLF would be erased by the erase before the LF
+	frame = strings.ReplaceAll(frame, "\r\n", "\n")
+	r.mu.Lock()
+	r.pending, r.screen, r.dirty = frame, nil, true
+	r.partial = false
+	r.mu.Unlock()
+}
+
+// RenderScreen submits a Screen frame, replacing any frame that was not drawn
+// yet. Only the cells that changed since the previous Screen frame are drawn.
+// The screen is copied, so the caller may keep modifying it.
+func (r *Renderer) RenderScreen(s *Screen) {
+	s = s.Clone()
+	r.mu.Lock()
+	r.pending, r.screen, r.dirty = "", s, true
+	r.partial = false
+	r.mu.Unlock()
+}
+
+// RenderScreenDamage is like RenderScreen, but only the cells within the
+// regions recorded by d are compared to the previous Screen frame and drawn,
+// and d is reset. If a frame that was not drawn yet is replaced, the damage
+// of both frames is drawn.
+func (r *Renderer) RenderScreenDamage(s *Screen, d *Damage) {
+	s = s.Clone()
+	r.mu.Lock()
+	switch {
+	case !r.dirty:
+		r.damage = append(r.damage[:0], d.rects...)
+		r.partial = true
+	case r.partial:
+		r.damage = append(r.damage, d.rects...)
+	}
+	r.pending, r.screen, r.dirty = "", s, true
+	r.mu.Unlock()
+	d.Reset()
+}
+
+// Flush draws the pending frame immediately, if any, and returns the first
+// write error encountered by the renderer.
+func (r *Renderer) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dirty && r.err == nil {
+		r.err = r.draw()
+	}
+	return r.err
+}
+
+// Close stops the render loop, draws the pending frame, and returns the first
+// write error encountered by the renderer.
+func (r *Renderer) Close() error {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	<-r.done
+	err := r.Flush()
+	if r.live != nil {
+		if lerr := r.live.Close(); err == nil {
+			err = lerr
+		}
+	}
+	return err
+}
+
+func (r *Renderer) loop() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if Interactive() {
+				r.Flush()
+			}
+		}
+	}
+}
+
+// draw writes the pending frame. It must be called with r.mu held.
+func (r *Renderer) draw() error {
+	var frame string
+	if r.live != nil {
+		frame = r.pending
+		if r.screen != nil {
+			frame = r.screen.lines()
+		}
+		r.pending, r.screen, r.dirty = "", nil, false
+		return r.live.Set(strings.Split(frame, "\n")...)
+	}
+	if !Interactive() {
+		frame = r.pending
+		if r.screen != nil {
+			frame = r.screen.lines()
+		}
+		r.pending, r.screen, r.dirty = "", nil, false
+		_, err := io.WriteString(r.w, frame+"\n")
+		return err
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	b.WriteString(SyncUpdateBegin)
+	if r.screen != nil {
+		if r.partial {
+			r.screen.diffRects(b, r.last, r.damage)
+		} else {
+			r.screen.diff(b, r.last)
+		}
+		r.last = r.screen
+	} else {
+		b.WriteString(CursorTopLeft)
+		b.WriteString(strings.Replace(r.pending, "\n", EraseRight+"\r\n", -1))
+		b.WriteString(EraseRight + EraseDown)
+		r.last = nil
+	}
+	b.WriteString(SyncUpdateEnd)
+	r.pending, r.screen, r.dirty = "", nil, false
+	r.partial = false
+
+	_, err := r.w.Write(b.Bytes())
+	return err
+}
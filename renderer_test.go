@@ -0,0 +1,25 @@
+package escapes
+
+import "testing"
+
+func TestRendererRender(t *testing.T) {
+	defer SetInteractive(Interactive())
+	SetInteractive(true)
+
+	tests := []struct {
+		frame, want string
+	}{
+		{"abc\ndef", "abc\ndef\n"},
+		{"abc\r\ndef", "abc\ndef\n"},
+	}
+	for _, tt := range tests {
+		e := NewEmulator(10, 3)
+		e.WriteString("old\nold\nold")
+		r := NewRenderer(e, 0)
+		r.Render(tt.frame)
+		r.Close()
+		if got := e.String(); got != tt.want {
+			t.Errorf("Render(%q): screen = %q, want %q", tt.frame, got, tt.want)
+		}
+	}
+}
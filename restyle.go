@@ -0,0 +1,42 @@
+package escapes
+
+// Restyle returns s with the style of each of its Spans replaced by the one
+// returned by f, given the style and text of the span, such as to remove
+// backgrounds or dim quoted output. The output uses the shortest sequences
+// between styles, as with Transition, and ends in the default style.
+// Hyperlinks are kept, without their parameters, while other escape
+// sequences, such as cursor movements, are dropped.
+func Restyle(s string, f func(style Style, text string) Style) string {
+	spans := Spans(s)
+	for i, span := range spans {
+		spans[i].Style = f(span.Style, span.Text)
+	}
+	return joinSpans(spans)
+}
+
+// joinSpans renders spans with the shortest sequences between their styles
+// and hyperlinks, ending in the default style.
+func joinSpans(spans []Span) string {
+	var (
+		style Style
+		url   string
+	)
+	b := getBuffer()
+	defer putBuffer(b)
+	for _, span := range spans {
+		b.WriteString(Transition(style, span.Style))
+		style = span.Style
+		if span.URL != url {
+			b.WriteString(Osc + "8;;")
+			b.WriteString(span.URL)
+			b.WriteString(oscEnd())
+			url = span.URL
+		}
+		b.WriteString(span.Text)
+	}
+	if url != "" {
+		b.WriteString(Osc + "8;;" + oscEnd())
+	}
+	b.WriteString(Transition(style, Style{}))
+	return b.String()
+}
@@ -0,0 +1,463 @@
+package escapes
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// Cell is a single character cell of a Screen. A zero rune is drawn as a
+// space.
+type Cell struct {
+	Rune  rune
+	Marks string // Combining characters drawn over the rune, if any
+	Style Style
+	URL   string // Target of the hyperlink the cell is in, if any
+}
+
+// WideContinuation is the rune of the cell covered by the right half of the
+// double-width character to its left. Such cells are drawn along with the
+// character.
+const WideContinuation rune = -1
+
+// Screen is an in-memory grid of cells that can be rendered in full, or as the
+// minimal update from a previously rendered Screen.
+type Screen struct {
+	width  int
+	height int
+	cells  []Cell
+}
+
+// NewScreen returns a blank Screen of the given dimensions.
+func NewScreen(width, height int) *Screen {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	return &Screen{
+		width:  width,
+		height: height,
+		cells:  make([]Cell, width*height),
+	}
+}
+
+// Size returns the dimensions of the screen.
+func (s *Screen) Size() (width, height int) {
+	return s.width, s.height
+}
+
+// Cell returns the cell at (x, y), or a blank cell if it is out of bounds.
+func (s *Screen) Cell(x, y int) Cell {
+	if !s.inBounds(x, y) {
+		return Cell{}
+	}
+	return s.cells[y*s.width+x]
+}
+
+// SetCell sets the cell at (x, y). Cells out of bounds are ignored. A
+// double-width character also sets the cell to its right to a
+// WideContinuation, or is replaced by a blank if it does not fit. A
+// double-width character that is partly overwritten is replaced by a blank.
+func (s *Screen) SetCell(x, y int, c Cell) {
+	if !s.inBounds(x, y) {
+		return
+	}
+	i := y*s.width + x
+	if c.Rune == WideContinuation {
+		c.Rune = 0
+	}
+	s.splitWide(i)
+	if RuneWidth(c.Rune) == 2 {
+		if x+1 >= s.width {
+			c.Rune = 0
+		} else {
+			s.splitWide(i + 1)
+			s.cells[i+1] = Cell{Rune: WideContinuation, Style: c.Style, URL: c.URL}
+		}
+	}
+	s.cells[i] = c
+}
+
+// splitWide replaces the double-width character covering cells[i], if any,
+// with blanks, before cells[i] is overwritten.
+func (s *Screen) splitWide(i int) {
+	x := i % s.width
+	switch {
+	case s.cells[i].Rune == WideContinuation && x > 0:
+		s.cells[i-1].Rune = 0
+		s.cells[i].Rune = 0
+	case s.cells[i].Rune != WideContinuation && x+1 < s.width && s.cells[i+1].Rune == WideContinuation:
+		s.cells[i+1].Rune = 0
+	}
+}
+
+// SetString writes text starting at (x, y) with the given style, and returns
+// the number of cells written. Double-width characters take two cells, and
+// combining characters are added to the Marks of the cell before them. Text
+// is clipped at the right edge and does not wrap. Control characters and
+// escape sequences are skipped.
+func (s *Screen) SetString(x, y int, text string, style Style) int {
+	return s.SetLinkString(x, y, text, "", style)
+}
+
+// SetLinkString is like SetString, but makes the text a hyperlink to url.
+func (s *Screen) SetLinkString(x, y int, text, url string, style Style) int {
+	start, last := x, -1
+	for i := 0; i < len(text) && x < s.width; {
+		if n := sequenceLen(text[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		w := RuneWidth(r)
+		if w == 0 {
+			if last >= 0 && r >= 0x300 {
+				s.cells[y*s.width+last].Marks += string(r)
+			}
+			continue
+		}
+		if x+w > s.width {
+			break
+		}
+		s.SetCell(x, y, Cell{Rune: r, Style: style, URL: url})
+		if s.inBounds(x, y) {
+			last = x
+		}
+		x += w
+	}
+	return x - start
+}
+
+// Fill sets every cell of the screen to c.
+func (s *Screen) Fill(c Cell) {
+	for i := range s.cells {
+		s.cells[i] = c
+	}
+}
+
+// Clear resets every cell of the screen to a blank cell.
+func (s *Screen) Clear() {
+	s.Fill(Cell{})
+}
+
+// Clone returns a deep copy of the screen.
+func (s *Screen) Clone() *Screen {
+	c := *s
+	c.cells = append([]Cell(nil), s.cells...)
+	return &c
+}
+
+func (s *Screen) inBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < s.width && y < s.height
+}
+
+// Render returns the escape sequences that draw the whole screen, starting
+// from the top-left corner of the terminal. The output ends with the default
+// style restored and no hyperlink open.
+func (s *Screen) Render() string {
+	b := getBuffer()
+	defer putBuffer(b)
+	s.render(b)
+	return b.String()
+}
+
+// render writes the sequences returned by Render to b.
+func (s *Screen) render(b *bytes.Buffer) {
+	var p pen
+	b.WriteString(CursorTopLeft)
+	for y := 0; y < s.height; y++ {
+		if y > 0 {
+			b.WriteString("\r\n")
+		}
+		for x := 0; x < s.width; x++ {
+			p = s.writeCell(b, x, y, p)
+		}
+	}
+	b.WriteString(p.reset())
+}
+
+// lines returns the rows of the screen as lines of styled text, without any
+// cursor movement and without trailing blanks.
+func (s *Screen) lines() string {
+	b := getBuffer()
+	defer putBuffer(b)
+	s.writeLines(b)
+	return b.String()
+}
+
+// writeLines writes the lines returned by lines to b.
+func (s *Screen) writeLines(b *bytes.Buffer) {
+	for y := 0; y < s.height; y++ {
+		if y > 0 {
+			b.WriteByte('\n')
+		}
+		end := s.width
+		for end > 0 && s.cells[y*s.width+end-1] == (Cell{}) {
+			end--
+		}
+		var p pen
+		for x := 0; x < end; x++ {
+			p = s.writeCell(b, x, y, p)
+		}
+		b.WriteString(p.reset())
+	}
+}
+
+// Diff returns the escape sequences that update the terminal from showing
+// prev to showing s, only redrawing the cells that changed. The whole screen
+// is redrawn if prev is nil or has different dimensions. The output ends with
+// the default style restored and no hyperlink open.
+func (s *Screen) Diff(prev *Screen) string {
+	b := getBuffer()
+	defer putBuffer(b)
+	s.diff(b, prev)
+	return b.String()
+}
+
+// WriteDiff writes the escape sequences returned by Diff to w in a single
+// call. The sequences are built in a reused buffer, so that drawing frames
+// repeatedly produces little garbage.
+func (s *Screen) WriteDiff(w io.Writer, prev *Screen) (int, error) {
+	b := getBuffer()
+	defer putBuffer(b)
+	s.diff(b, prev)
+	if b.Len() == 0 {
+		return 0, nil
+	}
+	return w.Write(b.Bytes())
+}
+
+// diff writes the sequences returned by Diff to b.
+func (s *Screen) diff(b *bytes.Buffer, prev *Screen) {
+	if prev == nil || prev.width != s.width || prev.height != s.height {
+		b.WriteString(EraseScreen)
+		s.render(b)
+		return
+	}
+
+	if top, bottom, n := s.findShift(prev); n != 0 {
+		b.WriteString(shiftLines(top, bottom, n, s.height))
+		prev = prev.shifted(top, bottom, n)
+	}
+	ranges := make([]cellRange, s.height)
+	for y := range ranges {
+		ranges[y] = cellRange{y, 0, s.width}
+	}
+	s.diffRanges(b, prev, ranges)
+}
+
+// cellRange is the range of columns [start, end) of row y.
+type cellRange struct {
+	y, start, end int
+}
+
+// diffRanges writes the sequences that redraw the cells that changed between
+// prev and s within ranges, which are ordered and do not overlap, ending with
+// the default style restored. Ranges are extended to whole double-width
+// characters.
+func (s *Screen) diffRanges(b *bytes.Buffer, prev *Screen, ranges []cellRange) {
+	var (
+		p      pen
+		cx, cy = -1, -1
+	)
+	for _, r := range ranges {
+		y := r.y
+		start, end := r.start, r.end
+		if start > 0 && (s.Cell(start, y).Rune == WideContinuation || prev.Cell(start, y).Rune == WideContinuation) {
+			start--
+		}
+		if s.Cell(end, y).Rune == WideContinuation || prev.Cell(end, y).Rune == WideContinuation {
+			end++
+		}
+		if cy == y {
+			start = max(start, cx)
+		}
+		for x := start; x < end; x++ {
+			i := y*s.width + x
+			if s.cells[i] == prev.cells[i] {
+				continue
+			}
+			if s.cells[i].Rune == WideContinuation && x > 0 {
+				if cy == y && cx > x {
+					// Drawn along with the character to its left
+					continue
+				}
+				x, i = x-1, i-1
+			}
+
+			// Rewriting a few unchanged cells is cheaper than moving over them
+			if cy == y && cx >= 0 && cx < x && x-cx <= 3 {
+				for ; cx < x; cx++ {
+					p = s.writeCell(b, cx, y, p)
+				}
+			}
+			if cx != x || cy != y {
+				if cx < 0 {
+					b.Write(AppendCursorPos(b.AvailableBuffer(), x, y))
+				} else {
+					b.WriteString(MoveTo(cx, cy, x, y))
+				}
+			}
+			p = s.writeCell(b, x, y, p)
+			cx, cy = x+1, y
+			if x+1 < s.width && s.cells[i+1].Rune == WideContinuation {
+				cx++
+			}
+			if cx >= s.width {
+				// The cursor is in the pending-wrap state, so its position
+				// is ambiguous until it is moved absolutely
+				cx, cy = -1, -1
+			}
+		}
+	}
+	b.WriteString(p.reset())
+}
+
+// findShift looks for a block of rows that moved vertically between prev and
+// s, as happens when new lines are appended to a log. It returns the region of
+// rows affected and the number of rows its contents moved up by (negative if
+// they moved down), or 0 if moving them is not worthwhile.
+func (s *Screen) findShift(prev *Screen) (top, bottom, n int) {
+	cur, old := s.rowHashes(), prev.rowHashes()
+	var bestGain int
+	for k := 1 - s.height; k < s.height; k++ {
+		if k == 0 {
+			continue
+		}
+
+		// Find the longest run of rows y whose contents were at row y+k, and
+		// count how many of them would need to be redrawn otherwise
+		var start, gain int
+		for y := 0; y <= s.height; y++ {
+			src := y + k
+			if y < s.height && src >= 0 && src < s.height && cur[y] == old[src] && s.rowEqual(prev, y, src) {
+				if cur[y] != old[y] || !s.rowEqual(prev, y, y) {
+					gain++
+				}
+				continue
+			}
+			if gain > bestGain && y-start > 0 {
+				bestGain = gain
+				if k > 0 {
+					top, bottom, n = start, y-1+k, k
+				} else {
+					top, bottom, n = start+k, y-1, k
+				}
+			}
+			start, gain = y+1, 0
+		}
+	}
+
+	// A shift costs about as much as redrawing a single line
+	if bestGain < 2 {
+		return 0, 0, 0
+	}
+	return top, bottom, n
+}
+
+// shiftLines returns the sequences that move rows top through bottom of a
+// screen of the given height up by n rows (or down, if n is negative), leaving
+// blank lines behind. The whole screen is scrolled if possible; otherwise,
+// lines are deleted and inserted so that the rows outside of the region are
+// left in place.
+func shiftLines(top, bottom, n, height int) string {
+	if top == 0 && bottom == height-1 {
+		return Scroll(n)
+	}
+	if n > 0 {
+		return CursorPos(0, top) + TextDeleteLines(n) +
+			CursorPos(0, bottom-n+1) + TextInsertLines(n)
+	}
+	return CursorPos(0, bottom+n+1) + TextDeleteLines(-n) +
+		CursorPos(0, top) + TextInsertLines(-n)
+}
+
+// shifted returns a copy of s after applying shiftLines to it.
+func (s *Screen) shifted(top, bottom, n int) *Screen {
+	c := NewScreen(s.width, s.height)
+	copy(c.cells, s.cells)
+	for y := top; y <= bottom; y++ {
+		row := c.cells[y*s.width : (y+1)*s.width]
+		if src := y + n; src >= top && src <= bottom {
+			copy(row, s.cells[src*s.width:(src+1)*s.width])
+		} else {
+			for x := range row {
+				row[x] = Cell{}
+			}
+		}
+	}
+	return c
+}
+
+// rowHashes returns a hash of every row, to quickly rule out unequal rows.
+func (s *Screen) rowHashes() []uint64 {
+	hashes := make([]uint64, s.height)
+	for y := range hashes {
+		h := uint64(14695981039346656037)
+		for _, c := range s.cells[y*s.width : (y+1)*s.width] {
+			h = (h ^ uint64(c.Rune)) * 1099511628211
+			h = (h ^ uint64(c.Style.Attrs)) * 1099511628211
+			for _, col := range []Color{c.Style.Fg, c.Style.Bg} {
+				v := uint64(col.kind)<<32 | uint64(col.index)<<24 |
+					uint64(col.r)<<16 | uint64(col.g)<<8 | uint64(col.b)
+				h = (h ^ v) * 1099511628211
+			}
+		}
+		hashes[y] = h
+	}
+	return hashes
+}
+
+// rowEqual reports whether row y of s equals row src of prev.
+func (s *Screen) rowEqual(prev *Screen, y, src int) bool {
+	a := s.cells[y*s.width : (y+1)*s.width]
+	b := prev.cells[src*s.width : (src+1)*s.width]
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pen is the style and hyperlink that cells are drawn with.
+type pen struct {
+	style Style
+	url   string
+}
+
+// reset returns the sequences that restore the default style and end the
+// hyperlink of the pen.
+func (p pen) reset() string {
+	s := Transition(p.style, Style{})
+	if p.url != "" {
+		s += Osc + "8;;" + oscEnd()
+	}
+	return s
+}
+
+// writeCell writes the cell at (x, y), preceded by the transition from the
+// active pen, and returns the new active pen. Cells covered by double-width
+// characters are not written.
+func (s *Screen) writeCell(b *bytes.Buffer, x, y int, active pen) pen {
+	c := s.cells[y*s.width+x]
+	if c.Rune == WideContinuation {
+		return active
+	}
+	b.WriteString(Transition(active.style, c.Style))
+	if c.URL != active.url {
+		b.WriteString(Osc + "8;;")
+		b.WriteString(c.URL)
+		b.WriteString(oscEnd())
+	}
+	if c.Rune == 0 {
+		b.WriteByte(' ')
+	} else {
+		b.WriteRune(c.Rune)
+	}
+	b.WriteString(c.Marks)
+	return pen{c.Style, c.URL}
+}
@@ -0,0 +1,55 @@
+package escapes
+
+import (
+	"strings"
+	"testing"
+)
+
+// plainScreen returns a screen of unstyled lines.
+func plainScreen(width int, lines ...string) *Screen {
+	s := NewScreen(width, len(lines))
+	for y, line := range lines {
+		for x, r := range line {
+			s.SetCell(x, y, Cell{Rune: r})
+		}
+	}
+	return s
+}
+
+func TestScreenRender(t *testing.T) {
+	s := plainScreen(3, "ab", "c")
+	if got, want := s.Render(), CursorTopLeft+"ab \r\nc  "; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestScreenDiff(t *testing.T) {
+	prev := plainScreen(4, "abcd", "efgh")
+	tests := []struct {
+		name string
+		next *Screen
+		want string
+	}{
+		{"same", plainScreen(4, "abcd", "efgh"), ""},
+		{"changed cell", plainScreen(4, "abcd", "eXgh"), CursorPos(1, 1) + "X"},
+		{"resized", plainScreen(2, "ab"), EraseScreen + CursorTopLeft + "ab"},
+	}
+	for _, tt := range tests {
+		if got := tt.next.Diff(prev); got != tt.want {
+			t.Errorf("%s: Diff() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScreenDiffScroll(t *testing.T) {
+	prev := plainScreen(6, "line 1", "line 2", "line 3", "line 4", "line 5")
+	next := plainScreen(6, "line 3", "line 4", "line 5", "line 6", "line 7")
+	d := next.Diff(prev)
+	if !strings.HasPrefix(d, Scroll(2)) {
+		t.Errorf("Diff() = %q, want it to scroll by 2 rows", d)
+	}
+	// Only the new rows are drawn
+	if strings.Contains(d, "3") || !strings.Contains(d, "6") || !strings.Contains(d, "7") {
+		t.Errorf("Diff() = %q, want only rows 6 and 7 drawn", d)
+	}
+}
@@ -0,0 +1,156 @@
+package escapes
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ScreenReaderProgressStep is the smallest change in progress, in percent,
+// reported by the writer returned by NewScreenReaderWriter.
+var ScreenReaderProgressStep = 10
+
+var percentPattern = regexp.MustCompile(`(\d{1,3})(?:\.\d+)?\s*%`)
+
+// NewScreenReaderWriter returns a writer that turns the output passing through
+// it into plain lines suited to screen readers and logs. Purely decorative
+// sequences, such as colors and cursor movements, are removed, while semantic
+// ones are converted to text:
+//
+//   - Hyperlinks are written as "text <url>", or just the URL if it is the
+//     text.
+//   - Lines redrawn in place with carriage returns, such as spinners and
+//     progress bars, are dropped; if they contain a percentage, it is reported
+//     as "Progress: n%" every ScreenReaderProgressStep percent.
+//   - Progress reported with OSC 9;4 is reported the same way.
+//
+// Lines are written once they end, so the writer must be flushed with Flush
+// once the output is complete.
+func NewScreenReaderWriter(w io.Writer) *TransformWriter {
+	var (
+		line     strings.Builder
+		linkURL  string
+		linkText int // Offset in line of the text of the open link
+		progress = -1
+		cr       bool // Whether the last write ended with a carriage return
+	)
+
+	report := func(b *strings.Builder, pct int) {
+		if pct < 0 || pct > 100 {
+			return
+		}
+		if progress < 0 || pct < progress || pct-progress >= ScreenReaderProgressStep || (pct == 100 && progress != 100) {
+			b.WriteString("Progress: " + strconv.Itoa(pct) + "%\n")
+			progress = pct
+		}
+	}
+	closeLink := func() {
+		// Links without text, such as one left open at the end of a line,
+		// are omitted
+		if text := strings.TrimSpace(line.String()[linkText:]); linkURL != "" && text != "" && text != linkURL {
+			line.WriteString(" <" + linkURL + ">")
+		}
+		linkURL = ""
+	}
+	// A line redrawn in place is only reported through its progress, if any
+	redraw := func(b *strings.Builder) {
+		if m := percentPattern.FindStringSubmatch(line.String()); m != nil {
+			pct, _ := strconv.Atoi(m[1])
+			report(b, pct)
+		}
+		line.Reset()
+		linkText = 0
+	}
+
+	t := newTransformWriter(w, func(s string) string {
+		var b strings.Builder
+		if cr && s[0] != '\n' {
+			redraw(&b)
+		}
+		cr = false
+		for i := 0; i < len(s); {
+			if n := sequenceLen(s[i:]); n > 0 {
+				seq := s[i : i+n]
+				i += n
+				if _, url, ok := parseLinkSequence(seq); ok {
+					closeLink()
+					linkURL, linkText = url, line.Len()
+				} else if pct, ok := parseProgressSequence(seq); ok {
+					report(&b, pct)
+				}
+				continue
+			}
+
+			c := s[i]
+			i++
+			switch c {
+			case '\n':
+				// A link spanning several lines is written on each of them
+				url := linkURL
+				closeLink()
+				b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+				line.Reset()
+				linkURL, linkText = url, 0
+				progress = -1
+			case '\r':
+				switch {
+				case i == len(s):
+					// The LF of a CRLF may come with the next write
+					cr = true
+				case s[i] != '\n':
+					redraw(&b)
+				}
+			case '\b':
+				if str := line.String(); len(str) > 0 {
+					_, size := utf8.DecodeLastRuneInString(str)
+					line.Reset()
+					line.WriteString(str[:len(str)-size])
+					linkText = min(linkText, line.Len())
+				}
+			case '\t':
+				line.WriteByte(c)
+			default:
+				if c >= 0x20 && c != AsciiDelete {
+					line.WriteByte(c)
+				}
+			}
+		}
+		return b.String()
+	})
+	t.end = func() string {
+		var b strings.Builder
+		if cr {
+			redraw(&b)
+			cr = false
+		}
+		closeLink()
+		if s := strings.TrimRight(line.String(), " "); s != "" {
+			b.WriteString(s + "\n")
+		}
+		line.Reset()
+		return b.String()
+	}
+	return t
+}
+
+// parseProgressSequence returns the percentage of a progress sequence,
+// OSC 9 ; 4 ; state ; percent ST, as supported by ConEmu and Windows
+// Terminal. It is -1 for states without a percentage.
+func parseProgressSequence(seq string) (int, bool) {
+	const prefix = Osc + "9;4;"
+	if !strings.HasPrefix(seq, prefix) {
+		return 0, false
+	}
+	body := strings.TrimSuffix(strings.TrimSuffix(seq[len(prefix):], Bel), St)
+	fields := strings.Split(body, ";")
+	if fields[0] == "0" || len(fields) < 2 {
+		return -1, true
+	}
+	pct, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return -1, true
+	}
+	return pct, true
+}
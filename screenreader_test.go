@@ -0,0 +1,54 @@
+package escapes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScreenReaderWriter(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "hello\nworld\n", "hello\nworld\n"},
+		{"colors", "\x1b[1;31merror\x1b[0m: failed\n", "error: failed\n"},
+		{"link", Link("https://example.com", "docs") + "\n", "docs <https://example.com>\n"},
+		{"link to itself", Link("https://example.com", "https://example.com") + "\n", "https://example.com\n"},
+		{"progress", "10%\r50%\r100%\rdone\n", "Progress: 10%\nProgress: 50%\nProgress: 100%\ndone\n"},
+		{"backspace", "abc\b\bd\n", "ad\n"},
+		{"backspace rune", "né\bo\n", "no\n"},
+		{"backspace over link", Osc + "8;;https://x" + Bel + "ab\b\b\b\bc\n", "c <https://x>\n"},
+		{"unfinished", "last", "last\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		w := NewScreenReaderWriter(&b)
+		w.Write([]byte(tt.in))
+		w.Flush()
+		if b.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, b.String(), tt.want)
+		}
+	}
+}
+
+func TestScreenReaderWriterSplitWrites(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want string
+	}{
+		{"CRLF", []string{"one\r", "\ntwo\r\n"}, "one\ntwo\n"},
+		{"progress", []string{"10%\r", "50%\r", "done\n"}, "Progress: 10%\nProgress: 50%\ndone\n"},
+		{"final CR", []string{"100%\r"}, "Progress: 100%\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		w := NewScreenReaderWriter(&b)
+		for _, s := range tt.in {
+			w.Write([]byte(s))
+		}
+		w.Flush()
+		if b.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, b.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package escapes
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// SequenceClass is a set of classes of escape sequences allowed by
+// NewSecurityFilter.
+type SequenceClass uint16
+
+// Classes of escape sequences, combinable with the bitwise OR operator
+const (
+	// AllowSGR allows colors and text attributes.
+	AllowSGR SequenceClass = 1 << iota
+
+	// AllowCursor allows moving, saving, restoring, showing, hiding and
+	// changing the shape of the cursor.
+	AllowCursor
+
+	// AllowErase allows erasing, inserting and deleting characters and lines,
+	// and scrolling.
+	AllowErase
+
+	// AllowHyperlinks allows hyperlinks (OSC 8).
+	AllowHyperlinks
+
+	// AllowTitle allows setting the window and icon titles (OSC 0, 1 and 2).
+	AllowTitle
+
+	// AllowClipboardWrite allows setting the clipboard (OSC 52). Reading it
+	// is never allowed.
+	AllowClipboardWrite
+
+	// AllowImages allows inline images in the iTerm2, kitty and sixel
+	// formats.
+	AllowImages
+)
+
+// DefaultAllow are the classes of sequences that only affect how text is
+// displayed.
+const DefaultAllow = AllowSGR | AllowCursor | AllowErase | AllowHyperlinks
+
+// NewSecurityFilter returns a writer that only passes the escape sequences of
+// the allowed classes, for displaying output from untrusted sources. All other
+// sequences are dropped, notably those that make the terminal reply as if the
+// user typed it (device status and attribute reports, DECRQSS), read the
+// clipboard, change terminal modes or reset the terminal. So are unterminated
+// sequences, and strings cut short by another sequence, which could hide it.
+// Control characters other than tab, newline, carriage return and backspace
+// are dropped as well, including C1 controls that some terminals interpret as
+// escape sequences.
+//
+// The writer must be flushed with Flush once the output is complete.
+func NewSecurityFilter(w io.Writer, allow SequenceClass) *TransformWriter {
+	return newTransformWriter(w, func(s string) string {
+		var b strings.Builder
+		for i := 0; i < len(s); {
+			t := nextToken(s[i:])
+			if n := stringInterrupt(t); n > 0 {
+				// Terminals end the string there, and interpret what
+				// follows anew, so the string is dropped and the rest
+				// filtered on its own
+				i += n
+				if s[i] != AsciiEscape {
+					i++
+				}
+				continue
+			}
+			i += len(t.Value)
+			if t.Kind == TokenText {
+				writeSafeText(&b, t.Value)
+			} else if _, complete := sequenceEnd(t.Value); complete && allow&sequenceClass(t) != 0 {
+				b.WriteString(t.Value)
+			}
+		}
+		return b.String()
+	})
+}
+
+// writeSafeText writes text without its control characters, except for tab,
+// newline, carriage return and backspace.
+func writeSafeText(b *strings.Builder, s string) {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		c := s[i]
+		switch {
+		case c == '\t' || c == '\n' || c == '\r' || c == '\b':
+		case c < 0x20 || c == AsciiDelete:
+			i += size
+			continue
+		case r == utf8.RuneError && size == 1 && c >= 0x80 && c < 0xA0:
+			// A raw C1 control, in terminals not decoding UTF-8
+			i += size
+			continue
+		case r >= 0x80 && r < 0xA0:
+			i += size
+			continue
+		}
+		b.WriteString(s[i : i+size])
+		i += size
+	}
+}
+
+// stringInterrupt returns the offset of the first ESC, CAN or SUB control
+// within a string sequence (OSC, DCS, APC, PM or SOS) other than the ESC of its
+// terminator, at which terminals cut the string short, or 0 if there is none.
+func stringInterrupt(t Token) int {
+	switch t.Kind {
+	case TokenOSC, TokenDCS, TokenAPC, TokenPM, TokenSOS:
+	default:
+		return 0
+	}
+	for i := 2; i < len(t.Value); i++ {
+		switch t.Value[i] {
+		case AsciiCancel, AsciiSubstitute:
+			return i
+		case AsciiEscape:
+			if t.Value[i:] != St {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// sequenceClass returns the class of an escape sequence, or 0 if it belongs to
+// none.
+func sequenceClass(t Token) SequenceClass {
+	switch t.Kind {
+	case TokenCSI:
+		prefix, _, final := parseCSI(t.Value)
+		switch {
+		case prefix == "" && final == "m":
+			return AllowSGR
+		case prefix == "" && len(final) == 1 && strings.Contains("ABCDEFGHfdea`su", final):
+			return AllowCursor
+		case prefix == "" && final == " q":
+			return AllowCursor
+		case prefix == "?" && (t.Value == CursorShow || t.Value == CursorHide):
+			return AllowCursor
+		case (prefix == "" || prefix == "?") && (final == "J" || final == "K"):
+			return AllowErase
+		case prefix == "" && len(final) == 1 && strings.Contains("XPLM@ST", final):
+			return AllowErase
+		}
+	case TokenOSC:
+		code, data := oscCommand(t.Value)
+		switch code {
+		case "8":
+			return AllowHyperlinks
+		case "0", "1", "2":
+			return AllowTitle
+		case "52":
+			if i := strings.IndexByte(data, ';'); i >= 0 && data[i+1:] != "?" {
+				return AllowClipboardWrite
+			}
+		case "1337":
+			if strings.HasPrefix(data, "File=") {
+				return AllowImages
+			}
+		}
+	case TokenAPC:
+		if strings.HasPrefix(t.Value, Apc+"G") {
+			return AllowImages
+		}
+	case TokenDCS:
+		if isSixel(t.Value) {
+			return AllowImages
+		}
+	case TokenEscape:
+		switch t.Value {
+		case "\u001B7", "\u001B8", "\u001BD", "\u001BE", "\u001BM":
+			return AllowCursor
+		}
+	}
+	return 0
+}
+
+// oscCommand returns the command number and data of an OSC sequence.
+func oscCommand(seq string) (code, data string) {
+	body := strings.TrimPrefix(seq, Osc)
+	if strings.HasSuffix(body, Bel) {
+		body = strings.TrimSuffix(body, Bel)
+	} else {
+		body = strings.TrimSuffix(body, St)
+	}
+	if i := strings.IndexByte(body, ';'); i >= 0 {
+		return body[:i], body[i+1:]
+	}
+	return body, ""
+}
+
+// isSixel reports whether a DCS sequence is sixel graphics, DCS params q.
+func isSixel(seq string) bool {
+	body := strings.TrimPrefix(seq, Dcs)
+	for i := 0; i < len(body); i++ {
+		if c := body[i]; c == 'q' {
+			return true
+		} else if (c < '0' || c > '9') && c != ';' {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package escapes
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSecurityFilter(t *testing.T) {
+	tests := []struct {
+		in    string
+		allow SequenceClass
+		want  string
+	}{
+		{"\x1b[31mred\x1b[0m\r\n", DefaultAllow, "\x1b[31mred\x1b[0m\r\n"},
+		{"a\x07b\x1b[6nc", DefaultAllow, "abc"},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", DefaultAllow, "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"},
+		{"\x1b]2;title\x07", DefaultAllow, ""},
+		{"\x1b]2;title\x07", DefaultAllow | AllowTitle, "\x1b]2;title\x07"},
+		{"\x1b]52;c;?\x07", AllowClipboardWrite, ""},
+		// Strings cut short by another sequence
+		{"\x1b]8;;\x1b]52;c;?\x07", DefaultAllow, ""},
+		{"\x1b]8;;\x1b]2;pwned\x07", DefaultAllow, ""},
+		{"\x1b]8;;\x1b]2;title\x07", DefaultAllow | AllowTitle, "\x1b]2;title\x07"},
+		{"\x1b]8;;x\x18\x1b[6nok", DefaultAllow, "ok"},
+		{"\x1b]8;;x\x1a\x1b[1mok", DefaultAllow, "\x1b[1mok"},
+		// Unterminated at the end of the output
+		{"a\x1b]8;;https://example.com", DefaultAllow, "a"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		f := NewSecurityFilter(&b, tt.allow)
+		f.Write([]byte(tt.in))
+		f.Flush()
+		if got := b.String(); got != tt.want {
+			t.Errorf("NewSecurityFilter(%q, %#x) wrote %q, want %q", tt.in, tt.allow, got, tt.want)
+		}
+	}
+}
+
+func TestSecurityFilterUnterminated(t *testing.T) {
+	var b bytes.Buffer
+	f := NewSecurityFilter(&b, DefaultAllow)
+	f.Write([]byte(Osc + "8;;"))
+	for n := 0; n <= maxPending; n += 4096 {
+		f.Write([]byte(strings.Repeat("x", 4096)))
+	}
+	f.Write([]byte("\x1b]2;pwned\x07"))
+	f.Flush()
+	if got := b.String(); strings.Contains(got, "\x1b") {
+		t.Errorf("wrote a sequence after an unterminated OSC: %q", got[max(len(got)-20, 0):])
+	}
+}
@@ -0,0 +1,123 @@
+package escapes
+
+import (
+	"io"
+	"os"
+)
+
+// SessionMode is an optional input mode enabled by NewSession.
+type SessionMode int
+
+// Modes of sessions
+const (
+	// SessionMouse reports mouse clicks, wheel and drags, in SGR format.
+	SessionMouse SessionMode = iota
+
+	// SessionMouseMotion reports mouse motion even without buttons pressed,
+	// in addition to SessionMouse.
+	SessionMouseMotion
+
+	// SessionMousePixels reports mouse positions in pixels, in addition to
+	// SessionMouse, in terminals supporting it.
+	SessionMousePixels
+
+	// SessionBracketedPaste marks pasted text, so that it is not mistaken for
+	// keys.
+	SessionBracketedPaste
+
+	// SessionFocusReport reports when the terminal gains or loses focus.
+	SessionFocusReport
+
+	// SessionKeyboard enables the disambiguation of keys of the kitty
+	// keyboard protocol, in terminals supporting it.
+	SessionKeyboard
+
+	// SessionAlternateScroll makes the mouse wheel send Up and Down keys,
+	// without SessionMouse.
+	SessionAlternateScroll
+)
+
+// Session is the state of the terminal for a fullscreen application, set by
+// NewSession and restored by Close. Output should be written through the
+// session, so that modes changed by the application are restored as well.
+type Session struct {
+	// Input decodes the input of the session.
+	Input *InputDecoder
+
+	guard    *TerminalGuard
+	keyboard bool
+}
+
+// NewSession prepares the terminal for a fullscreen application writing to w
+// and reading input from r: it puts the terminal in raw mode if r is one,
+// switches to the alternate screen, hides the cursor and enables the given
+// modes. If the process receives an interrupt or termination signal, the
+// terminal is restored and the process exits with status 130 or 143,
+// depending on the signal; see NewTerminalGuard. It returns ErrNotInteractive
+// if interactive sequences are disabled.
+func NewSession(w io.Writer, r io.Reader, modes ...SessionMode) (*Session, error) {
+	if !Interactive() {
+		return nil, ErrNotInteractive
+	}
+	s := &Session{Input: NewInputDecoder(r), guard: NewTerminalGuard(w)}
+	if f, ok := r.(*os.File); ok && IsTerminal(f) {
+		if err := s.guard.MakeRaw(f.Fd()); err != nil {
+			s.guard.Close()
+			return nil, err
+		}
+	}
+
+	seq := AltScreenEnable + CursorHide
+	for _, mode := range modes {
+		switch mode {
+		case SessionMouse:
+			seq += MouseButtonEnable + MouseSGREnable
+		case SessionMouseMotion:
+			seq += MouseAnyEnable + MouseSGREnable
+		case SessionMousePixels:
+			seq += MouseSGRPixelsEnable
+			s.Input.MousePixels = true
+		case SessionBracketedPaste:
+			seq += BracketedPasteEnable
+		case SessionFocusReport:
+			seq += FocusReportEnable
+		case SessionAlternateScroll:
+			seq += AlternateScrollEnable
+		case SessionKeyboard:
+			seq += PushKeyboardFlags(KeyboardDisambiguate)
+			s.keyboard = true
+		}
+	}
+	if _, err := s.guard.WriteString(seq); err != nil {
+		s.guard.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write writes output to the terminal.
+func (s *Session) Write(p []byte) (int, error) {
+	return s.guard.Write(p)
+}
+
+// WriteString is like Write, but writes the contents of string str.
+func (s *Session) WriteString(str string) (int, error) {
+	return s.guard.WriteString(str)
+}
+
+// Close restores the terminal in the reverse order it was prepared: it
+// disables the modes, shows the cursor, switches back to the main screen and
+// leaves raw mode. It also stops handling signals.
+func (s *Session) Close() error {
+	var err error
+	if s.keyboard {
+		// Keyboard enhancements are kept per screen, so they are restored
+		// before leaving the alternate screen
+		_, err = s.guard.WriteString(PopKeyboardFlags(1))
+		s.keyboard = false
+	}
+	if cerr := s.guard.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,180 @@
+package escapes
+
+import (
+	"strconv"
+	"strings"
+)
+
+// sgrParams returns the parameters of seq if it is an SGR sequence, CSI
+// params m.
+func sgrParams(seq string) (string, bool) {
+	if len(seq) < 3 || seq[:2] != Esc || seq[len(seq)-1] != 'm' {
+		return "", false
+	}
+	params := seq[2 : len(seq)-1]
+	for i := 0; i < len(params); i++ {
+		if c := params[i]; (c < '0' || c > '9') && c != ';' && c != ':' {
+			return "", false
+		}
+	}
+	return params, true
+}
+
+// sgrEffects describes the effects of an SGR sequence besides the resulting
+// style.
+type sgrEffects uint8
+
+const (
+	// sgrReset is set if the sequence resets all attributes and colors, so
+	// the resulting style does not depend on the previous one.
+	sgrReset sgrEffects = 1 << iota
+
+	// sgrForeground is set if the sequence sets the foreground color,
+	// including by resetting it.
+	sgrForeground
+
+	// sgrUnknown is set if the sequence has parameters that are not
+	// reflected in the style, such as underline styles or fonts.
+	sgrUnknown
+)
+
+// applySGR returns s updated with the parameters of an SGR sequence, along
+// with the effects of the sequence. Colors may use either semicolons or colons
+// to separate their components.
+func applySGR(s Style, params string) (Style, sgrEffects) {
+	var effects sgrEffects
+	fields := strings.Split(params, ";")
+	for i := 0; i < len(fields); i++ {
+		sub := strings.Split(fields[i], ":")
+		p, err := strconv.Atoi(sub[0])
+		if err != nil && sub[0] != "" {
+			effects |= sgrUnknown
+			continue
+		}
+
+		switch {
+		case p == 38 || p == 48 || p == 58:
+			var (
+				c  Color
+				ok bool
+			)
+			if len(sub) > 1 {
+				c, ok = parseSGRColor(sub[1:], true)
+			} else {
+				var n int
+				c, n, ok = parseSGRColorFields(fields[i+1:])
+				i += n
+			}
+			switch {
+			case !ok || p == 58:
+				// Underline colors are not part of styles
+				effects |= sgrUnknown
+			case p == 38:
+				s.Fg = c
+				effects |= sgrForeground
+			default:
+				s.Bg = c
+			}
+		case len(sub) > 1:
+			// Sub-parameters, such as underline styles
+			effects |= sgrUnknown
+		case p == 0:
+			s = Style{}
+			effects |= sgrReset | sgrForeground
+		case p == 39:
+			s.Fg = DefaultColor
+			effects |= sgrForeground
+		case p == 49:
+			s.Bg = DefaultColor
+		case p >= 30 && p <= 37:
+			s.Fg = ANSIColor(p - 30)
+			effects |= sgrForeground
+		case p >= 40 && p <= 47:
+			s.Bg = ANSIColor(p - 40)
+		case p >= 90 && p <= 97:
+			s.Fg = ANSIColor(p - 90 + 8)
+			effects |= sgrForeground
+		case p >= 100 && p <= 107:
+			s.Bg = ANSIColor(p - 100 + 8)
+		default:
+			known := false
+			for j, on := range attrParams {
+				if fields[i] == on {
+					s.Attrs |= 1 << uint(j)
+					known = true
+				}
+				if fields[i] == attrOffParams[j] {
+					s.Attrs &^= 1 << uint(j)
+					known = true
+				}
+			}
+			if !known {
+				effects |= sgrUnknown
+			}
+		}
+	}
+	return s, effects
+}
+
+// parseSGRColorFields parses the components of an extended color following
+// 38, 48 or 58, separated by semicolons, returning how many fields were used.
+func parseSGRColorFields(fields []string) (Color, int, bool) {
+	if len(fields) == 0 {
+		return Color{}, 0, false
+	}
+	switch fields[0] {
+	case "5":
+		if len(fields) < 2 {
+			return Color{}, len(fields), false
+		}
+		c, ok := parseSGRColor(fields[:2], false)
+		return c, 2, ok
+	case "2":
+		if len(fields) < 4 {
+			return Color{}, len(fields), false
+		}
+		c, ok := parseSGRColor(fields[:4], false)
+		return c, 4, ok
+	default:
+		return Color{}, 1, false
+	}
+}
+
+// parseSGRColor parses the components of an extended color: 5 and an index,
+// or 2 and RGB values. With colons, the RGB values may be preceded by a color
+// space id.
+func parseSGRColor(c []string, colons bool) (Color, bool) {
+	if len(c) == 0 {
+		return Color{}, false
+	}
+	switch c[0] {
+	case "5":
+		if len(c) < 2 {
+			return Color{}, false
+		}
+		i, err := strconv.Atoi(c[1])
+		if err != nil || i < 0 || i > 255 {
+			return Color{}, false
+		}
+		return IndexedColor(i), true
+	case "2":
+		v := c[1:]
+		if colons && len(v) >= 4 {
+			v = v[1:]
+		}
+		if len(v) < 3 {
+			return Color{}, false
+		}
+		var rgb [3]uint8
+		for j := range rgb {
+			n, err := strconv.Atoi(v[j])
+			if err != nil || n < 0 || n > 255 {
+				return Color{}, false
+			}
+			rgb[j] = uint8(n)
+		}
+		return RGB(rgb[0], rgb[1], rgb[2]), true
+	default:
+		return Color{}, false
+	}
+}
@@ -0,0 +1,48 @@
+package escapes
+
+import "testing"
+
+func TestApplySGR(t *testing.T) {
+	bold := Style{Attrs: AttrBold}
+	tests := []struct {
+		params  string
+		from    Style
+		want    Style
+		unknown bool
+	}{
+		{"", bold, Style{}, false},
+		{"0", bold, Style{}, false},
+		{"1;31", Style{}, Style{Fg: ANSIColor(1), Attrs: AttrBold}, false},
+		{"22", bold, Style{}, false},
+		{"91;102", Style{}, Style{Fg: ANSIColor(9), Bg: ANSIColor(10)}, false},
+		{"38;5;208", Style{}, Style{Fg: IndexedColor(208)}, false},
+		{"48;2;1;2;3", Style{}, Style{Bg: RGB(1, 2, 3)}, false},
+		{"38:2::1:2:3", Style{}, Style{Fg: RGB(1, 2, 3)}, false},
+		{"38:5:9;1", Style{}, Style{Fg: IndexedColor(9), Attrs: AttrBold}, false},
+		{"39;49", Style{Fg: ANSIColor(1), Bg: ANSIColor(2)}, Style{}, false},
+		{"4:3", Style{}, Style{}, true},
+		{"58;2;255;0;0", bold, bold, true},
+		{"58;5;1;3", bold, bold.With(AttrItalic), true},
+		{"58:2::255:0:0", bold, bold, true},
+		{"59", bold, bold, true},
+		{"38;5;300", Style{}, Style{}, true},
+		{"12", Style{}, Style{}, true},
+	}
+	for _, tt := range tests {
+		got, effects := applySGR(tt.from, tt.params)
+		if got != tt.want {
+			t.Errorf("applySGR(%+v, %q) = %+v, want %+v", tt.from, tt.params, got, tt.want)
+		}
+		if unknown := effects&sgrUnknown != 0; unknown != tt.unknown {
+			t.Errorf("applySGR(%+v, %q) unknown = %v, want %v", tt.from, tt.params, unknown, tt.unknown)
+		}
+	}
+}
+
+func TestSpansUnderlineColor(t *testing.T) {
+	spans := Spans("\x1b[1;31m\x1b[58;2;255;0;0mred")
+	want := Style{Fg: ANSIColor(1), Attrs: AttrBold}
+	if len(spans) != 1 || spans[0].Text != "red" || spans[0].Style != want {
+		t.Errorf("Spans = %+v, want one span %q in %+v", spans, "red", want)
+	}
+}
@@ -0,0 +1,96 @@
+package escapes
+
+import (
+	"image"
+	"strconv"
+	"strings"
+)
+
+// Sixel returns an escape sequence to display an image at the cursor position
+// as sixel graphics, reduced to a palette of at most 256 colors. Transparent
+// pixels are left unchanged. Supported by xterm (with -ti vt340), foot,
+// WezTerm, mlterm, Konsole and Windows Terminal; see Capabilities.SupportsSixel.
+// Pixels are drawn square whatever the aspect ratio of cells, so the image is
+// not stretched.
+func Sixel(img image.Image, opts RasterOptions) string {
+	colors := opts.Colors
+	if colors <= 0 || colors > 256 {
+		colors = 256
+	}
+
+	r := newRaster(img, opts.Width, 1)
+	palette := medianCut(r, colors)
+	indices := quantize(r, palette, nil, opts.Dither)
+
+	var b strings.Builder
+	// Pixels that are not drawn keep their color, and the aspect ratio is 1:1
+	b.WriteString(Dcs + "0;1;0q")
+	b.WriteString("\"1;1;" + strconv.Itoa(r.width) + ";" + strconv.Itoa(r.height))
+	for i, c := range palette {
+		b.WriteString("#" + strconv.Itoa(i) + ";2;" + sixelPercent(c[0]) + ";" +
+			sixelPercent(c[1]) + ";" + sixelPercent(c[2]))
+	}
+
+	// Every band of 6 rows is drawn once per color it uses, returning to the
+	// start of the band in between
+	bits := make([]byte, r.width)
+	for y := 0; y < r.height; y += 6 {
+		first := true
+		for c := range palette {
+			used := false
+			for x := 0; x < r.width; x++ {
+				bits[x] = 0
+				for dy := 0; dy < 6 && y+dy < r.height; dy++ {
+					if indices[(y+dy)*r.width+x] == c {
+						bits[x] |= 1 << uint(dy)
+						used = true
+					}
+				}
+			}
+			if !used {
+				continue
+			}
+			if !first {
+				b.WriteByte('$')
+			}
+			first = false
+			b.WriteString("#" + strconv.Itoa(c))
+			writeSixels(&b, bits)
+		}
+		b.WriteByte('-')
+	}
+
+	b.WriteString(St)
+	return b.String()
+}
+
+// writeSixels writes a row of sixels, compressing repeated ones.
+func writeSixels(b *strings.Builder, bits []byte) {
+	// Trailing empty sixels need not be drawn
+	end := len(bits)
+	for end > 0 && bits[end-1] == 0 {
+		end--
+	}
+
+	for i := 0; i < end; {
+		n := 1
+		for i+n < end && bits[i+n] == bits[i] {
+			n++
+		}
+		c := '?' + bits[i]
+		if n > 3 {
+			b.WriteString("!" + strconv.Itoa(n))
+			b.WriteByte(c)
+		} else {
+			for j := 0; j < n; j++ {
+				b.WriteByte(c)
+			}
+		}
+		i += n
+	}
+}
+
+// sixelPercent converts a color channel to the 0-100 scale of sixel palettes.
+func sixelPercent(v uint8) string {
+	return strconv.Itoa((int(v)*100 + 127) / 255)
+}
@@ -0,0 +1,59 @@
+package escapes
+
+import "strings"
+
+// Span is a run of text with the same style and hyperlink.
+type Span struct {
+	Text  string // Text without escape sequences
+	Style Style
+	URL   string // Target of the hyperlink the text is in, if any
+}
+
+// Spans splits the visible text of s into runs of the same style and
+// hyperlink, resolving the SGR sequences in s into Styles, such as to search
+// styled output or convert it to another format. Other escape sequences are
+// dropped, as are SGR parameters that Style does not represent, such as
+// underline styles. Text is kept as it is, including control characters such
+// as newlines.
+func Spans(s string) []Span {
+	var (
+		spans []Span
+		cur   Span
+		text  strings.Builder
+	)
+	flush := func() {
+		if text.Len() > 0 {
+			cur.Text = text.String()
+			spans = append(spans, cur)
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			j := i + 1
+			for j < len(s) && s[j] != AsciiEscape {
+				j++
+			}
+			text.WriteString(s[i:j])
+			i = j
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+
+		next := cur
+		if params, ok := sgrParams(seq); ok {
+			next.Style, _ = applySGR(cur.Style, params)
+		} else if _, url, ok := parseLinkSequence(seq); ok {
+			next.URL = url
+		}
+		if next != cur {
+			flush()
+			cur = next
+		}
+	}
+	flush()
+	return spans
+}
@@ -0,0 +1,172 @@
+package escapes
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// State tracks the style and hyperlink of output written to it, such as the
+// colored output of another program, to tell which are active at any byte
+// offset of the output. Annotations can then be spliced into the output
+// without breaking its styles; see Splice. Sequences split across writes are
+// handled.
+type State struct {
+	mu      sync.Mutex
+	n       int    // Number of bytes written
+	partial string // Unterminated sequence at the end of the output
+	changes []stateChange
+	seqs    []stateRange // Escape sequences of the output, in order
+}
+
+// stateChange is a change of style or hyperlink, which applies from offset.
+type stateChange struct {
+	offset int
+	style  Style
+	url    string
+}
+
+// stateRange is the range of bytes [start, end) of an escape sequence.
+type stateRange struct {
+	start, end int
+}
+
+// Write applies output to the state.
+func (s *State) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := s.partial + string(p)
+	base := s.n - len(s.partial)
+	s.n += len(p)
+	s.partial = ""
+	cur := s.current()
+	for i := 0; i < len(out); {
+		j := strings.IndexByte(out[i:], AsciiEscape)
+		if j < 0 {
+			break
+		}
+		i += j
+		n, complete := sequenceEnd(out[i:])
+		if !complete {
+			s.partial = out[i:]
+			break
+		}
+		seq := out[i : i+n]
+		s.seqs = append(s.seqs, stateRange{base + i, base + i + n})
+		i += n
+
+		next := cur
+		if params, ok := sgrParams(seq); ok {
+			next.style, _ = applySGR(cur.style, params)
+		} else if _, url, ok := parseLinkSequence(seq); ok {
+			next.url = url
+		}
+		if next.style != cur.style || next.url != cur.url {
+			next.offset = base + i
+			s.changes = append(s.changes, next)
+			cur = next
+		}
+	}
+	return len(p), nil
+}
+
+// WriteString is like Write, but writes the contents of string str.
+func (s *State) WriteString(str string) (int, error) {
+	return s.Write([]byte(str))
+}
+
+// Len returns the number of bytes written.
+func (s *State) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n
+}
+
+// Style returns the style active at the end of the output.
+func (s *State) Style() Style {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current().style
+}
+
+// URL returns the target of the hyperlink open at the end of the output, or
+// an empty string if none is.
+func (s *State) URL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current().url
+}
+
+// StyleAt returns the style of the byte at offset, set by the sequences
+// before it.
+func (s *State) StyleAt(offset int) Style {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.at(offset).style
+}
+
+// URLAt returns the target of the hyperlink the byte at offset is in, or an
+// empty string if it is in none.
+func (s *State) URLAt(offset int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.at(offset).url
+}
+
+// InSequence reports whether offset is inside an escape sequence, so that
+// text must not be inserted there.
+func (s *State) InSequence(offset int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.partial != "" && offset > s.n-len(s.partial) {
+		return true
+	}
+	i := sort.Search(len(s.seqs), func(i int) bool { return s.seqs[i].end > offset })
+	return i < len(s.seqs) && s.seqs[i].start < offset
+}
+
+// Splice returns text prepared to be inserted into the output at offset,
+// which must not be inside an escape sequence: the hyperlink and style
+// active there are ended before text, which is shown with its own styles,
+// and restored after it. Hyperlinks are restored without their parameters.
+func (s *State) Splice(offset int, text string) string {
+	s.mu.Lock()
+	active := s.at(offset)
+	s.mu.Unlock()
+
+	var inner State
+	inner.WriteString(text)
+	end := inner.current()
+
+	var b strings.Builder
+	if active.url != "" {
+		b.WriteString(Osc + "8;;" + oscEnd())
+	}
+	b.WriteString(transition(active.style, Style{}))
+	b.WriteString(text)
+	if end.url != active.url {
+		b.WriteString(Osc + "8;;" + active.url + oscEnd())
+	}
+	b.WriteString(transition(end.style, active.style))
+	return b.String()
+}
+
+// current returns the style and hyperlink active at the end of the output.
+// It must be called with s.mu held.
+func (s *State) current() stateChange {
+	if len(s.changes) == 0 {
+		return stateChange{}
+	}
+	return s.changes[len(s.changes)-1]
+}
+
+// at returns the style and hyperlink active at offset. It must be called with
+// s.mu held.
+func (s *State) at(offset int) stateChange {
+	i := sort.Search(len(s.changes), func(i int) bool { return s.changes[i].offset > offset })
+	if i == 0 {
+		return stateChange{}
+	}
+	return s.changes[i-1]
+}
@@ -0,0 +1,135 @@
+package escapes
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// Sequences to save and restore the cursor position and style (DECSC and
+// DECRC), which unlike CursorSave and CursorRestore are supported by every
+// terminal, and are not affected by left and right margins.
+const (
+	decSaveCursor    = "\u001B7"
+	decRestoreCursor = "\u001B8"
+)
+
+// StatusBar reserves rows at the bottom of the terminal for status lines,
+// such as the state of a daemon, while output written through it scrolls in
+// the rows above as usual. It uses a scroll region, so the output should not
+// set one itself, nor clear the screen.
+//
+// Writes and status updates are serialized, so a StatusBar may be used from
+// several goroutines. If interactive sequences are disabled, output is
+// written as it is, and status lines are not shown.
+type StatusBar struct {
+	// Style is applied to the status lines, over their whole width.
+	Style Style
+
+	mu     sync.Mutex
+	w      io.Writer
+	width  int
+	height int
+	lines  []string
+	err    error
+}
+
+// NewStatusBar reserves rows at the bottom of a terminal of the given size for
+// status lines, initially empty, and returns a StatusBar writing to w. The
+// cursor is expected to be at the end of the output, where it is left. The
+// status bar must be closed with Close to give the rows back.
+func NewStatusBar(w io.Writer, rows int, size ConsoleDim) (*StatusBar, error) {
+	b := &StatusBar{
+		Style:  Style{Attrs: AttrReverse},
+		w:      w,
+		width:  size.Cols,
+		height: size.Rows,
+		lines:  make([]string, max(rows, 1)),
+	}
+	if !Interactive() {
+		return b, nil
+	}
+
+	// Scroll the output up to make room, then restrict scrolling to the rows
+	// above the status lines
+	var s strings.Builder
+	s.WriteString(strings.Repeat("\n", len(b.lines)) + CursorMove(0, -len(b.lines)))
+	s.WriteString(decSaveCursor + SetScrollRegion(0, b.top()-1) + decRestoreCursor)
+	s.WriteString(b.status())
+	if _, err := io.WriteString(w, s.String()); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Write writes output above the status lines.
+func (b *StatusBar) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return writeFull(b.w, p)
+}
+
+// WriteString is like Write, but writes the contents of string s.
+func (b *StatusBar) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// Set replaces the status lines. Lines beyond the number of rows reserved are
+// ignored, and missing lines are left empty. Lines should fit on a row.
+func (b *StatusBar) Set(lines ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range b.lines {
+		b.lines[i] = ""
+		if i < len(lines) {
+			b.lines[i] = lines[i]
+		}
+	}
+	return b.draw(b.status())
+}
+
+// Resize adapts the status bar to a new size of the terminal, such as reported
+// after a SIGWINCH signal.
+func (b *StatusBar) Resize(size ConsoleDim) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.width, b.height = size.Cols, size.Rows
+	return b.draw(decSaveCursor + SetScrollRegion(0, b.top()-1) + decRestoreCursor + b.status())
+}
+
+// Close removes the status lines and gives their rows back to the output.
+func (b *StatusBar) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.draw(decSaveCursor + ScrollRegionReset + CursorPos(0, b.top()) + EraseDown + decRestoreCursor)
+}
+
+// top returns the row of the first status line.
+func (b *StatusBar) top() int {
+	return max(b.height-len(b.lines), 1)
+}
+
+// status returns the sequences that draw the status lines, leaving the cursor
+// in place.
+func (b *StatusBar) status() string {
+	var s strings.Builder
+	s.WriteString(decSaveCursor)
+	for i, line := range b.lines {
+		line, _ := splitAtWidth(line, b.width)
+		s.WriteString(CursorPos(0, b.top()+i))
+		s.WriteString(b.Style.Sequence() + line.s + ColorReset + b.Style.Sequence())
+		s.WriteString(strings.Repeat(" ", max(b.width-line.w, 0)) + ColorReset)
+	}
+	s.WriteString(decRestoreCursor)
+	return s.String()
+}
+
+// draw writes sequences if interactive sequences are enabled. It must be
+// called with b.mu held.
+func (b *StatusBar) draw(s string) error {
+	if !Interactive() || b.err != nil {
+		return b.err
+	}
+	_, b.err = io.WriteString(b.w, s)
+	return b.err
+}
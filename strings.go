@@ -0,0 +1,189 @@
+package escapes
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The functions below work like those of the strings package, but match the
+// visible text of strings, ignoring escape sequences. Sequences are never
+// split, and stay attached to the text they precede; those within or just
+// before a separator are moved to the start of the following part.
+
+// Index returns the byte offset in s of the first instance of substr in the
+// visible text of s, or -1 if there is none. Escape sequences in s between
+// the characters of substr do not prevent a match.
+func Index(s, substr string) int {
+	v := visibleText(s)
+	i := strings.Index(v.text, substr)
+	if i < 0 {
+		return -1
+	}
+	return v.offsets[i]
+}
+
+// Contains reports whether substr is within the visible text of s.
+func Contains(s, substr string) bool {
+	return strings.Contains(Strip(s), substr)
+}
+
+// Split slices s into the parts separated by sep in its visible text. If sep
+// is empty, s is split after each visible UTF-8 sequence.
+func Split(s, sep string) []string {
+	v := visibleText(s)
+	var seps [][2]int
+	if sep == "" {
+		for i := 0; i < len(v.text); {
+			_, size := utf8.DecodeRuneInString(v.text[i:])
+			if i+size < len(v.text) {
+				seps = append(seps, [2]int{i + size, i + size})
+			}
+			i += size
+		}
+	} else {
+		for i := 0; ; {
+			j := strings.Index(v.text[i:], sep)
+			if j < 0 {
+				break
+			}
+			seps = append(seps, [2]int{i + j, i + j + len(sep)})
+			i += j + len(sep)
+		}
+	}
+	return v.split(s, seps)
+}
+
+// Fields splits s around runs of white space in its visible text. Escape
+// sequences surrounding the fields are kept with the first or last field.
+func Fields(s string) []string {
+	v := visibleText(s)
+	var seps [][2]int
+	start := -1
+	for i, r := range v.text {
+		if unicode.IsSpace(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			seps = append(seps, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		seps = append(seps, [2]int{start, len(v.text)})
+	}
+
+	var (
+		fields []string
+		carry  string
+	)
+	for _, part := range v.split(s, seps) {
+		if Strip(part) == "" {
+			carry += part
+			continue
+		}
+		fields = append(fields, carry+part)
+		carry = ""
+	}
+	if len(fields) > 0 {
+		fields[len(fields)-1] += carry
+	}
+	return fields
+}
+
+// TrimSpace returns s without leading and trailing white space in its visible
+// text. Escape sequences within the white space are kept, at the start or end
+// of the result.
+func TrimSpace(s string) string {
+	v := visibleText(s)
+	i := len(v.text) - len(strings.TrimLeftFunc(v.text, unicode.IsSpace))
+	j := len(strings.TrimRightFunc(v.text, unicode.IsSpace))
+	if i >= j {
+		return sequencesOnly(s)
+	}
+	return v.trim(s, i, j)
+}
+
+// TrimPrefix returns s without the given prefix of its visible text, if it has
+// it. Escape sequences within the prefix are kept, at the start of the result.
+func TrimPrefix(s, prefix string) string {
+	v := visibleText(s)
+	if prefix == "" || !strings.HasPrefix(v.text, prefix) {
+		return s
+	}
+	return v.trim(s, len(prefix), len(v.text))
+}
+
+// TrimSuffix returns s without the given suffix of its visible text, if it has
+// it. Escape sequences within the suffix are kept, at the end of the result.
+func TrimSuffix(s, suffix string) string {
+	v := visibleText(s)
+	if suffix == "" || !strings.HasSuffix(v.text, suffix) {
+		return s
+	}
+	return v.trim(s, 0, len(v.text)-len(suffix))
+}
+
+// visible is the visible text of a string, with the offsets of its bytes in
+// the string.
+type visible struct {
+	text string
+
+	// offsets are the byte offsets in the string of each byte of text, and
+	// of the end of the string.
+	offsets []int
+}
+
+func visibleText(s string) visible {
+	var (
+		b       strings.Builder
+		offsets []int
+	)
+	for i := 0; i < len(s); {
+		if n := sequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		offsets = append(offsets, i)
+		i++
+	}
+	return visible{text: b.String(), offsets: append(offsets, len(s))}
+}
+
+// end returns the byte offset in the string just after the visible byte
+// before i, or 0 if i is the first one.
+func (v visible) end(i int) int {
+	if i == 0 {
+		return 0
+	}
+	return v.offsets[i-1] + 1
+}
+
+// split slices s around separators given as ranges of its visible text, in
+// order. Escape sequences within or before a separator are moved to the next
+// part.
+func (v visible) split(s string, seps [][2]int) []string {
+	parts := make([]string, 0, len(seps)+1)
+	start, carry := 0, ""
+	for _, sep := range seps {
+		end := v.end(sep[0])
+		parts = append(parts, carry+s[start:end])
+		start = v.offsets[sep[1]]
+		carry = sequencesOnly(s[end:start])
+	}
+	return append(parts, carry+s[start:])
+}
+
+// trim returns s with only the visible text in [i, j), keeping all escape
+// sequences outside of it.
+func (v visible) trim(s string, i, j int) string {
+	start, end := v.offsets[i], v.end(j)
+	if j == 0 {
+		end = start
+	}
+	return sequencesOnly(s[:start]) + s[start:end] + sequencesOnly(s[end:])
+}
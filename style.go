@@ -0,0 +1,202 @@
+package escapes
+
+import (
+	"io"
+	"strings"
+)
+
+// Attr is a set of text attributes.
+type Attr uint16
+
+// Text attributes, combinable with the bitwise OR operator
+const (
+	AttrBold Attr = 1 << iota
+	AttrItalic
+	AttrUnderline
+	AttrBlink
+	AttrReverse
+	AttrHidden
+	AttrStrikethrough
+	AttrDim
+	AttrRapidBlink
+)
+
+// SGR parameters that enable and disable each attribute, in the order of the
+// constants. Bold and dim share a reset, as do both kinds of blinking.
+var (
+	attrParams    = []string{"1", "3", "4", "5", "7", "8", "9", "2", "6"}
+	attrOffParams = []string{"22", "23", "24", "25", "27", "28", "29", "22", "25"}
+)
+
+// Style is a combination of foreground color, background color and text
+// attributes. The zero value is the terminal's default style. Styles are
+// values; the builder methods return modified copies.
+type Style struct {
+	Fg    Color
+	Bg    Color
+	Attrs Attr
+}
+
+// Foreground returns a copy of s with the foreground color set to c.
+func (s Style) Foreground(c Color) Style {
+	s.Fg = c
+	return s
+}
+
+// Background returns a copy of s with the background color set to c.
+func (s Style) Background(c Color) Style {
+	s.Bg = c
+	return s
+}
+
+// With returns a copy of s with the attributes a added.
+func (s Style) With(a Attr) Style {
+	s.Attrs |= a
+	return s
+}
+
+// Without returns a copy of s with the attributes a removed.
+func (s Style) Without(a Attr) Style {
+	s.Attrs &^= a
+	return s
+}
+
+// IsZero reports whether s is the terminal's default style.
+func (s Style) IsZero() bool {
+	return s == Style{}
+}
+
+// Convert returns a copy of s whose colors are supported by profile p.
+func (s Style) Convert(p Profile) Style {
+	s.Fg = s.Fg.Convert(p)
+	s.Bg = s.Bg.Convert(p)
+	return s
+}
+
+// Sequence returns an escape sequence that applies s, starting from the
+// default style. Colors are converted to the current color profile. An empty
+// string is returned for the default style.
+func (s Style) Sequence() string {
+	return string(s.AppendSequence(nil))
+}
+
+// AppendSequence appends the escape sequence returned by Sequence to b and
+// returns the extended buffer.
+func (s Style) AppendSequence(b []byte) []byte {
+	s = s.Convert(CurrentProfile())
+	start := len(b)
+	b = append(b, Esc...)
+	sep := false
+	param := func() {
+		if sep {
+			b = append(b, ';')
+		}
+		sep = true
+	}
+	for i, p := range attrParams {
+		if s.Attrs&(1<<uint(i)) != 0 {
+			param()
+			b = append(b, p...)
+		}
+	}
+	if !s.Fg.IsDefault() {
+		param()
+		b = s.Fg.appendParams(b, false)
+	}
+	if !s.Bg.IsDefault() {
+		param()
+		b = s.Bg.appendParams(b, true)
+	}
+	if !sep {
+		return b[:start]
+	}
+	return append(b, 'm')
+}
+
+// WriteTo writes the escape sequence returned by Sequence to w. It implements
+// io.WriterTo.
+func (s Style) WriteTo(w io.Writer) (int64, error) {
+	n, err := writeAppended(w, s.AppendSequence)
+	return int64(n), err
+}
+
+// Render returns text styled with s, followed by a reset to the default style.
+func (s Style) Render(text string) string {
+	seq := s.Sequence()
+	if seq == "" {
+		return text
+	}
+	return seq + text + ColorReset
+}
+
+// params returns the SGR parameters that apply s from the default style.
+func (s Style) params() []string {
+	var params []string
+	for i, p := range attrParams {
+		if s.Attrs&(1<<uint(i)) != 0 {
+			params = append(params, p)
+		}
+	}
+	if !s.Fg.IsDefault() {
+		params = append(params, s.Fg.params(false))
+	}
+	if !s.Bg.IsDefault() {
+		params = append(params, s.Bg.params(true))
+	}
+	return params
+}
+
+// Transition returns the shortest escape sequence that changes the active
+// style from one style to another. Only the attributes and colors that differ
+// are changed, unless a full reset followed by the new style is shorter. An
+// empty string is returned if both styles render the same.
+func Transition(from, to Style) string {
+	p := CurrentProfile()
+	return transition(from.Convert(p), to.Convert(p))
+}
+
+// transition is like Transition, but uses the colors of both styles as is.
+func transition(from, to Style) string {
+	if from == to {
+		return ""
+	}
+
+	var delta []string
+	hasParam := func(p string) bool {
+		for _, d := range delta {
+			if d == p {
+				return true
+			}
+		}
+		return false
+	}
+	for i, off := range attrOffParams {
+		a := Attr(1 << uint(i))
+		if from.Attrs&a != 0 && to.Attrs&a == 0 && !hasParam(off) {
+			delta = append(delta, off)
+		}
+	}
+	// Attributes whose reset was shared with one turned off are set again
+	for i, on := range attrParams {
+		a := Attr(1 << uint(i))
+		if to.Attrs&a != 0 && (from.Attrs&a == 0 || hasParam(attrOffParams[i])) {
+			delta = append(delta, on)
+		}
+	}
+	if from.Fg != to.Fg {
+		delta = append(delta, to.Fg.params(false))
+	}
+	if from.Bg != to.Bg {
+		delta = append(delta, to.Bg.params(true))
+	}
+
+	// An empty first parameter is equivalent to 0, resetting all attributes
+	reset := append([]string{""}, to.params()...)
+	if to.IsZero() {
+		reset = nil
+	}
+	if len(strings.Join(reset, ";")) < len(strings.Join(delta, ";")) {
+		delta = reset
+	}
+	return Esc + strings.Join(delta, ";") + "m"
+}
@@ -0,0 +1,73 @@
+package escapes
+
+import (
+	"io"
+	"sync"
+)
+
+// SyncWriter serializes writes from multiple goroutines to a terminal. Each
+// call to one of its methods is written in full before any other, so escape
+// sequences and styled messages from concurrent goroutines never interleave.
+// Output that is built from several writes must go through Do to be atomic.
+type SyncWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf Buffer
+}
+
+// NewSyncWriter returns a SyncWriter that writes to w.
+func NewSyncWriter(w io.Writer) *SyncWriter {
+	return &SyncWriter{w: w, buf: Buffer{w: w}}
+}
+
+// Write writes p to the underlying writer, retrying short writes, before any
+// other write is started.
+func (w *SyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return writeFull(w.w, p)
+}
+
+// WriteString is like Write, but writes the contents of s.
+func (w *SyncWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteStyled writes text styled with style as a single message.
+func (w *SyncWriter) WriteStyled(style Style, text string) error {
+	_, err := w.WriteString(style.Render(text))
+	return err
+}
+
+// Do calls fn with a Buffer, then writes everything fn wrote to the buffer
+// in a single write. Other writes to w wait until Do returns. Nothing is
+// written if fn returns an error.
+func (w *SyncWriter) Do(fn func(b *Buffer) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Reset()
+	if err := fn(&w.buf); err != nil {
+		w.buf.Reset()
+		return err
+	}
+	_, err := writeFull(w.w, w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// writeFull writes p to w, retrying for as long as w makes progress.
+func writeFull(w io.Writer, p []byte) (int, error) {
+	var written int
+	for written < len(p) {
+		n, err := w.Write(p[written:])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n == 0 {
+			return written, io.ErrShortWrite
+		}
+	}
+	return written, nil
+}
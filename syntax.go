@@ -0,0 +1,132 @@
+package escapes
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+// TokenStyles maps the types of tokens of source code to the styles they are
+// highlighted with. Types are named as by the chroma lexers, such as
+// "KeywordConstant" or "LiteralStringDouble". A type without a style of its
+// own takes the style of its closest category, found by removing words from
+// the end of its name, so that "LiteralStringDouble" falls back to
+// "LiteralString", then to "Literal".
+type TokenStyles map[string]Style
+
+// DefaultTokenStyles highlights code with the colors of DefaultTheme.
+var DefaultTokenStyles = TokenStylesFromTheme(DefaultTheme)
+
+// TokenStylesFromTheme returns token styles that highlight code with the
+// colors of t.
+func TokenStylesFromTheme(t Theme) TokenStyles {
+	return TokenStyles{
+		"Text":            t.Text,
+		"Error":           t.Error,
+		"Comment":         t.Muted.With(AttrItalic),
+		"CommentPreproc":  t.Info,
+		"Keyword":         t.Accent,
+		"KeywordType":     t.Info,
+		"NameBuiltin":     t.Info,
+		"NameFunction":    t.Text.With(AttrBold),
+		"NameClass":       t.Text.With(AttrBold),
+		"NameTag":         t.Accent,
+		"NameAttribute":   t.Info,
+		"LiteralString":   t.Success,
+		"LiteralNumber":   t.Warning,
+		"GenericDeleted":  t.Error,
+		"GenericInserted": t.Success,
+		"GenericHeading":  t.Accent.With(AttrBold),
+		"GenericEmph":     t.Text.With(AttrItalic),
+		"GenericStrong":   t.Text.With(AttrBold),
+	}
+}
+
+// Style returns the style of tokens of type typ.
+func (s TokenStyles) Style(typ string) Style {
+	for typ != "" {
+		if style, ok := s[typ]; ok {
+			return style
+		}
+		i := strings.LastIndexFunc(typ, unicode.IsUpper)
+		if i < 0 {
+			break
+		}
+		typ = typ[:i]
+	}
+	return Style{}
+}
+
+// CodeToken is a token of source code, with the fields of the tokens of the
+// chroma lexers.
+type CodeToken struct {
+	Type  string
+	Value string
+}
+
+// Highlight returns tokens of source code highlighted with styles, as written
+// by a SyntaxWriter.
+func Highlight(tokens []CodeToken, styles TokenStyles) string {
+	var b strings.Builder
+	s := NewSyntaxWriter(&b, styles)
+	for _, t := range tokens {
+		s.WriteToken(t.Type, t.Value)
+	}
+	s.Close()
+	return b.String()
+}
+
+// SyntaxWriter writes tokens of source code highlighted with styles, such as
+// produced by the chroma lexers:
+//
+//	s := escapes.NewSyntaxWriter(os.Stdout, escapes.DefaultTokenStyles)
+//	for t := it(); t != chroma.EOF; t = it() {
+//		s.WriteToken(t.Type.String(), t.Value)
+//	}
+//	s.Close()
+//
+// Styles are changed with the shortest sequences, using the colors of the
+// current color profile, and are reset at the end of every line.
+type SyntaxWriter struct {
+	w      io.Writer
+	styles TokenStyles
+	style  Style
+	err    error
+}
+
+// NewSyntaxWriter returns a SyntaxWriter that writes to w.
+func NewSyntaxWriter(w io.Writer, styles TokenStyles) *SyntaxWriter {
+	return &SyntaxWriter{w: w, styles: styles}
+}
+
+// WriteToken writes the value of a token of type typ. Once writing fails,
+// nothing more is written and the error is returned.
+func (s *SyntaxWriter) WriteToken(typ, value string) error {
+	style := s.styles.Style(typ)
+	var b strings.Builder
+	for i, line := range strings.Split(value, "\n") {
+		if i > 0 {
+			b.WriteString(Transition(s.style, Style{}) + "\n")
+			s.style = Style{}
+		}
+		if line != "" {
+			b.WriteString(Transition(s.style, style) + line)
+			s.style = style
+		}
+	}
+	return s.write(b.String())
+}
+
+// Close resets the style. It does not close the underlying writer.
+func (s *SyntaxWriter) Close() error {
+	err := s.write(Transition(s.style, Style{}))
+	s.style = Style{}
+	return err
+}
+
+func (s *SyntaxWriter) write(str string) error {
+	if s.err == nil && str != "" {
+		_, s.err = io.WriteString(s.w, str)
+	}
+	return s.err
+}
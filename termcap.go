@@ -0,0 +1,73 @@
+package escapes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+)
+
+// RequestTermcap returns the sequences requesting the values of terminfo or
+// termcap capabilities, such as "colors", "Co", "smcup" or "TN" (the name of
+// the terminal), with XTGETTCAP. Each capability is requested separately, as
+// some terminals stop at the first unknown one.
+func RequestTermcap(names ...string) string {
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(Dcs + "+q" + strings.ToUpper(hex.EncodeToString([]byte(name))) + St)
+	}
+	return b.String()
+}
+
+// ParseTermcap parses the replies to RequestTermcap, returning the values of
+// the capabilities the terminal knows by name. Boolean capabilities have empty
+// values, and numeric ones are in decimal, as in terminfo.
+func ParseTermcap(reply []byte) map[string]string {
+	caps := make(map[string]string)
+	s := string(reply)
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+
+		if !strings.HasPrefix(seq, Dcs+"1+r") {
+			continue
+		}
+		body := strings.TrimSuffix(strings.TrimSuffix(seq[5:], St), Bel)
+		for _, field := range strings.Split(body, ";") {
+			hexName, hexValue, _ := strings.Cut(field, "=")
+			name, err := hex.DecodeString(hexName)
+			if err != nil || len(name) == 0 {
+				continue
+			}
+			value, err := hex.DecodeString(hexValue)
+			if err != nil {
+				continue
+			}
+			caps[string(name)] = string(value)
+		}
+	}
+	return caps
+}
+
+// QueryTermcap queries the values of terminfo or termcap capabilities from the
+// terminal with XTGETTCAP, as supported by xterm, kitty, foot, WezTerm and
+// others. It returns the capabilities the terminal knows; an empty map means
+// that it knows none of them, or does not support the query. See Query for the
+// requirements on w and r.
+func QueryTermcap(w io.Writer, r io.Reader, timeout time.Duration, names ...string) (map[string]string, error) {
+	// End with a device status report, answered by every terminal, to
+	// avoid waiting for the timeout for unknown capabilities
+	reply, err := Query(w, r, RequestTermcap(names...)+Esc+"5n", func(b []byte) bool {
+		return bytes.Contains(b, []byte(Esc+"0n"))
+	}, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTermcap(reply), nil
+}
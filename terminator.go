@@ -0,0 +1,40 @@
+package escapes
+
+import "sync/atomic"
+
+// Terminator is the ending of OSC sequences built by this package.
+type Terminator int
+
+// Terminators of OSC sequences
+const (
+	// TerminatorBEL ends sequences with BEL, which xterm introduced and most
+	// terminals accept. It is the default.
+	TerminatorBEL Terminator = iota
+
+	// TerminatorST ends sequences with ESC \, the string terminator of the
+	// standard, which some terminals and multiplexers require, while others
+	// expect replies to queries to use the same terminator as the query.
+	TerminatorST
+)
+
+var oscTerminator int32
+
+// OSCTerminator returns the ending of the OSC sequences built by this
+// package, such as by Link, Image, SetCwd and SetPointerShape.
+func OSCTerminator() Terminator {
+	return Terminator(atomic.LoadInt32(&oscTerminator))
+}
+
+// SetOSCTerminator sets the ending of the OSC sequences built by this package.
+// Constants such as ReportCellSizeITerm always end with BEL.
+func SetOSCTerminator(t Terminator) {
+	atomic.StoreInt32(&oscTerminator, int32(t))
+}
+
+// oscEnd returns the ending of OSC sequences.
+func oscEnd() string {
+	if OSCTerminator() == TerminatorST {
+		return St
+	}
+	return Bel
+}
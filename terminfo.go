@@ -0,0 +1,375 @@
+package escapes
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Terminfo describes the capabilities of a type of terminal, as in the
+// terminfo database, for terminals whose sequences differ from the xterm ones
+// used by the rest of this package. Capabilities are named as in terminfo(5),
+// such as "cup" or "setaf".
+type Terminfo struct {
+	Name    string
+	Bools   map[string]bool
+	Numbers map[string]int
+	Strings map[string]string
+}
+
+// LookupTerminfo returns the description of a terminal type from a subset of
+// the terminfo database compiled into the package, covering common terminals.
+// Types that are not included, but belong to a known family such as
+// "xterm-ghostty" or "screen.xterm-256color", are resolved to the family.
+func LookupTerminfo(term string) (*Terminfo, bool) {
+	if ti, ok := terminfoDB[term]; ok {
+		return ti, true
+	}
+	if alias, ok := terminfoAliases[term]; ok {
+		return terminfoDB[alias], true
+	}
+
+	// Fall back to the family of the terminal, keeping the color support
+	// suggested by its name
+	family := term
+	if i := strings.IndexAny(family, "-."); i >= 0 {
+		family = family[:i]
+	}
+	if strings.Contains(term, "256color") || strings.Contains(term, "direct") {
+		if ti, ok := terminfoDB[family+"-256color"]; ok {
+			return ti, true
+		}
+	}
+	if ti, ok := terminfoDB[family]; ok && family != term {
+		return ti, true
+	}
+	return nil, false
+}
+
+// CurrentTerminfo returns the description of the terminal named by the TERM
+// environment variable, or nil if it is unknown.
+func CurrentTerminfo() *Terminfo {
+	ti, _ := LookupTerminfo(os.Getenv("TERM"))
+	return ti
+}
+
+// Has reports whether t has a string capability. A nil Terminfo has none.
+func (t *Terminfo) Has(name string) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.Strings[name]
+	return ok
+}
+
+// Sequence returns a string capability with the given parameters applied, as
+// by tparm(3), and padding removed. It returns false if t lacks the
+// capability.
+func (t *Terminfo) Sequence(name string, params ...int) (string, bool) {
+	if !t.Has(name) {
+		return "", false
+	}
+	return tparm(t.Strings[name], params...), true
+}
+
+// SequenceOr is like Sequence, but returns fallback if t lacks the capability.
+func (t *Terminfo) SequenceOr(name, fallback string, params ...int) string {
+	if s, ok := t.Sequence(name, params...); ok {
+		return s
+	}
+	return fallback
+}
+
+// terminfoCaps maps the constants of this package to the equivalent terminfo
+// capabilities.
+var terminfoCaps = map[string]string{
+	CursorUp:              "cuu1",
+	CursorForward:         "cuf1",
+	CursorTopLeft:         "home",
+	CursorSave:            "sc",
+	CursorRestore:         "rc",
+	CursorShow:            "cnorm",
+	CursorHide:            "civis",
+	AltScreenEnable:       "smcup",
+	AltScreenDisable:      "rmcup",
+	EraseRight:            "el",
+	EraseLeft:             "el1",
+	EraseDown:             "ed",
+	TextInsertChar:        "ich1",
+	TextDeleteChar:        "dch1",
+	TextInsertLine:        "il1",
+	TextDeleteLine:        "dl1",
+	ColorReset:            "sgr0",
+	TextDim:               "dim",
+	BracketedPasteEnable:  "BE",
+	BracketedPasteDisable: "BD",
+}
+
+// Translate returns the terminal's own sequence for one of the constants of
+// this package, such as CursorHide or AltScreenEnable, or seq itself if the
+// terminal lacks the capability or the constant has no equivalent.
+func (t *Terminfo) Translate(seq string) string {
+	if name, ok := terminfoCaps[seq]; ok {
+		return t.SequenceOr(name, seq)
+	}
+	return seq
+}
+
+// CursorPos returns the terminal's sequence to move the cursor to a coordinate
+// pair, where (0, 0) is the origin, falling back to CursorPos.
+func (t *Terminfo) CursorPos(x, y int) string {
+	return t.SequenceOr("cup", CursorPos(x, y), y, x)
+}
+
+// CursorPosX returns the terminal's sequence to move the cursor to a column,
+// falling back to CursorPosX.
+func (t *Terminfo) CursorPosX(x int) string {
+	return t.SequenceOr("hpa", CursorPosX(x), x)
+}
+
+// CursorPosY returns the terminal's sequence to move the cursor to a row,
+// falling back to CursorPosY.
+func (t *Terminfo) CursorPosY(y int) string {
+	return t.SequenceOr("vpa", CursorPosY(y), y)
+}
+
+// Colors returns the number of colors the terminal supports, or 0 if unknown.
+func (t *Terminfo) Colors() int {
+	if t == nil {
+		return 0
+	}
+	return t.Numbers["colors"]
+}
+
+// Foreground returns the terminal's sequence to set the foreground to a
+// palette color, falling back to the SGR sequence for it if the terminal
+// lacks the capability or has fewer colors.
+func (t *Terminfo) Foreground(i int) string {
+	fallback := Esc + terminfoPalette(i).params(false) + "m"
+	if i >= t.Colors() {
+		return fallback
+	}
+	return t.SequenceOr("setaf", fallback, i)
+}
+
+// Background returns the terminal's sequence to set the background to a
+// palette color, falling back to the SGR sequence for it if the terminal
+// lacks the capability or has fewer colors.
+func (t *Terminfo) Background(i int) string {
+	fallback := Esc + terminfoPalette(i).params(true) + "m"
+	if i >= t.Colors() {
+		return fallback
+	}
+	return t.SequenceOr("setab", fallback, i)
+}
+
+func terminfoPalette(i int) Color {
+	if i < 16 {
+		return ANSIColor(i)
+	}
+	return IndexedColor(i)
+}
+
+// tparm expands the parameterized string of a capability, as by tparm(3), and
+// removes padding delays of the form $<n>. Unsupported operations, such as
+// string parameters, expand to nothing.
+func tparm(s string, params ...int) string {
+	var (
+		b     strings.Builder
+		stack []int
+		vars  [52]int
+		p     [9]int
+	)
+	copy(p[:], params)
+	push := func(v int) { stack = append(stack, v) }
+	pop := func() int {
+		if len(stack) == 0 {
+			return 0
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	boolInt := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '$' && i+1 < len(s) && s[i+1] == '<' {
+			if end := strings.IndexByte(s[i:], '>'); end >= 0 {
+				i += end
+				continue
+			}
+		}
+		if c != '%' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch op := s[i]; op {
+		case '%':
+			b.WriteByte('%')
+		case 'c':
+			b.WriteByte(byte(pop()))
+		case 'd', 'o', 'x', 'X', ':', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '.':
+			// Formatted output, with optional flags and width
+			j := i
+			for j < len(s) && strings.IndexByte("doxX", s[j]) < 0 {
+				j++
+			}
+			if j == len(s) {
+				return b.String()
+			}
+			b.WriteString(tparmFormat(strings.TrimPrefix(s[i:j], ":"), s[j], pop()))
+			i = j
+		case 'p':
+			if i+1 < len(s) && s[i+1] >= '1' && s[i+1] <= '9' {
+				i++
+				push(p[s[i]-'1'])
+			}
+		case 'P', 'g':
+			if i+1 < len(s) {
+				i++
+				v := s[i]
+				idx := -1
+				if v >= 'a' && v <= 'z' {
+					idx = int(v - 'a')
+				} else if v >= 'A' && v <= 'Z' {
+					idx = 26 + int(v-'A')
+				}
+				if idx >= 0 && op == 'P' {
+					vars[idx] = pop()
+				} else if idx >= 0 {
+					push(vars[idx])
+				}
+			}
+		case '\'':
+			if i+2 < len(s) {
+				push(int(s[i+1]))
+				i += 2
+			}
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return b.String()
+			}
+			n, _ := strconv.Atoi(s[i+1 : i+end])
+			push(n)
+			i += end
+		case 'i':
+			p[0]++
+			p[1]++
+		case '+', '-', '*', '/', 'm', '&', '|', '^', '=', '<', '>', 'A', 'O':
+			y, x := pop(), pop()
+			switch op {
+			case '+':
+				push(x + y)
+			case '-':
+				push(x - y)
+			case '*':
+				push(x * y)
+			case '/':
+				if y != 0 {
+					push(x / y)
+				} else {
+					push(0)
+				}
+			case 'm':
+				if y != 0 {
+					push(x % y)
+				} else {
+					push(0)
+				}
+			case '&':
+				push(x & y)
+			case '|':
+				push(x | y)
+			case '^':
+				push(x ^ y)
+			case '=':
+				push(boolInt(x == y))
+			case '<':
+				push(boolInt(x < y))
+			case '>':
+				push(boolInt(x > y))
+			case 'A':
+				push(boolInt(x != 0 && y != 0))
+			case 'O':
+				push(boolInt(x != 0 || y != 0))
+			}
+		case '!':
+			push(boolInt(pop() == 0))
+		case '~':
+			push(^pop())
+		case '?', ';':
+		case 't':
+			if pop() == 0 {
+				// Skip to the matching %e or %;
+				i = tparmSkip(s, i+1, true)
+			}
+		case 'e':
+			// The then part was taken, so skip the else part
+			i = tparmSkip(s, i+1, false)
+		}
+	}
+	return b.String()
+}
+
+// tparmSkip returns the index of the last byte of the %e (if elseOK) or %; at
+// the same nesting level, starting at i.
+func tparmSkip(s string, i int, elseOK bool) int {
+	depth := 0
+	for ; i+1 < len(s); i++ {
+		if s[i] != '%' {
+			continue
+		}
+		i++
+		switch s[i] {
+		case '?':
+			depth++
+		case ';':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case 'e':
+			if depth == 0 && elseOK {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// tparmFormat formats a number like printf with the given flags and width.
+func tparmFormat(flags string, verb byte, v int) string {
+	base := 10
+	switch verb {
+	case 'o':
+		base = 8
+	case 'x', 'X':
+		base = 16
+	}
+	s := strconv.FormatInt(int64(v), base)
+	if verb == 'X' {
+		s = strings.ToUpper(s)
+	}
+
+	pad := " "
+	if strings.HasPrefix(flags, "0") {
+		pad = "0"
+	}
+	width, _ := strconv.Atoi(strings.TrimLeft(flags, "-+# 0"))
+	if n := width - len(s); n > 0 {
+		if strings.Contains(flags, "-") {
+			return s + strings.Repeat(" ", n)
+		}
+		return strings.Repeat(pad, n) + s
+	}
+	return s
+}
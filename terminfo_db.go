@@ -0,0 +1,281 @@
+package escapes
+
+// A subset of the terminfo database for common terminals, limited to the
+// capabilities used for output and the most common keys. Entries are built
+// from the xterm one by replacing the capabilities that differ, as in the
+// "use=" clauses of the terminfo sources.
+
+var xtermCaps = map[string]string{
+	"bel":   "\a",
+	"blink": "\x1b[5m",
+	"bold":  "\x1b[1m",
+	"cbt":   "\x1b[Z",
+	"civis": "\x1b[?25l",
+	"clear": "\x1b[H\x1b[2J",
+	"cnorm": "\x1b[?12l\x1b[?25h",
+	"cr":    "\r",
+	"csr":   "\x1b[%i%p1%d;%p2%dr",
+	"cub":   "\x1b[%p1%dD",
+	"cub1":  "\b",
+	"cud":   "\x1b[%p1%dB",
+	"cud1":  "\n",
+	"cuf":   "\x1b[%p1%dC",
+	"cuf1":  "\x1b[C",
+	"cup":   "\x1b[%i%p1%d;%p2%dH",
+	"cuu":   "\x1b[%p1%dA",
+	"cuu1":  "\x1b[A",
+	"cvvis": "\x1b[?12;25h",
+	"dch":   "\x1b[%p1%dP",
+	"dch1":  "\x1b[P",
+	"dim":   "\x1b[2m",
+	"dl":    "\x1b[%p1%dM",
+	"dl1":   "\x1b[M",
+	"ech":   "\x1b[%p1%dX",
+	"ed":    "\x1b[J",
+	"el":    "\x1b[K",
+	"el1":   "\x1b[1K",
+	"home":  "\x1b[H",
+	"hpa":   "\x1b[%i%p1%dG",
+	"ich":   "\x1b[%p1%d@",
+	"il":    "\x1b[%p1%dL",
+	"il1":   "\x1b[L",
+	"ind":   "\n",
+	"invis": "\x1b[8m",
+	"op":    "\x1b[39;49m",
+	"rc":    "\x1b8",
+	"rev":   "\x1b[7m",
+	"ri":    "\x1bM",
+	"ritm":  "\x1b[23m",
+	"rmcup": "\x1b[?1049l\x1b[23;0;0t",
+	"rmkx":  "\x1b[?1l\x1b>",
+	"rmso":  "\x1b[27m",
+	"rmul":  "\x1b[24m",
+	"sc":    "\x1b7",
+	"setab": "\x1b[4%p1%dm",
+	"setaf": "\x1b[3%p1%dm",
+	"sgr0":  "\x1b(B\x1b[m",
+	"sitm":  "\x1b[3m",
+	"smcup": "\x1b[?1049h\x1b[22;0;0t",
+	"smkx":  "\x1b[?1h\x1b=",
+	"smso":  "\x1b[7m",
+	"smul":  "\x1b[4m",
+	"vpa":   "\x1b[%i%p1%dd",
+	"BE":    "\x1b[?2004h",
+	"BD":    "\x1b[?2004l",
+	"kbs":   "\x7f",
+	"kcbt":  "\x1b[Z",
+	"kcub1": "\x1bOD",
+	"kcud1": "\x1bOB",
+	"kcuf1": "\x1bOC",
+	"kcuu1": "\x1bOA",
+	"kdch1": "\x1b[3~",
+	"kend":  "\x1bOF",
+	"khome": "\x1bOH",
+	"kich1": "\x1b[2~",
+	"knp":   "\x1b[6~",
+	"kpp":   "\x1b[5~",
+	"kf1":   "\x1bOP",
+	"kf2":   "\x1bOQ",
+	"kf3":   "\x1bOR",
+	"kf4":   "\x1bOS",
+	"kf5":   "\x1b[15~",
+	"kf6":   "\x1b[17~",
+	"kf7":   "\x1b[18~",
+	"kf8":   "\x1b[19~",
+	"kf9":   "\x1b[20~",
+	"kf10":  "\x1b[21~",
+	"kf11":  "\x1b[23~",
+	"kf12":  "\x1b[24~",
+}
+
+// Color capabilities of 256-color terminals
+var color256Caps = map[string]string{
+	"setaf": "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+	"setab": "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+}
+
+// Color capabilities of 88-color terminals, whose palette has a 4x4x4 color
+// cube and 8 grays after the 16 ANSI colors. Indices beyond it set the
+// default color.
+var color88Caps = map[string]string{
+	"setaf": "\x1b[%?%p1%{8}%<%t%p1%{30}%+%d%e%p1%{16}%<%t%p1%{82}%+%d%e%p1%{88}%<%t38;5;%p1%d%e39%;m",
+	"setab": "\x1b[%?%p1%{8}%<%t%p1%{40}%+%d%e%p1%{16}%<%t%p1%{92}%+%d%e%p1%{88}%<%t48;5;%p1%d%e49%;m",
+}
+
+// Capabilities of screen that differ from xterm, or that it lacks (as empty
+// strings)
+var screenCaps = map[string]string{
+	"cnorm": "\x1b[34h\x1b[?25h",
+	"cvvis": "\x1b[34l",
+	"dim":   "\x1b[2m",
+	"ech":   "",
+	"hpa":   "",
+	"vpa":   "",
+	"op":    "\x1b[39;49m",
+	"ritm":  "",
+	"sitm":  "",
+	"rmcup": "\x1b[?1049l",
+	"smcup": "\x1b[?1049h",
+	"sgr0":  "\x1b[m\x0f",
+	"kend":  "\x1b[4~",
+	"khome": "\x1b[1~",
+	"BE":    "",
+	"BD":    "",
+}
+
+// Capabilities of tmux that differ from screen
+var tmuxCaps = map[string]string{
+	"ritm": "\x1b[23m",
+	"sitm": "\x1b[3m",
+	"BE":   "\x1b[?2004h",
+	"BD":   "\x1b[?2004l",
+}
+
+// Capabilities of the Linux console that differ from xterm
+var linuxCaps = map[string]string{
+	"civis": "\x1b[?25l\x1b[?1c",
+	"clear": "\x1b[H\x1b[J",
+	"cnorm": "\x1b[?25h\x1b[?0c",
+	"cvvis": "\x1b[?25h\x1b[?8c",
+	"cuf1":  "\x1b[C",
+	"ritm":  "",
+	"sitm":  "",
+	"rmcup": "",
+	"smcup": "",
+	"rmkx":  "",
+	"smkx":  "",
+	"sgr0":  "\x1b[m\x0f",
+	"kcub1": "\x1b[D",
+	"kcud1": "\x1b[B",
+	"kcuf1": "\x1b[C",
+	"kcuu1": "\x1b[A",
+	"kend":  "\x1b[4~",
+	"khome": "\x1b[1~",
+	"kf1":   "\x1b[[A",
+	"kf2":   "\x1b[[B",
+	"kf3":   "\x1b[[C",
+	"kf4":   "\x1b[[D",
+	"kf5":   "\x1b[[E",
+	"BE":    "",
+	"BD":    "",
+}
+
+// Capabilities of rxvt-unicode that differ from xterm
+var rxvtCaps = map[string]string{
+	"cnorm": "\x1b[?25h",
+	"cvvis": "\x1b[?25h",
+	"rmcup": "\x1b[r\x1b[?1049l",
+	"smcup": "\x1b[?1049h",
+	"sgr0":  "\x1b[m\x1b(B",
+	"kend":  "\x1b[8~",
+	"khome": "\x1b[7~",
+	"kf1":   "\x1b[11~",
+	"kf2":   "\x1b[12~",
+	"kf3":   "\x1b[13~",
+	"kf4":   "\x1b[14~",
+}
+
+// The VT100 lacks colors and most editing capabilities, and needs padding
+var vt100Caps = map[string]string{
+	"bel":   "\a",
+	"blink": "\x1b[5m$<2>",
+	"bold":  "\x1b[1m$<2>",
+	"clear": "\x1b[H\x1b[J$<50>",
+	"cr":    "\r",
+	"csr":   "\x1b[%i%p1%d;%p2%dr",
+	"cub":   "\x1b[%p1%dD",
+	"cub1":  "\b",
+	"cud":   "\x1b[%p1%dB",
+	"cud1":  "\n",
+	"cuf":   "\x1b[%p1%dC",
+	"cuf1":  "\x1b[C$<2>",
+	"cup":   "\x1b[%i%p1%d;%p2%dH$<5>",
+	"cuu":   "\x1b[%p1%dA",
+	"cuu1":  "\x1b[A$<2>",
+	"ed":    "\x1b[J$<50>",
+	"el":    "\x1b[K$<3>",
+	"el1":   "\x1b[1K$<3>",
+	"home":  "\x1b[H",
+	"ind":   "\n",
+	"rc":    "\x1b8",
+	"rev":   "\x1b[7m$<2>",
+	"ri":    "\x1bM$<5>",
+	"rmkx":  "\x1b[?1l\x1b>",
+	"rmso":  "\x1b[m$<2>",
+	"rmul":  "\x1b[m$<2>",
+	"sc":    "\x1b7",
+	"sgr0":  "\x1b[m\x0f$<2>",
+	"smkx":  "\x1b[?1h\x1b=",
+	"smso":  "\x1b[7m$<2>",
+	"smul":  "\x1b[4m$<2>",
+	"kbs":   "\b",
+	"kcub1": "\x1bOD",
+	"kcud1": "\x1bOB",
+	"kcuf1": "\x1bOC",
+	"kcuu1": "\x1bOA",
+	"kf1":   "\x1bOP",
+	"kf2":   "\x1bOQ",
+	"kf3":   "\x1bOR",
+	"kf4":   "\x1bOS",
+}
+
+// terminfoDB are the terminals included in the package, by name.
+var terminfoDB = map[string]*Terminfo{
+	"xterm":                 newTerminfo("xterm", 8, xtermCaps),
+	"xterm-256color":        newTerminfo("xterm-256color", 256, xtermCaps, color256Caps),
+	"screen":                newTerminfo("screen", 8, xtermCaps, screenCaps),
+	"screen-256color":       newTerminfo("screen-256color", 256, xtermCaps, screenCaps, color256Caps),
+	"tmux":                  newTerminfo("tmux", 8, xtermCaps, screenCaps, tmuxCaps),
+	"tmux-256color":         newTerminfo("tmux-256color", 256, xtermCaps, screenCaps, tmuxCaps, color256Caps),
+	"linux":                 newTerminfo("linux", 8, xtermCaps, linuxCaps),
+	"rxvt-unicode":          newTerminfo("rxvt-unicode", 88, xtermCaps, rxvtCaps, color88Caps),
+	"rxvt-unicode-256color": newTerminfo("rxvt-unicode-256color", 256, xtermCaps, rxvtCaps, color256Caps),
+	"vt100":                 newTerminfo("vt100", 0, vt100Caps),
+}
+
+// terminfoAliases are terminals whose descriptions are compatible with one of
+// terminfoDB for the capabilities it includes.
+var terminfoAliases = map[string]string{
+	"alacritty":        "xterm-256color",
+	"foot":             "xterm-256color",
+	"wezterm":          "xterm-256color",
+	"xterm-kitty":      "xterm-256color",
+	"xterm-ghostty":    "xterm-256color",
+	"ghostty":          "xterm-256color",
+	"vte-256color":     "xterm-256color",
+	"gnome-256color":   "xterm-256color",
+	"konsole-256color": "xterm-256color",
+	"putty-256color":   "xterm-256color",
+	"st-256color":      "xterm-256color",
+	"rxvt":             "rxvt-unicode",
+	"rxvt-256color":    "rxvt-unicode-256color",
+	"vt102":            "vt100",
+	"vt220":            "vt100",
+	"ansi":             "vt100",
+}
+
+// newTerminfo builds a terminal description from layers of string
+// capabilities, where later layers override earlier ones and empty strings
+// remove capabilities.
+func newTerminfo(name string, colors int, layers ...map[string]string) *Terminfo {
+	t := &Terminfo{
+		Name:    name,
+		Bools:   map[string]bool{"am": true, "xenl": true},
+		Numbers: map[string]int{"cols": 80, "lines": 24, "it": 8},
+		Strings: make(map[string]string),
+	}
+	if colors > 0 {
+		t.Numbers["colors"] = colors
+		t.Numbers["pairs"] = colors * colors
+	}
+	for _, layer := range layers {
+		for k, v := range layer {
+			if v == "" {
+				delete(t.Strings, k)
+			} else {
+				t.Strings[k] = v
+			}
+		}
+	}
+	return t
+}
@@ -0,0 +1,68 @@
+package escapes
+
+import "testing"
+
+func TestTparm(t *testing.T) {
+	tests := []struct {
+		s      string
+		params []int
+		want   string
+	}{
+		{"\x1b[%i%p1%d;%p2%dH", []int{4, 9}, "\x1b[5;10H"},
+		{"\x1b[%p1%dA", []int{12}, "\x1b[12A"},
+		{"\x1b[?5h$<100/>\x1b[?5l", nil, "\x1b[?5h\x1b[?5l"},
+		{"%p1%03d|%p1%x|%p1%X|%p1%o|%p1%:-4d|", []int{31}, "031|1f|1F|37|31  |"},
+		{"%p1%c%'x'%c%%", []int{'a'}, "ax%"},
+		{"%p1%p2%+%d %p1%p2%-%d %p1%p2%*%d %p1%p2%/%d %p1%p2%m%d", []int{7, 2}, "9 5 14 3 1"},
+		{"%p1%{0}%/%d", []int{7}, "0"},
+		{"%p1%Pa%ga%ga%+%d", []int{21}, "42"},
+		{"%?%p1%t1%e0%;", []int{3}, "1"},
+		{"%?%p1%t1%e0%;", []int{0}, "0"},
+		// Nested conditions, and else-if chains
+		{"%?%p1%{2}%<%t%?%p1%t1%e0%;%e%p1%{5}%<%tfew%emany%;", []int{1}, "1"},
+		{"%?%p1%{2}%<%t%?%p1%t1%e0%;%e%p1%{5}%<%tfew%emany%;", []int{3}, "few"},
+		{"%?%p1%{2}%<%t%?%p1%t1%e0%;%e%p1%{5}%<%tfew%emany%;", []int{9}, "many"},
+		{"%p1%!%d %p1%~%d", []int{0}, "1 -1"},
+		// Unterminated operations expand to what precedes them
+		{"x%p1%{3", []int{1}, "x"},
+	}
+	for _, tt := range tests {
+		if got := tparm(tt.s, tt.params...); got != tt.want {
+			t.Errorf("tparm(%q, %v) = %q, want %q", tt.s, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestTerminfoColors(t *testing.T) {
+	tests := []struct {
+		term   string
+		i      int
+		fg, bg string
+	}{
+		{"xterm-256color", 1, "\x1b[31m", "\x1b[41m"},
+		{"xterm-256color", 9, "\x1b[91m", "\x1b[101m"},
+		{"xterm-256color", 200, "\x1b[38;5;200m", "\x1b[48;5;200m"},
+		{"rxvt-unicode", 3, "\x1b[33m", "\x1b[43m"},
+		{"rxvt-unicode", 12, "\x1b[94m", "\x1b[104m"},
+		{"rxvt-unicode", 87, "\x1b[38;5;87m", "\x1b[48;5;87m"},
+		{"rxvt-unicode-256color", 200, "\x1b[38;5;200m", "\x1b[48;5;200m"},
+	}
+	for _, tt := range tests {
+		ti, ok := LookupTerminfo(tt.term)
+		if !ok {
+			t.Fatalf("LookupTerminfo(%q) failed", tt.term)
+		}
+		if got := ti.Foreground(tt.i); got != tt.fg {
+			t.Errorf("%s: Foreground(%d) = %q, want %q", tt.term, tt.i, got, tt.fg)
+		}
+		if got := ti.Background(tt.i); got != tt.bg {
+			t.Errorf("%s: Background(%d) = %q, want %q", tt.term, tt.i, got, tt.bg)
+		}
+	}
+
+	// Indices beyond the 88 colors of rxvt-unicode set the default color
+	ti, _ := LookupTerminfo("rxvt-unicode")
+	if got, _ := ti.Sequence("setaf", 88); got != "\x1b[39m" {
+		t.Errorf("rxvt-unicode: setaf 88 = %q, want %q", got, "\x1b[39m")
+	}
+}
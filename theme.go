@@ -0,0 +1,189 @@
+package escapes
+
+import "math"
+
+// Theme is a set of styles for the semantic roles of text in a program's
+// output, so that the colors can be changed in one place, such as to offer an
+// accessible color scheme.
+type Theme struct {
+	Text    Style
+	Muted   Style
+	Accent  Style
+	Success Style
+	Warning Style
+	Error   Style
+	Info    Style
+
+	// Scale is the gradient used for data, such as charts and heat maps.
+	Scale Gradient
+}
+
+// DefaultTheme uses the basic colors, which the user's terminal theme can
+// adjust.
+var DefaultTheme = Theme{
+	Muted:   Style{Fg: ANSIColor(8)},
+	Accent:  Style{Fg: ANSIColor(12)},
+	Success: Style{Fg: ANSIColor(2)},
+	Warning: Style{Fg: ANSIColor(3)},
+	Error:   Style{Fg: ANSIColor(1)},
+	Info:    Style{Fg: ANSIColor(6)},
+	Scale:   HeatGradient,
+}
+
+// The Okabe-Ito palette, whose colors remain distinguishable with all common
+// forms of color blindness.
+var (
+	OkabeItoOrange        = RGB(0xE6, 0x9F, 0x00)
+	OkabeItoSkyBlue       = RGB(0x56, 0xB4, 0xE9)
+	OkabeItoBluishGreen   = RGB(0x00, 0x9E, 0x73)
+	OkabeItoYellow        = RGB(0xF0, 0xE4, 0x42)
+	OkabeItoBlue          = RGB(0x00, 0x72, 0xB2)
+	OkabeItoVermillion    = RGB(0xD5, 0x5E, 0x00)
+	OkabeItoReddishPurple = RGB(0xCC, 0x79, 0xA7)
+)
+
+// ViridisGradient is a perceptually uniform scale from dark purple to yellow,
+// readable with color blindness and when printed in grayscale.
+var ViridisGradient = Gradient{
+	RGB(0x44, 0x01, 0x54),
+	RGB(0x48, 0x28, 0x78),
+	RGB(0x3E, 0x4A, 0x89),
+	RGB(0x31, 0x68, 0x8E),
+	RGB(0x26, 0x82, 0x8E),
+	RGB(0x1F, 0x9E, 0x89),
+	RGB(0x35, 0xB7, 0x79),
+	RGB(0x6D, 0xCD, 0x59),
+	RGB(0xB4, 0xDE, 0x2C),
+	RGB(0xFD, 0xE7, 0x25),
+}
+
+// CividisGradient is a scale from blue to yellow, designed to look the same
+// with and without red-green color blindness.
+var CividisGradient = Gradient{
+	RGB(0x00, 0x22, 0x4E),
+	RGB(0x12, 0x35, 0x70),
+	RGB(0x3B, 0x49, 0x6C),
+	RGB(0x57, 0x5D, 0x6D),
+	RGB(0x70, 0x71, 0x73),
+	RGB(0x8A, 0x86, 0x78),
+	RGB(0xA5, 0x9C, 0x74),
+	RGB(0xC3, 0xB3, 0x69),
+	RGB(0xE1, 0xCC, 0x55),
+	RGB(0xFE, 0xE8, 0x38),
+}
+
+// OkabeItoTheme uses the Okabe-Ito palette, with blue and orange rather than
+// green and red for success and errors.
+var OkabeItoTheme = Theme{
+	Muted:   Style{Fg: ANSIColor(8)},
+	Accent:  Style{Fg: OkabeItoSkyBlue},
+	Success: Style{Fg: OkabeItoBluishGreen},
+	Warning: Style{Fg: OkabeItoYellow},
+	Error:   Style{Fg: OkabeItoVermillion},
+	Info:    Style{Fg: OkabeItoBlue},
+	Scale:   ViridisGradient,
+}
+
+// ViridisTheme uses colors from the viridis scale for every role.
+var ViridisTheme = Theme{
+	Muted:   Style{Fg: ANSIColor(8)},
+	Accent:  Style{Fg: ViridisGradient.At(0.5)},
+	Success: Style{Fg: ViridisGradient.At(0.7)},
+	Warning: Style{Fg: ViridisGradient.At(1)},
+	Error:   Style{Fg: ViridisGradient.At(0.15), Attrs: AttrBold},
+	Info:    Style{Fg: ViridisGradient.At(0.35)},
+	Scale:   ViridisGradient,
+}
+
+// ColorBlindness is a form of color blindness that can be simulated, to check
+// that colors remain distinguishable.
+type ColorBlindness int
+
+// Forms of color blindness
+const (
+	Protanopia   ColorBlindness = iota // No red cones
+	Deuteranopia                       // No green cones
+	Tritanopia                         // No blue cones
+)
+
+// colorBlindnessMatrices simulate each form of color blindness in linear RGB,
+// from Machado, Oliveira and Fernandes (2009).
+var colorBlindnessMatrices = [...][3][3]float64{
+	Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// Simulate returns how color c appears with color blindness k. The default
+// color is returned unchanged, as its value is unknown.
+func (k ColorBlindness) Simulate(c Color) Color {
+	if c.IsDefault() || k < 0 || int(k) >= len(colorBlindnessMatrices) {
+		return c
+	}
+	m := colorBlindnessMatrices[k]
+	r, g, b := c.rgb()
+	lin := [3]float64{srgbToLinear(r), srgbToLinear(g), srgbToLinear(b)}
+
+	var out [3]uint8
+	for i, row := range m {
+		out[i] = linearToSRGB(row[0]*lin[0] + row[1]*lin[1] + row[2]*lin[2])
+	}
+	return RGB(out[0], out[1], out[2])
+}
+
+// SimulateStyle returns s with both colors as they appear with color
+// blindness k.
+func (k ColorBlindness) SimulateStyle(s Style) Style {
+	s.Fg, s.Bg = k.Simulate(s.Fg), k.Simulate(s.Bg)
+	return s
+}
+
+// Simulate returns t as it appears with color blindness k, so that a theme can
+// be previewed by users without it.
+func (t Theme) Simulate(k ColorBlindness) Theme {
+	for _, s := range []*Style{&t.Text, &t.Muted, &t.Accent, &t.Success, &t.Warning, &t.Error, &t.Info} {
+		*s = k.SimulateStyle(*s)
+	}
+	scale := make(Gradient, len(t.Scale))
+	for i, c := range t.Scale {
+		scale[i] = k.Simulate(c)
+	}
+	t.Scale = scale
+	return t
+}
+
+// srgbToLinear converts an sRGB channel to linear light, from 0 to 1.
+func srgbToLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts linear light to an sRGB channel, clamping it.
+func linearToSRGB(c float64) uint8 {
+	switch {
+	case c <= 0:
+		return 0
+	case c >= 1:
+		return 255
+	case c <= 0.0031308:
+		c *= 12.92
+	default:
+		c = 1.055*math.Pow(c, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(c * 255))
+}
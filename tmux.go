@@ -0,0 +1,19 @@
+package escapes
+
+import (
+	"os"
+	"strings"
+)
+
+// InTmux reports whether the program is running inside tmux, which only passes
+// sequences it does not understand to the terminal through TmuxPassthrough.
+func InTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// TmuxPassthrough wraps an escape sequence so that tmux passes it to the
+// terminal unchanged. tmux 3.3 and later require the allow-passthrough option
+// to be enabled.
+func TmuxPassthrough(seq string) string {
+	return Dcs + "tmux;" + strings.Replace(seq, "\u001B", "\u001B\u001B", -1) + St
+}
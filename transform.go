@@ -0,0 +1,130 @@
+package escapes
+
+import (
+	"io"
+	"sync"
+)
+
+// TransformWriter rewrites the output written to it before passing it to an
+// underlying writer. Escape sequences split across several writes are held
+// back until they are complete, so that they are always transformed whole.
+// It is safe for concurrent use.
+type TransformWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	pending string
+	fn      func(s string) string
+	end     func() string // Returns output held back by fn, if not nil
+}
+
+// maxPending is the length above which an incomplete escape sequence is no
+// longer held back, so that an unterminated string sequence, such as an OSC
+// missing its terminator, does not hold back all the output that follows it.
+// The sequence is then transformed as it is, as by Flush.
+const maxPending = 1 << 20
+
+// newTransformWriter returns a TransformWriter applying fn to output that ends
+// on a complete escape sequence or text.
+func newTransformWriter(w io.Writer, fn func(s string) string) *TransformWriter {
+	return &TransformWriter{w: w, fn: fn}
+}
+
+// Write transforms p and writes it to the underlying writer, except for an
+// incomplete escape sequence at its end.
+func (t *TransformWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.pending + string(p)
+	cut := incompleteTail(s)
+	if len(s)-cut > maxPending {
+		cut = len(s)
+	}
+	t.pending = s[cut:]
+	if cut == 0 {
+		return len(p), nil
+	}
+	if _, err := writeFull(t.w, []byte(t.fn(s[:cut]))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush transforms and writes any output held back, such as an incomplete
+// escape sequence at the end of the output.
+func (t *TransformWriter) Flush() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var s string
+	if t.pending != "" {
+		s = t.fn(t.pending)
+		t.pending = ""
+	}
+	if t.end != nil {
+		s += t.end()
+	}
+	if s == "" {
+		return nil
+	}
+	_, err := writeFull(t.w, []byte(s))
+	return err
+}
+
+// incompleteTail returns the offset of the unterminated escape sequence at the
+// end of s, or len(s) if there is none.
+func incompleteTail(s string) int {
+	for i := 0; i < len(s); {
+		n, complete := sequenceEnd(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		if !complete {
+			return i
+		}
+		i += n
+	}
+	return len(s)
+}
+
+// transformReader applies a function to the output read from a reader, like
+// TransformWriter.
+type transformReader struct {
+	r       io.Reader
+	fn      func(s string) string
+	buf     []byte
+	pending string // Incomplete escape sequence read last
+	out     []byte // Transformed output not read yet
+	err     error
+}
+
+func newTransformReader(r io.Reader, fn func(s string) string) *transformReader {
+	return &transformReader{r: r, fn: fn, buf: make([]byte, 4096)}
+}
+
+func (t *transformReader) Read(p []byte) (int, error) {
+	for len(t.out) == 0 {
+		if t.err != nil {
+			return 0, t.err
+		}
+		n, err := t.r.Read(t.buf)
+		s := t.pending + string(t.buf[:n])
+		cut := incompleteTail(s)
+		if err != nil || len(s)-cut > maxPending {
+			// Nothing more will complete the sequence
+			cut, t.err = len(s), err
+		}
+		t.out = append(t.out[:0], t.fn(s[:cut])...)
+		t.pending = s[cut:]
+	}
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+// NewStripReader returns a reader that removes all escape sequences from the
+// output read from r, such as the colored output of a subprocess.
+func NewStripReader(r io.Reader) io.Reader {
+	return newTransformReader(r, Strip)
+}
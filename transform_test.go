@@ -0,0 +1,24 @@
+package escapes
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTransformWriterPendingLimit(t *testing.T) {
+	var b bytes.Buffer
+	w := NewSequenceWriter(&b, func(t Token) string { return "" })
+	w.Write([]byte(Osc + "0;"))
+	for n := 0; n <= maxPending; n += 4096 {
+		w.Write([]byte(strings.Repeat("x", 4096)))
+	}
+	if len(w.pending) > maxPending {
+		t.Errorf("held back %d bytes of an unterminated sequence, want at most %d", len(w.pending), maxPending)
+	}
+	w.Write([]byte("after"))
+	w.Flush()
+	if got := b.String(); !strings.HasSuffix(got, "after") || strings.Contains(got, Osc) {
+		t.Errorf("wrote %d bytes ending in %q, want the output after the sequence", len(got), got[max(len(got)-10, 0):])
+	}
+}
@@ -0,0 +1,128 @@
+package escapes
+
+import "strings"
+
+// Viewport shows part of some content with more lines, or wider lines, than
+// fit in a rectangle of the terminal, scrolled by offsets. Content may contain
+// colors and attributes, which carry over from line to line.
+type Viewport struct {
+	// X, Y, Width and Height are the position and size of the viewport in
+	// the terminal, where (0, 0) is the top-left corner.
+	X, Y, Width, Height int
+
+	// FullWidth reports whether the viewport spans the whole width of the
+	// terminal, which allows scrolling its contents with a scroll region
+	// instead of redrawing them. The scroll region is removed afterwards.
+	FullWidth bool
+
+	lines            []string
+	xOffset, yOffset int
+
+	drawn          bool // Whether the viewport was rendered since it was invalidated
+	drawnX, drawnY int  // Offsets of the last render
+	changed        bool
+}
+
+// NewViewport returns an empty viewport at the given position and size.
+func NewViewport(x, y, width, height int) *Viewport {
+	return &Viewport{X: x, Y: y, Width: width, Height: height}
+}
+
+// SetContent replaces the content of the viewport, keeping the offsets within
+// its bounds.
+func (v *Viewport) SetContent(content string) {
+	v.lines = v.lines[:0]
+	var active []string
+	for _, line := range strings.Split(content, "\n") {
+		v.lines = append(v.lines, strings.Join(active, "")+line)
+		active = trackSGR(active, line)
+	}
+	v.changed = true
+	v.ScrollTo(v.xOffset, v.yOffset)
+}
+
+// LineCount returns the number of lines of content.
+func (v *Viewport) LineCount() int {
+	return len(v.lines)
+}
+
+// Offset returns the number of columns and lines scrolled past.
+func (v *Viewport) Offset() (x, y int) {
+	return v.xOffset, v.yOffset
+}
+
+// ScrollTo scrolls to the given offsets, limited so that the viewport is not
+// scrolled past the end of the content.
+func (v *Viewport) ScrollTo(x, y int) {
+	maxX := 0
+	for _, line := range v.lines {
+		maxX = max(maxX, StringWidth(line)-v.Width)
+	}
+	v.xOffset = clampInt(x, 0, maxX)
+	v.yOffset = clampInt(y, 0, max(len(v.lines)-v.Height, 0))
+}
+
+// ScrollBy scrolls by the given number of columns and lines, which are
+// negative to scroll back.
+func (v *Viewport) ScrollBy(x, y int) {
+	v.ScrollTo(v.xOffset+x, v.yOffset+y)
+}
+
+// AtBottom reports whether the last line of content is shown.
+func (v *Viewport) AtBottom() bool {
+	return v.yOffset >= len(v.lines)-v.Height
+}
+
+// Invalidate makes the next call to Render redraw the whole viewport, as
+// needed after the terminal was cleared.
+func (v *Viewport) Invalidate() {
+	v.drawn = false
+}
+
+// Render returns the escape sequences that update the viewport in the
+// terminal since the last call. When only the vertical offset changed, by
+// less than the height, the contents of a full-width viewport are scrolled,
+// and only the lines scrolled in are drawn.
+func (v *Viewport) Render() string {
+	var b strings.Builder
+	n := v.yOffset - v.drawnY
+	moved := v.drawn && !v.changed && v.xOffset == v.drawnX
+	switch {
+	case moved && n == 0:
+	case moved && v.FullWidth && n > -v.Height && n < v.Height:
+		b.WriteString(SetScrollRegion(v.Y, v.Y+v.Height-1) + Scroll(n) + ScrollRegionReset)
+		first, last := v.Height-n, v.Height-1
+		if n < 0 {
+			first, last = 0, -n-1
+		}
+		for row := first; row <= last; row++ {
+			b.WriteString(v.renderRow(row))
+		}
+	default:
+		for row := 0; row < v.Height; row++ {
+			b.WriteString(v.renderRow(row))
+		}
+	}
+	v.drawn, v.drawnX, v.drawnY, v.changed = true, v.xOffset, v.yOffset, false
+	return b.String()
+}
+
+// renderRow returns the sequences that draw a row of the viewport, padded to
+// its width.
+func (v *Viewport) renderRow(row int) string {
+	var line string
+	if i := v.yOffset + row; i < len(v.lines) {
+		line = v.lines[i]
+	}
+	head, tail := splitAtWidth(line, v.xOffset)
+	shown, _ := splitAtWidth(sequencesOnly(head.s)+tail.s, v.Width)
+
+	s := CursorPos(v.X, v.Y+row) + shown.s
+	if sequencesOnly(line) != "" {
+		s += ColorReset
+	}
+	if shown.w < v.Width {
+		s += strings.Repeat(" ", v.Width-shown.w)
+	}
+	return s
+}
@@ -0,0 +1,485 @@
+package escapes
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// sequenceLen returns the length in bytes of the escape sequence at the start
+// of s, or 0 if s does not start with an escape sequence. Unterminated
+// sequences extend to the end of s.
+func sequenceLen(s string) int {
+	n, _ := sequenceEnd(s)
+	return n
+}
+
+// sequenceEnd is like sequenceLen, but also reports whether the sequence is
+// terminated, which is false if s ends in the middle of it.
+func sequenceEnd(s string) (n int, complete bool) {
+	if len(s) == 0 || s[0] != AsciiEscape {
+		return 0, false
+	}
+	if len(s) == 1 {
+		return 1, false
+	}
+
+	switch s[1] {
+	case '[':
+		// CSI: parameter and intermediate bytes, then a single final byte
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7E {
+				return i + 1, true
+			}
+		}
+		return len(s), false
+	case ']', 'P', 'X', '^', '_':
+		// OSC, DCS, SOS, PM and APC are terminated by ST, or BEL for OSC
+		for i := 2; i < len(s); i++ {
+			if s[i] == AsciiBell && s[1] == ']' {
+				return i + 1, true
+			}
+			if s[i] == AsciiEscape && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2, true
+			}
+		}
+		return len(s), false
+	default:
+		// Two-character (or longer, with intermediates) escape sequence
+		for i := 1; i < len(s); i++ {
+			if s[i] < 0x20 || s[i] > 0x2F {
+				return i + 1, true
+			}
+		}
+		return len(s), false
+	}
+}
+
+// Strip returns s with all escape sequences removed.
+func Strip(s string) string {
+	if strings.IndexByte(s, AsciiEscape) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		if n := sequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// StringWidth returns the number of columns s occupies when printed, ignoring
+// escape sequences and control characters.
+func StringWidth(s string) int {
+	var w int
+	for i := 0; i < len(s); {
+		if n := sequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w += RuneWidth(r)
+		i += size
+	}
+	return w
+}
+
+// ExpandTabs returns s with tabs replaced by spaces up to the next multiple of
+// tabWidth columns, defaulting to 8. Columns are counted in printed width,
+// ignoring escape sequences, and restart after newlines and carriage returns.
+func ExpandTabs(s string, tabWidth int) string {
+	if strings.IndexByte(s, '\t') < 0 {
+		return s
+	}
+	if tabWidth <= 0 {
+		tabWidth = 8
+	}
+
+	var (
+		b   strings.Builder
+		col int
+	)
+	for i := 0; i < len(s); {
+		if n := sequenceLen(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch r {
+		case '\t':
+			n := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		case '\n', '\r':
+			col = 0
+		default:
+			col += RuneWidth(r)
+		}
+		b.WriteString(s[i-size : i])
+	}
+	return b.String()
+}
+
+// RuneWidth returns the number of columns r occupies when printed: 0 for
+// control and combining characters, 2 for wide East Asian characters and
+// emoji, and 1 otherwise.
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case r < 0x300:
+		return 1
+	case inRanges(r, zeroWidthRanges):
+		return 0
+	case inRanges(r, wideRanges):
+		return 2
+	default:
+		return 1
+	}
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+func inRanges(r rune, ranges []runeRange) bool {
+	lo, hi := 0, len(ranges)
+	for lo < hi {
+		m := (lo + hi) / 2
+		switch {
+		case r < ranges[m].lo:
+			hi = m
+		case r > ranges[m].hi:
+			lo = m + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Combining marks, zero-width spaces/joiners and variation selectors
+var zeroWidthRanges = []runeRange{
+	{0x0300, 0x036F},
+	{0x0483, 0x0489},
+	{0x0591, 0x05BD},
+	{0x0610, 0x061A},
+	{0x064B, 0x065F},
+	{0x0E31, 0x0E31},
+	{0x0E34, 0x0E3A},
+	{0x0E47, 0x0E4E},
+	{0x1AB0, 0x1AFF},
+	{0x1DC0, 0x1DFF},
+	{0x200B, 0x200F},
+	{0x2028, 0x202E},
+	{0x2060, 0x2064},
+	{0x20D0, 0x20FF},
+	{0xFE00, 0xFE0F},
+	{0xFE20, 0xFE2F},
+	{0xFEFF, 0xFEFF},
+	{0xE0100, 0xE01EF},
+}
+
+// East Asian wide and fullwidth characters, and emoji presentation
+var wideRanges = []runeRange{
+	{0x1100, 0x115F},
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x23F0, 0x23F0},
+	{0x23F3, 0x23F3},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267F, 0x267F},
+	{0x2693, 0x2693},
+	{0x26A1, 0x26A1},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x26CE, 0x26CE},
+	{0x26D4, 0x26D4},
+	{0x26EA, 0x26EA},
+	{0x26F2, 0x26F3},
+	{0x26F5, 0x26F5},
+	{0x26FA, 0x26FA},
+	{0x26FD, 0x26FD},
+	{0x2705, 0x2705},
+	{0x270A, 0x270B},
+	{0x2728, 0x2728},
+	{0x274C, 0x274C},
+	{0x274E, 0x274E},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27B0, 0x27B0},
+	{0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xA960, 0xA97F},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF},
+	{0x1B000, 0x1B2FF},
+	{0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A},
+	{0x1F200, 0x1F251},
+	{0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335},
+	{0x1F337, 0x1F37C},
+	{0x1F37E, 0x1F393},
+	{0x1F3A0, 0x1F3CA},
+	{0x1F3CF, 0x1F3D3},
+	{0x1F3E0, 0x1F3F0},
+	{0x1F3F4, 0x1F3F4},
+	{0x1F3F8, 0x1F43E},
+	{0x1F440, 0x1F440},
+	{0x1F442, 0x1F4FC},
+	{0x1F4FF, 0x1F53D},
+	{0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567},
+	{0x1F57A, 0x1F57A},
+	{0x1F595, 0x1F596},
+	{0x1F5A4, 0x1F5A4},
+	{0x1F5FB, 0x1F64F},
+	{0x1F680, 0x1F6C5},
+	{0x1F6CC, 0x1F6CC},
+	{0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7},
+	{0x1F6EB, 0x1F6EC},
+	{0x1F6F4, 0x1F6FC},
+	{0x1F7E0, 0x1F7EB},
+	{0x1F90C, 0x1F93A},
+	{0x1F93C, 0x1F945},
+	{0x1F947, 0x1F9FF},
+	{0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+// Wrap word-wraps s so that no line is wider than width columns, ignoring
+// escape sequences when measuring. Existing line breaks are kept, and words
+// longer than width are broken. Colors and attributes that are active at a
+// line break are reset at the end of the line and reapplied at the start of
+// the next one, so that padding added around the lines is left unstyled.
+func Wrap(s string, width int) string {
+	return strings.Join(wrapLines(s, width), "\n")
+}
+
+// wrapToken is a word or a run of whitespace, with its escape sequences
+type wrapToken struct {
+	s     string
+	w     int
+	space bool
+}
+
+// wrapLines wraps s as per Wrap, returning the individual lines.
+func wrapLines(s string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+
+	var (
+		lines  []string
+		active []string
+	)
+	for _, para := range strings.Split(s, "\n") {
+		var (
+			line  strings.Builder
+			lineW int
+		)
+		line.WriteString(strings.Join(active, ""))
+		breakLine := func() {
+			if len(active) > 0 {
+				line.WriteString(ColorReset)
+			}
+			lines = append(lines, line.String())
+			line.Reset()
+			line.WriteString(strings.Join(active, ""))
+			lineW = 0
+		}
+		emit := func(t wrapToken) {
+			line.WriteString(t.s)
+			lineW += t.w
+			active = trackSGR(active, t.s)
+		}
+
+		var pending *wrapToken
+		for _, t := range splitWrapTokens(para) {
+			t := t
+			if t.space {
+				pending = &t
+				continue
+			}
+
+			if pending != nil {
+				if lineW > 0 && lineW+pending.w+t.w > width {
+					// Drop the whitespace at the break, but keep its sequences
+					seqs := sequencesOnly(pending.s)
+					line.WriteString(seqs)
+					active = trackSGR(active, seqs)
+					breakLine()
+				} else {
+					emit(*pending)
+				}
+				pending = nil
+			}
+
+			if lineW+t.w > width && lineW > 0 {
+				breakLine()
+			}
+			for t.w > width-lineW {
+				head, tail := splitAtWidth(t.s, width-lineW)
+				if head.w == 0 && lineW == 0 {
+					// A single rune that is wider than the line itself
+					head, tail = splitAtWidth(t.s, RuneWidth(firstRune(t.s)))
+				}
+				emit(head)
+				breakLine()
+				t = tail
+			}
+			emit(t)
+		}
+		if pending != nil && lineW+pending.w <= width {
+			emit(*pending)
+		} else if pending != nil {
+			seqs := sequencesOnly(pending.s)
+			line.WriteString(seqs)
+			active = trackSGR(active, seqs)
+		}
+		if len(active) > 0 {
+			line.WriteString(ColorReset)
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// splitWrapTokens splits a single line into alternating words and whitespace.
+func splitWrapTokens(s string) []wrapToken {
+	var (
+		tokens []wrapToken
+		cur    wrapToken
+		start  int
+	)
+	flush := func(i int, space bool) {
+		if i > start && cur.space != space {
+			cur.s = s[start:i]
+			tokens = append(tokens, cur)
+			start = i
+			cur = wrapToken{}
+		}
+		cur.space = space
+	}
+
+	for i := 0; i < len(s); {
+		if n := sequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		flush(i, r == ' ' || r == '\t')
+		cur.w += RuneWidth(r)
+		i += size
+	}
+	if start < len(s) {
+		cur.s = s[start:]
+		tokens = append(tokens, cur)
+	}
+	return tokens
+}
+
+// splitAtWidth splits a word so that the first part is at most width columns
+// wide. Escape sequences directly following the split point stay with the
+// first part.
+func splitAtWidth(s string, width int) (wrapToken, wrapToken) {
+	var w int
+	for i := 0; i < len(s); {
+		if n := sequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := RuneWidth(r)
+		if w+rw > width {
+			return wrapToken{s: s[:i], w: w}, wrapToken{s: s[i:], w: StringWidth(s[i:])}
+		}
+		w += rw
+		i += size
+	}
+	return wrapToken{s: s, w: w}, wrapToken{}
+}
+
+func firstRune(s string) rune {
+	for i := 0; i < len(s); {
+		if n := sequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(s[i:])
+		return r
+	}
+	return 0
+}
+
+// sequencesOnly returns only the escape sequences contained in s.
+func sequencesOnly(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if n := sequenceLen(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// trackSGR updates the list of active SGR sequences with those found in s. A
+// reset clears the list.
+func trackSGR(active []string, s string) []string {
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+		if len(seq) < 3 || seq[1] != '[' || seq[len(seq)-1] != 'm' {
+			continue
+		}
+		params := seq[2 : len(seq)-1]
+		switch {
+		case params == "" || params == "0":
+			active = active[:0]
+		case strings.HasPrefix(params, "0;"):
+			active = append(active[:0], Esc+params[2:]+"m")
+		default:
+			active = append(active, seq)
+		}
+	}
+	return active
+}
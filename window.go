@@ -0,0 +1,124 @@
+package escapes
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrInvalidReply is returned when a reply from the terminal cannot be parsed.
+var ErrInvalidReply = errors.New("escapes: invalid reply from the terminal")
+
+// Window manipulation sequences (XTWINOPS). Terminals commonly ignore the ones
+// affecting the window itself, unless allowed by the user.
+const (
+	WindowDeiconify = Esc + "1t"
+	WindowIconify   = Esc + "2t"
+	WindowRaise     = Esc + "5t"
+	WindowLower     = Esc + "6t"
+
+	// ReportTextAreaPixels requests the size of the text area in pixels,
+	// replied to as CSI 4 ; height ; width t.
+	ReportTextAreaPixels = Esc + "14t"
+
+	// ReportCellSize requests the size of a character cell in pixels,
+	// replied to as CSI 6 ; height ; width t.
+	ReportCellSize = Esc + "16t"
+
+	// ReportTextAreaChars requests the size of the text area in characters,
+	// replied to as CSI 8 ; height ; width t.
+	ReportTextAreaChars = Esc + "18t"
+)
+
+// PixelSize is a size in pixels.
+type PixelSize struct {
+	Width  int
+	Height int
+}
+
+// ParseTextAreaChars parses the reply to ReportTextAreaChars.
+func ParseTextAreaChars(reply []byte) (*ConsoleDim, error) {
+	h, w, ok := findWindowReport(reply, 8)
+	if !ok {
+		return nil, ErrInvalidReply
+	}
+	return &ConsoleDim{Rows: h, Cols: w}, nil
+}
+
+// ParseTextAreaPixels parses the reply to ReportTextAreaPixels.
+func ParseTextAreaPixels(reply []byte) (*PixelSize, error) {
+	h, w, ok := findWindowReport(reply, 4)
+	if !ok {
+		return nil, ErrInvalidReply
+	}
+	return &PixelSize{Width: w, Height: h}, nil
+}
+
+// ParseCellSize parses the reply to ReportCellSize.
+func ParseCellSize(reply []byte) (*PixelSize, error) {
+	h, w, ok := findWindowReport(reply, 6)
+	if !ok {
+		return nil, ErrInvalidReply
+	}
+	return &PixelSize{Width: w, Height: h}, nil
+}
+
+// QueryTextAreaChars queries the size of the text area in characters. See
+// Query for the requirements on w and r.
+func QueryTextAreaChars(w io.Writer, r io.Reader, timeout time.Duration) (*ConsoleDim, error) {
+	reply, err := queryWindowReport(w, r, ReportTextAreaChars, 8, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTextAreaChars(reply)
+}
+
+// QueryTextAreaPixels queries the size of the text area in pixels. See Query
+// for the requirements on w and r.
+func QueryTextAreaPixels(w io.Writer, r io.Reader, timeout time.Duration) (*PixelSize, error) {
+	reply, err := queryWindowReport(w, r, ReportTextAreaPixels, 4, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTextAreaPixels(reply)
+}
+
+// QueryCellSize queries the size of a character cell in pixels. See Query for
+// the requirements on w and r.
+func QueryCellSize(w io.Writer, r io.Reader, timeout time.Duration) (*PixelSize, error) {
+	reply, err := queryWindowReport(w, r, ReportCellSize, 6, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCellSize(reply)
+}
+
+func queryWindowReport(w io.Writer, r io.Reader, request string, code int, timeout time.Duration) ([]byte, error) {
+	return Query(w, r, request, func(b []byte) bool {
+		_, _, ok := findWindowReport(b, code)
+		return ok
+	}, timeout)
+}
+
+// findWindowReport finds a reply of the form CSI code ; height ; width t.
+func findWindowReport(reply []byte, code int) (height, width int, ok bool) {
+	s := string(reply)
+	for i := 0; i < len(s); {
+		n := sequenceLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		seq := s[i : i+n]
+		i += n
+
+		if len(seq) < 3 || seq[:2] != Esc {
+			continue
+		}
+		prefix, params, final := parseCSI(seq)
+		if prefix == "" && final == "t" && len(params) == 3 && params[0] == code {
+			return params[1], params[2], true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,84 @@
+package escapes
+
+import (
+	"io"
+	"strconv"
+)
+
+// The Append functions build the sequences of the functions of the same name
+// into a buffer, and the Write functions write them to a writer, without
+// allocating strings. They suit programs writing many sequences per frame.
+// Constant sequences, such as EraseRight, are written with io.WriteString,
+// which does not allocate either.
+
+// AppendCursorPos appends the escape sequence returned by CursorPos to b and
+// returns the extended buffer.
+func AppendCursorPos(b []byte, x, y int) []byte {
+	b = append(b, Esc...)
+	b = strconv.AppendInt(b, int64(y+1), 10)
+	b = append(b, ';')
+	b = strconv.AppendInt(b, int64(x+1), 10)
+	return append(b, 'H')
+}
+
+// WriteCursorPos writes the escape sequence returned by CursorPos to w.
+func WriteCursorPos(w io.Writer, x, y int) (int, error) {
+	return writeAppended(w, func(b []byte) []byte { return AppendCursorPos(b, x, y) })
+}
+
+// AppendCursorMove appends the escape sequence returned by CursorMove to b
+// and returns the extended buffer.
+func AppendCursorMove(b []byte, x, y int) []byte {
+	if x < 0 {
+		b = appendCSI(b, -x, 'D')
+	} else if x > 0 {
+		b = appendCSI(b, x, 'C')
+	}
+	if y < 0 {
+		b = appendCSI(b, -y, 'A')
+	} else if y > 0 {
+		b = appendCSI(b, y, 'B')
+	}
+	return b
+}
+
+// WriteCursorMove writes the escape sequence returned by CursorMove to w.
+func WriteCursorMove(w io.Writer, x, y int) (int, error) {
+	if x == 0 && y == 0 {
+		return 0, nil
+	}
+	return writeAppended(w, func(b []byte) []byte { return AppendCursorMove(b, x, y) })
+}
+
+// AppendTextEraseChars appends the escape sequence returned by TextEraseChars
+// to b and returns the extended buffer.
+func AppendTextEraseChars(b []byte, n int) []byte {
+	return appendCSI(b, n, 'X')
+}
+
+// WriteTextEraseChars writes the escape sequence returned by TextEraseChars
+// to w.
+func WriteTextEraseChars(w io.Writer, n int) (int, error) {
+	return writeAppended(w, func(b []byte) []byte { return AppendTextEraseChars(b, n) })
+}
+
+// appendCSI appends a control sequence with a single numeric parameter.
+func appendCSI(b []byte, n int, final byte) []byte {
+	b = append(b, Esc...)
+	b = strconv.AppendInt(b, int64(n), 10)
+	return append(b, final)
+}
+
+// writeAppended writes to w the sequence that appendSeq appends to an empty
+// buffer. Buffered writers, such as bufio.Writer and bytes.Buffer, lend their
+// free space, so that the sequence is built in place; for other writers, it
+// is built in a pooled buffer.
+func writeAppended(w io.Writer, appendSeq func(b []byte) []byte) (int, error) {
+	if ab, ok := w.(interface{ AvailableBuffer() []byte }); ok {
+		return w.Write(appendSeq(ab.AvailableBuffer()))
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	buf.Grow(64)
+	return w.Write(appendSeq(buf.AvailableBuffer()))
+}
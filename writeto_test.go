@@ -0,0 +1,132 @@
+package escapes
+
+import (
+	"bufio"
+	"io"
+	"testing"
+)
+
+func TestAppendForms(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want string
+	}{
+		{"CursorPos", string(AppendCursorPos(nil, 3, 9)), CursorPos(3, 9)},
+		{"CursorMove left up", string(AppendCursorMove(nil, -2, -5)), CursorMove(-2, -5)},
+		{"CursorMove right down", string(AppendCursorMove(nil, 4, 1)), CursorMove(4, 1)},
+		{"CursorMove none", string(AppendCursorMove(nil, 0, 0)), CursorMove(0, 0)},
+		{"TextEraseChars", string(AppendTextEraseChars(nil, 12)), TextEraseChars(12)},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestStyleAppendSequence(t *testing.T) {
+	defer SetColorLevel(int(CurrentProfile()))
+	styles := []Style{
+		{},
+		{Attrs: AttrBold | AttrUnderline},
+		{Fg: ANSIColor(9), Bg: IndexedColor(200)},
+		{Fg: RGB(1, 2, 3), Bg: RGB(250, 128, 0), Attrs: AttrItalic},
+	}
+	for level := 0; level <= 3; level++ {
+		SetColorLevel(level)
+		for _, s := range styles {
+			if got, want := string(s.AppendSequence([]byte("x"))), "x"+s.Sequence(); got != want {
+				t.Errorf("level %d: %+v.AppendSequence = %q, want %q", level, s, got, want)
+			}
+		}
+	}
+}
+
+// The benchmarks compare writing a cursor movement and a style to a buffered
+// writer with the Write forms and with the string forms.
+
+var benchStyle = Style{Fg: RGB(250, 128, 0), Bg: IndexedColor(17), Attrs: AttrBold}
+
+func BenchmarkWriteCursorPos(b *testing.B) {
+	w := bufio.NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		WriteCursorPos(w, i%200, i%50)
+	}
+}
+
+func BenchmarkWriteCursorPosUnbuffered(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		WriteCursorPos(io.Discard, i%200, i%50)
+	}
+}
+
+func BenchmarkCursorPosString(b *testing.B) {
+	w := bufio.NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		io.WriteString(w, CursorPos(i%200, i%50))
+	}
+}
+
+func BenchmarkWriteCursorMove(b *testing.B) {
+	w := bufio.NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		WriteCursorMove(w, i%200-100, i%50-25)
+	}
+}
+
+func BenchmarkCursorMoveString(b *testing.B) {
+	w := bufio.NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		io.WriteString(w, CursorMove(i%200-100, i%50-25))
+	}
+}
+
+func BenchmarkWriteTextEraseChars(b *testing.B) {
+	w := bufio.NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		WriteTextEraseChars(w, i%200)
+	}
+}
+
+func BenchmarkTextEraseCharsString(b *testing.B) {
+	w := bufio.NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		io.WriteString(w, TextEraseChars(i%200))
+	}
+}
+
+func BenchmarkStyleWriteTo(b *testing.B) {
+	defer SetColorLevel(int(CurrentProfile()))
+	SetColorLevel(3)
+	w := bufio.NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchStyle.WriteTo(w)
+	}
+}
+
+func BenchmarkStyleWriteToUnbuffered(b *testing.B) {
+	defer SetColorLevel(int(CurrentProfile()))
+	SetColorLevel(3)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchStyle.WriteTo(io.Discard)
+	}
+}
+
+func BenchmarkStyleSequence(b *testing.B) {
+	defer SetColorLevel(int(CurrentProfile()))
+	SetColorLevel(3)
+	w := bufio.NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		io.WriteString(w, benchStyle.Sequence())
+	}
+}